@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `index_object_audit_serial`;
+DROP TABLE IF EXISTS `object_audit`;
+
+DROP INDEX IF EXISTS `index_object_instance_serial`;
+ALTER TABLE `object_instances` DROP COLUMN `serial`;
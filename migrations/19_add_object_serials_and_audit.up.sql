@@ -0,0 +1,28 @@
+ALTER TABLE `object_instances` ADD COLUMN `serial` VARCHAR(32) NULL DEFAULT NULL;
+
+/* Backfill existing instances so every persisted object can be traced */
+UPDATE
+    `object_instances`
+SET
+    `serial` = lower(hex(randomblob(8)))
+WHERE
+    `serial` IS NULL;
+
+/* Deliberately not unique: duplicated serials are what the world check reports */
+CREATE INDEX `index_object_instance_serial` ON object_instances(`serial`);
+
+CREATE TABLE object_audit (
+    `id` INTEGER PRIMARY KEY,
+
+    `serial` VARCHAR(32) NOT NULL,
+    `object_id` BIGINT NULL,
+    `event` VARCHAR(32) NOT NULL,
+
+    `from_owner` VARCHAR(255) NULL DEFAULT NULL,
+    `to_owner` VARCHAR(255) NULL DEFAULT NULL,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX `index_object_audit_serial` ON object_audit(`serial`);
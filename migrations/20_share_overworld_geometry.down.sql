@@ -0,0 +1,28 @@
+UPDATE
+    `scripts`
+SET
+    `script` = REPLACE(`script`, 'const { drawFilledRect } = require(''lib/geometry'');
+
+', 'function drawFilledRect(d, x, y, w, h, t, f) {
+  let j, s;
+
+  for (j = y; j < y + h; j++) {
+    for (s = x; s < x + w; s++) {
+      d[j][s] = f;
+    }
+  }
+
+  for (s = x; s <= x + w; s++) {
+    d[y][s] = t;
+    d[y + h][s] = t;
+  }
+
+  for (j = y; j < y + h; j++) {
+    d[j][x] = t;
+    d[j][x + w] = t;
+  }
+}
+
+')
+WHERE
+    `name` = 'overworld';
@@ -0,0 +1,29 @@
+/* The overworld script shares drawFilledRect from scripts/lib/geometry.js instead of defining its own copy */
+UPDATE
+    `scripts`
+SET
+    `script` = REPLACE(`script`, 'function drawFilledRect(d, x, y, w, h, t, f) {
+  let j, s;
+
+  for (j = y; j < y + h; j++) {
+    for (s = x; s < x + w; s++) {
+      d[j][s] = f;
+    }
+  }
+
+  for (s = x; s <= x + w; s++) {
+    d[y][s] = t;
+    d[y + h][s] = t;
+  }
+
+  for (j = y; j < y + h; j++) {
+    d[j][x] = t;
+    d[j][x + w] = t;
+  }
+}
+
+', 'const { drawFilledRect } = require(''lib/geometry'');
+
+')
+WHERE
+    `name` = 'overworld';
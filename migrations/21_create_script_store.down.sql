@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS `soft_delete_script_store_for_player_character`;
+DROP TRIGGER IF EXISTS `delete_script_store_for_player_character`;
+DROP TRIGGER IF EXISTS `rename_script_store_for_script`;
+DROP TRIGGER IF EXISTS `delete_script_store_for_script`;
+
+DROP TABLE IF EXISTS `script_store`;
@@ -0,0 +1,63 @@
+CREATE TABLE script_store (
+    `id` INTEGER PRIMARY KEY,
+
+    /* One of 'global', 'script' or 'character' */
+    `scope` VARCHAR(16) NOT NULL,
+
+    /* Script name or player character id, empty for the global scope */
+    `owner` VARCHAR(255) NOT NULL DEFAULT '',
+
+    `key` VARCHAR(255) NOT NULL,
+    `value` TEXT NOT NULL,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(`scope`, `owner`, `key`)
+);
+
+/* Scoped entries go away with their owner however it is removed */
+CREATE TRIGGER `delete_script_store_for_script` AFTER DELETE ON scripts
+BEGIN
+    DELETE FROM
+        script_store
+    WHERE
+        `scope` = 'script'
+    AND
+        `owner` = OLD.name;
+END;
+
+CREATE TRIGGER `rename_script_store_for_script` AFTER UPDATE OF name ON scripts
+WHEN OLD.name <> NEW.name
+BEGIN
+    UPDATE
+        script_store
+    SET
+        `owner` = NEW.name
+    WHERE
+        `scope` = 'script'
+    AND
+        `owner` = OLD.name;
+END;
+
+CREATE TRIGGER `delete_script_store_for_player_character` AFTER DELETE ON player_characters
+BEGIN
+    DELETE FROM
+        script_store
+    WHERE
+        `scope` = 'character'
+    AND
+        `owner` = CAST(OLD.id AS TEXT);
+END;
+
+CREATE TRIGGER `soft_delete_script_store_for_player_character` AFTER UPDATE OF deleted_at ON player_characters
+WHEN NEW.deleted_at IS NOT NULL
+BEGIN
+    DELETE FROM
+        script_store
+    WHERE
+        `scope` = 'character'
+    AND
+        `owner` = CAST(NEW.id AS TEXT);
+END;
@@ -0,0 +1,13 @@
+CREATE TABLE script_schedules (
+    `id` INTEGER PRIMARY KEY,
+    `name` VARCHAR(255) NOT NULL UNIQUE,
+    `cron_expression` VARCHAR(255) NOT NULL,
+    `paused` BOOLEAN NOT NULL DEFAULT 0,
+
+    `next_run_at` DATETIME NULL DEFAULT NULL,
+    `last_run_at` DATETIME NULL DEFAULT NULL,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
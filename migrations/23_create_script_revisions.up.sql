@@ -0,0 +1,23 @@
+CREATE TABLE script_revisions (
+    `id` INTEGER PRIMARY KEY,
+    `script_id` BIGINT NOT NULL,
+    `revision` INT NOT NULL,
+    `author` VARCHAR(64) NULL DEFAULT NULL,
+    `script` TEXT,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE,
+    UNIQUE (script_id, revision)
+);
+
+/* Existing scripts start their history from the source they have now */
+INSERT INTO
+    script_revisions(script_id, revision, script, created_at)
+SELECT
+    id,
+    1,
+    script,
+    updated_at
+FROM
+    scripts;
@@ -0,0 +1,7 @@
+ALTER TABLE `mobiles` DROP COLUMN `suscept_flags`;
+ALTER TABLE `mobiles` DROP COLUMN `immune_flags`;
+ALTER TABLE `mobiles` DROP COLUMN `resist_flags`;
+
+ALTER TABLE `races` DROP COLUMN `suscept_flags`;
+ALTER TABLE `races` DROP COLUMN `immune_flags`;
+ALTER TABLE `races` DROP COLUMN `resist_flags`;
@@ -0,0 +1,12 @@
+/* RESIST_*, IMMUNE_* and SUSCEPT_* bitmasks, see DamageFlagTable */
+ALTER TABLE `races` ADD COLUMN `resist_flags` INT NOT NULL DEFAULT 0;
+ALTER TABLE `races` ADD COLUMN `immune_flags` INT NOT NULL DEFAULT 0;
+ALTER TABLE `races` ADD COLUMN `suscept_flags` INT NOT NULL DEFAULT 0;
+
+ALTER TABLE `mobiles` ADD COLUMN `resist_flags` INT NOT NULL DEFAULT 0;
+ALTER TABLE `mobiles` ADD COLUMN `immune_flags` INT NOT NULL DEFAULT 0;
+ALTER TABLE `mobiles` ADD COLUMN `suscept_flags` INT NOT NULL DEFAULT 0;
+
+/* Dwarves shrug off poison; ogres shrug off blows but burn easily */
+UPDATE `races` SET `resist_flags` = 16 WHERE `name` = 'dwarf';
+UPDATE `races` SET `resist_flags` = 1, `suscept_flags` = 2 WHERE `name` = 'ogre';
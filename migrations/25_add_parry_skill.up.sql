@@ -0,0 +1,3 @@
+/* Parrying needs a wielded weapon; warriors learn it at level 15 */
+INSERT INTO skills(id, name, type, intent) VALUES (21, 'parry', 'passive', 'none');
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (25, 1, 21, 15, 10, 50);
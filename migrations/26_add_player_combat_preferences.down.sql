@@ -0,0 +1,2 @@
+ALTER TABLE `player_characters` DROP COLUMN `preference_flags`;
+ALTER TABLE `player_characters` DROP COLUMN `wimpy`;
@@ -0,0 +1,3 @@
+/* PREF_* toggles; autoassist (8) is on unless a player turns it off */
+ALTER TABLE `player_characters` ADD COLUMN `wimpy` INT NOT NULL DEFAULT 0 CHECK (`wimpy` >= 0);
+ALTER TABLE `player_characters` ADD COLUMN `preference_flags` INT NOT NULL DEFAULT 8;
@@ -0,0 +1,3 @@
+DELETE FROM pc_skill_proficiency WHERE skill_id = 22;
+DELETE FROM job_skill WHERE id = 26;
+DELETE FROM skills WHERE id = 22;
@@ -0,0 +1,3 @@
+/* Warriors learn to rescue their group from attackers at level 5 */
+INSERT INTO skills(id, name, type, intent) VALUES (22, 'rescue', 'skill', 'none');
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (26, 1, 22, 5, 5, 50);
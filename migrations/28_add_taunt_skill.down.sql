@@ -0,0 +1,3 @@
+DELETE FROM pc_skill_proficiency WHERE skill_id = 23;
+DELETE FROM job_skill WHERE id = 27;
+DELETE FROM skills WHERE id = 23;
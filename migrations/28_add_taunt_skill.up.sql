@@ -0,0 +1,3 @@
+/* Warriors learn to hold a mobile's attention at level 10 */
+INSERT INTO skills(id, name, type, intent) VALUES (23, 'taunt', 'skill', 'offensive');
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (27, 1, 23, 10, 5, 50);
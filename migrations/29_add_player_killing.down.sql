@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS index_pkills_victim_id;
+DROP INDEX IF EXISTS index_pkills_killer_id;
+DROP TABLE pkills;
+
+ALTER TABLE `districts` DROP COLUMN `flags`;
+
+ALTER TABLE `player_characters` DROP COLUMN `thief`;
+ALTER TABLE `player_characters` DROP COLUMN `killer`;
+ALTER TABLE `player_characters` DROP COLUMN `pk_cooldown`;
+ALTER TABLE `player_characters` DROP COLUMN `pk_consent`;
@@ -0,0 +1,28 @@
+/* Consent to player killing, and seconds of play left on its cooldown and the KILLER and THIEF flags */
+ALTER TABLE `player_characters` ADD COLUMN `pk_consent` BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE `player_characters` ADD COLUMN `pk_cooldown` INT NOT NULL DEFAULT 0 CHECK (`pk_cooldown` >= 0);
+ALTER TABLE `player_characters` ADD COLUMN `killer` INT NOT NULL DEFAULT 0 CHECK (`killer` >= 0);
+ALTER TABLE `player_characters` ADD COLUMN `thief` INT NOT NULL DEFAULT 0 CHECK (`thief` >= 0);
+
+/* DISTRICT_* flags; pk (1) makes a district an arena */
+ALTER TABLE `districts` ADD COLUMN `flags` INT NOT NULL DEFAULT 0;
+
+CREATE TABLE pkills (
+    `id` INTEGER PRIMARY KEY,
+
+    `killer_id` BIGINT NOT NULL,
+    `victim_id` BIGINT NOT NULL,
+    `killer_level` INT NOT NULL,
+    `victim_level` INT NOT NULL,
+    `room_id` BIGINT NOT NULL,
+    `arena` BOOLEAN NOT NULL DEFAULT 0,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (killer_id) REFERENCES player_characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (victim_id) REFERENCES player_characters(id) ON DELETE CASCADE
+);
+
+CREATE INDEX index_pkills_killer_id ON pkills(killer_id);
+CREATE INDEX index_pkills_victim_id ON pkills(victim_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS index_player_corpses_player_character_id;
+DROP INDEX IF EXISTS index_player_corpses_object_instance_id;
+DROP TABLE player_corpses;
@@ -0,0 +1,23 @@
+/* A fallen player's corpse; its contents are the object instances inside it */
+CREATE TABLE player_corpses (
+    `id` INTEGER PRIMARY KEY,
+
+    `object_instance_id` BIGINT NOT NULL,
+    `player_character_id` BIGINT NOT NULL,
+
+    /* Where the corpse lies, as for player_characters */
+    `room_id` BIGINT NOT NULL,
+    `plane_id` BIGINT NULL DEFAULT NULL,
+    `plane_x` INT NULL DEFAULT NULL,
+    `plane_y` INT NULL DEFAULT NULL,
+    `plane_z` INT NULL DEFAULT NULL,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (object_instance_id) REFERENCES object_instances(id) ON DELETE CASCADE,
+    FOREIGN KEY (player_character_id) REFERENCES player_characters(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX index_player_corpses_object_instance_id ON player_corpses(object_instance_id);
+CREATE INDEX index_player_corpses_player_character_id ON player_corpses(player_character_id);
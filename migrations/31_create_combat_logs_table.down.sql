@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS index_combat_logs_ended_at;
+DROP TABLE combat_logs;
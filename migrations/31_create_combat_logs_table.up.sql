@@ -0,0 +1,16 @@
+/* A finished fight, kept for balancing when combat logs are persisted */
+CREATE TABLE combat_logs (
+    `id` INTEGER PRIMARY KEY,
+
+    `room_id` BIGINT NULL DEFAULT NULL,
+    `rounds` INT NOT NULL DEFAULT 0,
+
+    /* The participants and events as exported by the combatlog command */
+    `data` TEXT NOT NULL,
+
+    /* Timestamps */
+    `started_at` DATETIME NOT NULL,
+    `ended_at` DATETIME NOT NULL
+);
+
+CREATE INDEX index_combat_logs_ended_at ON combat_logs(ended_at);
@@ -0,0 +1,6 @@
+DELETE FROM pc_skill_proficiency WHERE skill_id IN (24, 25);
+DELETE FROM job_skill WHERE id IN (28, 29, 30);
+DELETE FROM skills WHERE id IN (24, 25);
+
+UPDATE terrain SET flags = 0 WHERE id IN (19, 20);
+UPDATE terrain SET flags = 1 WHERE id = 8;
@@ -0,0 +1,10 @@
+/* The ocean is deep water rather than impassable; mountains are rugged climbs */
+UPDATE terrain SET flags = 4 WHERE id = 8;
+UPDATE terrain SET flags = 8 WHERE id IN (19, 20);
+
+/* Warriors and thieves learn to swim at level 10; mages learn to fly at level 15 */
+INSERT INTO skills(id, name, type, intent) VALUES (24, 'swim', 'passive', 'none');
+INSERT INTO skills(id, name, type, intent) VALUES (25, 'fly', 'spell', 'curative');
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (28, 1, 24, 10, 5, 50);
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (29, 2, 24, 10, 5, 50);
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (30, 3, 25, 15, 4, 100);
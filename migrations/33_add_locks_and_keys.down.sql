@@ -0,0 +1,10 @@
+DELETE FROM pc_skill_proficiency WHERE skill_id = 26;
+DELETE FROM job_skill WHERE id = 31;
+DELETE FROM skills WHERE id = 26;
+
+DELETE FROM objects WHERE id = 13;
+UPDATE objects SET value_3 = 20, value_4 = 20 WHERE id = 11;
+UPDATE object_instances SET value_3 = 20, value_4 = 20 WHERE parent_id = 11;
+
+ALTER TABLE exits DROP COLUMN `lock_difficulty`;
+ALTER TABLE exits DROP COLUMN `key_id`;
@@ -0,0 +1,14 @@
+/* Doors name the object that unlocks them; containers keep theirs in value_3, with the lock's difficulty in value_4 */
+ALTER TABLE exits ADD COLUMN `key_id` BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE exits ADD COLUMN `lock_difficulty` INT NOT NULL DEFAULT 0;
+
+/* The seed chest has no lock, nor do the chests already in the world */
+UPDATE objects SET value_3 = 0, value_4 = 0 WHERE id = 11;
+UPDATE object_instances SET value_3 = 0, value_4 = 0 WHERE parent_id = 11;
+
+/* A plain key for builders to hang on their doors and chests */
+INSERT INTO objects(id, zone_id, name, short_description, long_description, description, flags, item_type, value_1, value_2, value_3, value_4) VALUES (13, 1, 'brass key', 'a brass key', 'A small brass key has been dropped here.', 'A small brass key with a plain, square bow.', 1, 'key', 0, 0, 0, 0);
+
+/* Thieves learn to pick locks at level 5 */
+INSERT INTO skills(id, name, type, intent) VALUES (26, 'pick', 'skill', 'none');
+INSERT INTO job_skill(id, job_id, skill_id, level, complexity, cost) VALUES (31, 2, 26, 5, 5, 5);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS index_shop_object_instance_shop_id;
+DROP INDEX IF EXISTS index_shop_object_instance_object_instance_id;
+DROP TABLE shop_object_instance;
+
+ALTER TABLE shop_object DROP COLUMN `stock`;
+
+ALTER TABLE shops DROP COLUMN `close_hour`;
+ALTER TABLE shops DROP COLUMN `open_hour`;
+ALTER TABLE shops DROP COLUMN `sell_margin`;
+ALTER TABLE shops DROP COLUMN `buy_margin`;
+ALTER TABLE shops DROP COLUMN `buy_types`;
@@ -0,0 +1,32 @@
+/* Item types the keeper buys from players, the percentages of an item's value it pays for them and charges to resell them, and its opening hours */
+ALTER TABLE shops ADD COLUMN `buy_types` VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE shops ADD COLUMN `buy_margin` INT NOT NULL DEFAULT 50;
+ALTER TABLE shops ADD COLUMN `sell_margin` INT NOT NULL DEFAULT 100;
+ALTER TABLE shops ADD COLUMN `open_hour` INT NOT NULL DEFAULT 0;
+ALTER TABLE shops ADD COLUMN `close_hour` INT NOT NULL DEFAULT 0;
+
+/* How many of a listing the keeper restocks to when its zone resets, or 0 for an endless supply */
+ALTER TABLE shop_object ADD COLUMN `stock` INT NOT NULL DEFAULT 0;
+
+/* Goods players have sold to a shop, held until someone buys them back */
+CREATE TABLE shop_object_instance (
+    `id` INTEGER PRIMARY KEY,
+
+    `shop_id` BIGINT NOT NULL,
+    `object_instance_id` BIGINT NOT NULL,
+
+    /* Timestamps */
+    `created_at` DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (shop_id) REFERENCES shops(id) ON DELETE CASCADE,
+    FOREIGN KEY (object_instance_id) REFERENCES object_instances(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX index_shop_object_instance_object_instance_id ON shop_object_instance(object_instance_id);
+CREATE INDEX index_shop_object_instance_shop_id ON shop_object_instance(shop_id);
+
+/* The astral shop keeper deals in arms, armour and potions; its rarer wares run out */
+UPDATE shops SET buy_types = 'armor weapon potion' WHERE id = 1;
+UPDATE shop_object SET stock = 1 WHERE id = 7;
+UPDATE shop_object SET stock = 3 WHERE id IN (3, 4, 5, 6);
+UPDATE shop_object SET stock = 10 WHERE id = 2;
@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+const RoomCell = 6;
+const MobileSlime = 1;
+
+test('fighting is refused in safe rooms', () => {
+    const limbo = fixture.room(1);
+    const fighter = fixture.player('Pacifist', { room: limbo });
+    fixture.mobile(MobileSlime, limbo);
+
+    assert.contains(fixture.command(fighter, 'kill slime'), 'You cannot do that here.');
+    assert.equal(fighter.fighting, null);
+});
+
+test('combat rounds are fought until the slime dies', () => {
+    const cell = fixture.room(RoomCell);
+    const fighter = fixture.player('Gladiator', { room: cell, level: 20 });
+
+    fighter.health = fighter.maxHealth = 500;
+
+    assert.contains(fixture.command(fighter, 'kill slime'), 'You begin attacking an animated slime!');
+    assert(fighter.fighting !== null, 'the fight did not start');
+
+    for (let round = 0; round < 50 && fighter.fighting; round++) {
+        fixture.tick(2);
+    }
+
+    assert.equal(fighter.fighting, null, 'the fight never ended');
+    assert.equal(cell.characters.count, 1, 'the slime is still in the cell');
+    assert(fighter.health < 500, 'the slime never fought back');
+});
+
+test('combatants cannot walk away from a fight', () => {
+    const cell = fixture.room(RoomCell);
+    const hall = fixture.createRoom('A Hall', 'An empty hall.');
+    const fighter = fixture.player('Chaser', { room: cell });
+    const target = fixture.player('Runner', { room: cell });
+
+    fighter.pkConsent = target.pkConsent = true;
+    fixture.link(cell, 'up', hall);
+    fixture.command(fighter, 'kill runner');
+
+    assert.contains(fixture.command(target, 'up'), 'You are in the middle of fighting!');
+    assert.equal(target.room.id, RoomCell);
+
+    fixture.tick(2);
+    assert.contains(fixture.output(target), 'Chaser');
+});
+
+test('players flee once hurt below their wimpy', () => {
+    const cell = fixture.room(RoomCell);
+    const hall = fixture.createRoom('A Hall', 'An empty hall.');
+    const fighter = fixture.player('Coward', { room: cell });
+    const slime = fixture.mobile(MobileSlime, cell);
+
+    fixture.link(cell, 'up', hall);
+    slime.health = slime.maxHealth = 10000;
+    fighter.maxHealth = 200;
+    fighter.health = 80;
+
+    assert.contains(fixture.command(fighter, 'wimpy 90'), 'You will now flee when your health drops below 90.');
+    assert.contains(fixture.command(fighter, 'wimpy 150'), 'your wimpy can be at most 100');
+
+    fixture.command(fighter, 'kill slime');
+
+    for (let round = 0; round < 50 && fighter.room.isEqual(cell); round++) {
+        fixture.tick(2);
+    }
+
+    assert.equal(fighter.room.id, hall.id, 'the coward never fled');
+    assert.equal(fighter.fighting, null);
+    assert.contains(fixture.output(fighter), 'You panic and flee up!');
+});
+
+test('autogold takes the coins from a kill', () => {
+    const cell = fixture.room(RoomCell);
+    const fighter = fixture.player('Miser', { room: cell, level: 20 });
+    const slime = fixture.mobile(MobileSlime, cell);
+
+    fighter.health = fighter.maxHealth = 500;
+    slime.gold = 25;
+
+    assert.contains(fixture.command(fighter, 'autogold'), 'You will now take the gold from the corpses of your kills.');
+    assert.contains(fixture.command(fighter, 'auto'), 'autogold     on');
+
+    const gold = fighter.gold;
+    fixture.command(fighter, 'kill slime');
+
+    for (let round = 0; round < 50 && fighter.fighting; round++) {
+        fixture.tick(2);
+    }
+
+    assert.equal(fighter.gold, gold + 25);
+    assert.contains(fixture.output(fighter), 'You take 25 gold coins');
+});
+
+test('group members assist and rescue each other', () => {
+    const cell = fixture.room(RoomCell);
+    const tank = fixture.player('Tank', { room: cell, level: 20, skills: { rescue: 100 } });
+    const healer = fixture.player('Healer', { room: cell });
+    const slime = fixture.mobile(MobileSlime, cell);
+
+    slime.health = slime.maxHealth = 10000;
+
+    fixture.command(healer, 'follow tank');
+    fixture.command(tank, 'group healer');
+    fixture.output(tank);
+
+    fixture.command(slime, 'kill healer');
+    assert.equal(healer.fighting.id, slime.id);
+    assert(tank.fighting && tank.fighting.isEqual(slime), 'the tank did not assist');
+    assert.contains(fixture.output(tank), 'in defense of Healer');
+
+    assert.contains(fixture.command(tank, 'rescue healer'), 'You leap in front of Healer and rescue them!');
+    assert(slime.fighting.isEqual(tank), 'the slime is still fighting the healer');
+});
+
+test('a taunt pulls a mobile off the healer', () => {
+    const cell = fixture.room(RoomCell);
+    const tank = fixture.player('Guardian', { room: cell, level: 20, skills: { taunt: 100 } });
+    const healer = fixture.player('Cleric', { room: cell });
+    const slime = fixture.mobile(MobileSlime, cell);
+
+    slime.health = slime.maxHealth = 10000;
+
+    fixture.command(healer, 'follow guardian');
+    fixture.command(tank, 'group cleric');
+    fixture.command(slime, 'kill cleric');
+    fixture.output(tank);
+
+    assert.contains(fixture.command(tank, 'taunt'), 'drawing its fury');
+    assert(slime.threat(tank) > slime.threat(healer), 'the taunt did not outdo the healer');
+
+    fixture.tick(3);
+    assert(slime.fighting.isEqual(tank), 'the slime is still fighting the healer');
+    assert.contains(fixture.output(healer), 'turns to attack Guardian');
+});
+
+test('players must consent to fight each other, and kills make the leaderboard', () => {
+    const cell = fixture.room(RoomCell);
+    const duelist = fixture.player('Duelist', { room: cell, level: 20 });
+    const rival = fixture.player('Rival', { room: cell, level: 20 });
+
+    assert.contains(fixture.command(duelist, 'kill rival'), 'You have not chosen to fight other players');
+    fixture.command(duelist, 'pk on');
+    assert.contains(fixture.command(duelist, 'kill rival'), 'Rival has not chosen to fight other players');
+    assert.equal(duelist.fighting, null);
+
+    fixture.command(rival, 'pk on');
+    assert.contains(fixture.command(rival, 'pk off'), 'You must wait 30 minutes');
+
+    assert.contains(fixture.command(duelist, 'kill rival'), 'You begin attacking Rival!');
+    assert.equal(duelist.killer, 0);
+    assert(rival.fighting && rival.fighting.isEqual(duelist), 'the rival did not fight back');
+
+    rival.health = 1;
+    fixture.tick(2);
+    assert.contains(fixture.output(duelist), 'Rival has been slain!');
+
+    assert.contains(fixture.command(duelist, 'pkills'), 'Duelist');
+    assert.contains(fixture.command(rival, 'pkills'), 'been slain by players 1 times');
+});
+
+test('starting a fight with a much weaker player makes a killer', () => {
+    const cell = fixture.room(RoomCell);
+    const bully = fixture.player('Bully', { room: cell, level: 40 });
+    const novice = fixture.player('Novice', { room: cell, level: 20 });
+
+    fixture.command(bully, 'pk on');
+    fixture.command(novice, 'pk on');
+
+    assert.contains(fixture.command(bully, 'kill novice'), 'You are now a KILLER!');
+    assert(bully.killer > 0, 'the bully was not flagged');
+    assert.equal(novice.killer, 0);
+});
+
+test('only the owner and their group may loot a player corpse, and healers retrieve it', () => {
+    const cell = fixture.room(RoomCell);
+    const fallen = fixture.player('Fallen', { room: cell, level: 1 });
+    const scavenger = fixture.player('Scavenger', { room: cell });
+    const slime = fixture.mobile(MobileSlime, cell);
+
+    fixture.object(6, fallen);
+    fallen.gold = 35;
+    fallen.health = 1;
+    slime.health = slime.maxHealth = 10000;
+
+    fixture.command(slime, 'kill fallen');
+    fixture.tick(2);
+    assert.contains(fixture.output(scavenger), 'Fallen has been slain!');
+    assert.equal(fallen.gold, 0);
+
+    assert.contains(fixture.command(scavenger, 'get cutlass fallen'), 'You may not loot the corpse of Fallen.');
+
+    const limbo = fixture.room(1);
+    const healer = fixture.mobile(MobileSlime, limbo);
+    healer.flags |= Golem.CharacterFlags.CHAR_HEALER;
+
+    assert.contains(fixture.command(fallen, 'retrieve'), 'takes the coins from your corpse');
+    assert.equal(fallen.gold, 35 - 20);
+    fixture.command(fallen, 'stand');
+    assert.contains(fixture.command(fallen, 'get cutlass fallen'), 'swashbuckler');
+    assert.contains(fixture.command(fallen, 'retrieve'), 'I can find no corpse of yours');
+});
+
+test('admins can review every fight in the combat log', () => {
+    const arena = fixture.createRoom('A Sparring Ring', 'Sawdust covers the floor.');
+    const admin = fixture.player('Arbiter', { room: arena, level: 60 });
+    const slime = fixture.mobile(MobileSlime, arena);
+
+    slime.health = 1;
+    fixture.command(admin, 'kill slime');
+    fixture.tick(4);
+    assert.equal(admin.fighting, null, 'the fight never ended');
+
+    const logs = Golem.game.combatLogs();
+    const fight = logs[logs.length - 1];
+    assert.equal(fight.participants[0].name, 'Arbiter');
+
+    assert.contains(fixture.command(admin, 'combatlog'), 'Arbiter');
+
+    const detail = fixture.command(admin, 'combatlog ' + fight.id);
+    assert.contains(detail, 'An animated slime is slain by Arbiter');
+    assert.contains(detail, 'DPS');
+});
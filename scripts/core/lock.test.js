@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+const ObjectBrassKey = 13;
+const ObjectTreasureChest = 11;
+const DoorClosed = Golem.ExitFlags.EXIT_IS_DOOR | Golem.ExitFlags.EXIT_CLOSED;
+
+function lockedDoor() {
+    const vault = fixture.createRoom('A Vault', 'Iron walls surround you.');
+    const antechamber = fixture.createRoom('An Antechamber', 'A heavy door leads north.');
+
+    fixture.link(antechamber, 'north', vault);
+
+    for (const exit of [antechamber.exit[Golem.Directions.DirectionNorth], vault.exit[Golem.Directions.DirectionSouth]]) {
+        exit.flags = DoorClosed | Golem.ExitFlags.EXIT_LOCKED;
+        exit.key = ObjectBrassKey;
+    }
+
+    return [antechamber, vault];
+}
+
+test('a key locks and unlocks both sides of a door', () => {
+    const [antechamber, vault] = lockedDoor();
+    const keeper = fixture.player('Keeper', { room: antechamber });
+    const guard = fixture.player('Vaultguard', { room: vault });
+
+    assert.contains(fixture.command(keeper, 'unlock north'), 'You lack the key.');
+
+    fixture.object(ObjectBrassKey, keeper);
+    assert.contains(fixture.command(keeper, 'unlock north'), 'You unlock the door north.');
+    assert.contains(fixture.output(guard), 'You hear a click from the south door.');
+    assert.equal(vault.exit[Golem.Directions.DirectionSouth].flags & Golem.ExitFlags.EXIT_LOCKED, 0);
+
+    assert.contains(fixture.command(keeper, 'open north'), 'You open the door.');
+    assert.contains(fixture.command(keeper, 'lock north'), "It isn't closed.");
+
+    fixture.command(keeper, 'close north');
+    assert.contains(fixture.command(keeper, 'lock north'), 'You lock the door north.');
+    assert(vault.exit[Golem.Directions.DirectionSouth].flags & Golem.ExitFlags.EXIT_LOCKED, 'the far side was not locked');
+});
+
+test('doors without a keyhole cannot be locked', () => {
+    const [antechamber] = lockedDoor();
+    const tinkerer = fixture.player('Tinkerer', { room: antechamber });
+
+    antechamber.exit[Golem.Directions.DirectionNorth].flags = DoorClosed;
+    antechamber.exit[Golem.Directions.DirectionNorth].key = 0;
+
+    assert.contains(fixture.command(tinkerer, 'lock north'), 'It has no keyhole.');
+    assert.contains(fixture.command(tinkerer, 'lock east'), 'You see no lock like that here.');
+});
+
+test('thieves pick locks unless they are too hard', () => {
+    const [antechamber, vault] = lockedDoor();
+    const burglar = fixture.player('Burglar', { room: antechamber, level: 5, job: 'thief', skills: { pick: 100 } });
+    const north = antechamber.exit[Golem.Directions.DirectionNorth];
+
+    north.lockDifficulty = 100;
+    assert.contains(fixture.command(burglar, 'pick north'), 'This lock is beyond your craft.');
+
+    north.lockDifficulty = 0;
+    assert.contains(fixture.command(burglar, 'pick north'), 'You pick the lock on the door north.');
+    assert.equal(vault.exit[Golem.Directions.DirectionSouth].flags & Golem.ExitFlags.EXIT_LOCKED, 0);
+});
+
+test('containers lock with their own key', () => {
+    const room = fixture.createRoom('A Counting House', 'Ledgers line the shelves.');
+    const clerk = fixture.player('Clerk', { room: room });
+    const chest = fixture.object(ObjectTreasureChest, room);
+
+    chest.value2 = ObjectBrassKey;
+    fixture.object(ObjectBrassKey, clerk);
+
+    assert.contains(fixture.command(clerk, 'lock chest'), 'You lock a weatherworn treasure chest.');
+    assert.contains(fixture.command(clerk, 'open chest'), "It's locked.");
+    assert.contains(fixture.command(clerk, 'unlock chest'), 'You unlock a weatherworn treasure chest.');
+});
@@ -7,6 +7,7 @@
  */
 function onReload() {
     Golem.clearAllEventHandlers();
+    Golem.clearCombatHooks();
     Golem.clearScriptedCommandHandlers();
     Golem.clearScriptedSkillHandlers();
 }
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+const MobileShopkeeper = 3;
+const ObjectCutlass = 6;
+const ObjectBrassKey = 13;
+
+// listings are shown newest first, so the single pointed hat leads the list
+const ListingPointedHat = 1;
+const ListingCount = 7;
+
+function tradingRoom(name) {
+    const room = fixture.createRoom(name, 'Shelves of curios line the walls.');
+    fixture.mobile(MobileShopkeeper, room);
+
+    return room;
+}
+
+test('limited stock sells out and restocks when the zone resets', () => {
+    const room = tradingRoom('A Hat Shop');
+    const buyer = fixture.player('Milliner', { room: room });
+
+    buyer.gold = 5000;
+
+    assert.contains(fixture.command(buyer, 'buy ' + ListingPointedHat), 'You buy a pointed hat for 1000 gold coins.');
+    assert.contains(fixture.command(buyer, 'buy ' + ListingPointedHat), "I'm sold out of those.");
+    assert.contains(fixture.command(buyer, 'shop'), '(sold out)');
+    assert.equal(buyer.gold, 4000);
+
+    const frequency = room.zone.resetFrequency;
+    room.zone.resetFrequency = 0;
+    fixture.tick(60);
+    room.zone.resetFrequency = frequency;
+
+    assert.contains(fixture.command(buyer, 'buy ' + ListingPointedHat), 'You buy a pointed hat for 1000 gold coins.');
+});
+
+test('keepers buy the goods they deal in and resell them secondhand', () => {
+    const room = tradingRoom('A Pawn Shop');
+    const seller = fixture.player('Pawner', { room: room });
+
+    fixture.object(ObjectCutlass, seller);
+    fixture.object(ObjectBrassKey, seller);
+
+    assert.contains(fixture.command(seller, 'value cutlass'), "I'll give you 100 gold coins for a swashbuckler's cutlass.");
+    assert.contains(fixture.command(seller, 'value key'), "I'm not interested in a brass key.");
+
+    assert.contains(fixture.command(seller, 'sell cutlass'), "You sell a swashbuckler's cutlass for 100 gold coins.");
+    assert.equal(seller.gold, 100);
+    assert.equal(seller.findObjectOnSelf('cutlass'), null);
+
+    const listing = fixture.command(seller, 'shop');
+    assert.contains(listing, 'Secondhand goods');
+    assert.contains(listing, "swashbuckler's cutlass");
+
+    seller.gold = 200;
+    assert.contains(fixture.command(seller, 'buy ' + (ListingCount + 1)), "You buy a swashbuckler's cutlass for 200 gold coins.");
+    assert.equal(seller.gold, 0);
+    assert(seller.findObjectOnSelf('cutlass'), 'the cutlass was not handed back');
+});
+
+test('shops turn customers away outside their hours', () => {
+    const room = tradingRoom('A Night Market');
+    const shopper = fixture.player('Latecomer', { room: room });
+    const shop = shopper.findShopInRoom();
+
+    // two hours ahead, so the clock can't reach opening time during the test
+    const hour = Golem.game.hour();
+    shop.openHour = (hour + 2) % 24;
+    shop.closeHour = (hour + 3) % 24;
+
+    assert.contains(fixture.command(shopper, 'shop'), "Sorry, we're closed.");
+    assert.contains(fixture.command(shopper, 'sell cutlass'), "Sorry, we're closed.");
+
+    shop.openHour = shop.closeHour = 0;
+    assert.contains(fixture.command(shopper, 'shop'), 'a pointed hat');
+});
+
+test('builders edit and save shops with sedit', () => {
+    const room = tradingRoom('A Locksmith');
+    const builder = fixture.player('Shopfitter', { room: room, level: Golem.Levels.LevelBuilder });
+    const shop = builder.findShopInRoom();
+
+    assert.contains(fixture.command(builder, 'sedit add ' + ObjectBrassKey + ' 5 2'), 'Now selling a brass key for 5 gold.');
+    assert.contains(fixture.command(builder, 'sedit buys key'), 'now buys key');
+    assert.contains(fixture.command(builder, 'sedit save'), 'Ok.');
+
+    const key = shop.listingAt(1);
+    assert(key.id > 0, 'the new listing was not saved');
+    assert.equal(key.stock, 2);
+    assert(shop.buys('key'), 'the keeper does not buy keys');
+
+    assert.contains(fixture.command(builder, 'sedit margin buy 150'), 'The keeper pays 100% and resells at 100%.');
+    assert.contains(fixture.command(builder, 'sedit margin sell 80'), 'The keeper pays 80% and resells at 80%.');
+    assert.contains(fixture.command(builder, 'sedit show'), 'a brass key for 5 gold, stock 2/2');
+    fixture.command(builder, 'sedit remove 1');
+    fixture.command(builder, 'sedit buys key');
+    assert.contains(fixture.command(builder, 'sedit save'), 'Ok.');
+    assert.equal(shop.listingAt(1).object.id, 7);
+});
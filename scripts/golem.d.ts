@@ -0,0 +1,1352 @@
+/*
+ * Type declarations for the Golem scripting API.
+ *
+ * Generated by "golem dts" from the Go types exposed to scripts; do not edit.
+ */
+
+declare const module: { exports: any; id: string; filename: string };
+declare const exports: any;
+declare const __filename: string;
+declare const __dirname: string;
+
+declare namespace Golem {
+    const AffectedTypes: {
+        readonly AFFECT_BLINDNESS: number;
+        readonly AFFECT_CHARM: number;
+        readonly AFFECT_DETECT_MAGIC: number;
+        readonly AFFECT_FIRESHIELD: number;
+        readonly AFFECT_FLYING: number;
+        readonly AFFECT_HASTE: number;
+        readonly AFFECT_PARALYSIS: number;
+        readonly AFFECT_POISON: number;
+        readonly AFFECT_SANCTUARY: number;
+        readonly AFFECT_SILENCE: number;
+        readonly AFFECT_SLOW: number;
+    };
+    const CharacterFlags: {
+        readonly CHAR_AGGRESSIVE: number;
+        readonly CHAR_HEALER: number;
+        readonly CHAR_IS_PLAYER: number;
+        readonly CHAR_PRACTICE: number;
+        readonly CHAR_SENTINEL: number;
+        readonly CHAR_SHOPKEEPER: number;
+        readonly CHAR_STAY_AREA: number;
+        readonly CHAR_TRAIN: number;
+    };
+    const Combat: {
+        readonly DamageTypeBash: number;
+        readonly DamageTypeCold: number;
+        readonly DamageTypeExotic: number;
+        readonly DamageTypeFire: number;
+        readonly DamageTypePoison: number;
+        readonly DamageTypeShock: number;
+        readonly DamageTypeSlash: number;
+        readonly DamageTypeStab: number;
+        readonly DamageTypeTrue: number;
+    };
+    const DefaultObjectDecayTtl: number;
+    const Directions: {
+        readonly DirectionDown: number;
+        readonly DirectionEast: number;
+        readonly DirectionNorth: number;
+        readonly DirectionNortheast: number;
+        readonly DirectionNorthwest: number;
+        readonly DirectionSouth: number;
+        readonly DirectionSoutheast: number;
+        readonly DirectionSouthwest: number;
+        readonly DirectionUp: number;
+        readonly DirectionWest: number;
+    };
+    const EffectTypes: {
+        readonly EffectTypeAffected: number;
+        readonly EffectTypeImmunity: number;
+        readonly EffectTypeResist: number;
+        readonly EffectTypeStat: number;
+        readonly EffectTypeSuscept: number;
+    };
+    const ExitFlags: {
+        readonly EXIT_CLOSED: number;
+        readonly EXIT_HIDDEN: number;
+        readonly EXIT_IS_DOOR: number;
+        readonly EXIT_LOCKED: number;
+    };
+    const ExitName: { [key: number]: string };
+    const FurnitureFlags: {
+        readonly FURNITURE_REST_AT: number;
+        readonly FURNITURE_REST_IN: number;
+        readonly FURNITURE_REST_ON: number;
+        readonly FURNITURE_SIT_AT: number;
+        readonly FURNITURE_SIT_IN: number;
+        readonly FURNITURE_SIT_ON: number;
+        readonly FURNITURE_SLEEP_AT: number;
+        readonly FURNITURE_SLEEP_IN: number;
+        readonly FURNITURE_SLEEP_ON: number;
+        readonly FURNITURE_STAND_AT: number;
+        readonly FURNITURE_STAND_IN: number;
+        readonly FURNITURE_STAND_ON: number;
+    };
+    const HTTP: {
+        Get(arg0: string): string;
+        Post(arg0: string, arg1: string): string;
+    };
+    const KnownLocations: {
+        readonly DeveloperLounge: number;
+        readonly Limbo: number;
+    };
+    const Levels: {
+        readonly LevelAdmin: number;
+        readonly LevelBuilder: number;
+        readonly LevelHero: number;
+    };
+    function NewExit(arg0: Room, arg1: number, arg2: Room, arg3: number): Exit;
+    const ObjectFlags: {
+        readonly ITEM_CLOSEABLE: number;
+        readonly ITEM_CLOSED: number;
+        readonly ITEM_DECAYS: number;
+        readonly ITEM_DECAY_SILENTLY: number;
+        readonly ITEM_GLOW: number;
+        readonly ITEM_HUM: number;
+        readonly ITEM_LOCKED: number;
+        readonly ITEM_PERSISTENT: number;
+        readonly ITEM_TAKE: number;
+        readonly ITEM_WEAPON: number;
+        readonly ITEM_WEARABLE: number;
+        readonly ITEM_WEAR_BODY: number;
+        readonly ITEM_WEAR_HANDS: number;
+        readonly ITEM_WEAR_HELD: number;
+        readonly ITEM_WEAR_LEGS: number;
+        readonly ITEM_WEAR_NECK: number;
+        readonly ITEM_WEAR_TORSO: number;
+    };
+    const RoomFlags: {
+        readonly ROOM_DARK: number;
+        readonly ROOM_DUNGEON: number;
+        readonly ROOM_EVIL_AURA: number;
+        readonly ROOM_PERSISTENT: number;
+        readonly ROOM_PK: number;
+        readonly ROOM_PLANAR: number;
+        readonly ROOM_SAFE: number;
+        readonly ROOM_VIRTUAL: number;
+    };
+    const StatTypes: {
+        readonly STAT_CHARISMA: number;
+        readonly STAT_CONSTITUTION: number;
+        readonly STAT_DEXTERITY: number;
+        readonly STAT_INTELLIGENCE: number;
+        readonly STAT_LUCK: number;
+        readonly STAT_MAX: number;
+        readonly STAT_NONE: number;
+        readonly STAT_STRENGTH: number;
+        readonly STAT_WISDOM: number;
+    };
+    const TerrainFlags: {
+        readonly TERRAIN_DEEP_WATER: number;
+        readonly TERRAIN_IMPASSABLE: number;
+        readonly TERRAIN_RUGGED: number;
+        readonly TERRAIN_SHALLOW_WATER: number;
+    };
+    const TerrainTypes: {
+        readonly OverworldCityEntrance: number;
+        readonly OverworldCityExterior: number;
+        readonly OverworldCityInterior: number;
+        readonly TerrainTypeCaveDeepWall1: number;
+        readonly TerrainTypeCaveTunnel: number;
+        readonly TerrainTypeDenseForest: number;
+        readonly TerrainTypeField: number;
+        readonly TerrainTypeHills: number;
+        readonly TerrainTypeLightForest: number;
+        readonly TerrainTypeMountains: number;
+        readonly TerrainTypeOcean: number;
+        readonly TerrainTypePlains: number;
+        readonly TerrainTypeShallowWater: number;
+        readonly TerrainTypeShore: number;
+        readonly TerrainTypeSnowcappedMountains: number;
+    };
+    const WearLocations: {
+        readonly WearLocationArms: number;
+        readonly WearLocationBody: number;
+        readonly WearLocationFeet: number;
+        readonly WearLocationHands: number;
+        readonly WearLocationHead: number;
+        readonly WearLocationHeld: number;
+        readonly WearLocationLegs: number;
+        readonly WearLocationMax: number;
+        readonly WearLocationNeck: number;
+        readonly WearLocationNone: number;
+        readonly WearLocationShield: number;
+        readonly WearLocationTorso: number;
+        readonly WearLocationWaist: number;
+        readonly WearLocationWielded: number;
+    };
+    function clearAllEventHandlers(): any;
+    function clearCombatHooks(): any;
+    function clearScriptedCommandHandlers(): any;
+    function clearScriptedSkillHandlers(): any;
+    const game: Game;
+    function registerCombatHook(arg0: string, arg1: (...args: any[]) => any): CombatHook;
+    function registerEventHandler(arg0: any, arg1: (...args: any[]) => any): any;
+    function registerPlayerCommand(arg0: any, arg1: (...args: any[]) => any, arg2: any): any;
+    function registerSkillHandler(arg0: any, arg1: (...args: any[]) => any): any;
+    function registerSpellHandler(arg0: any, arg1: (...args: any[]) => any): any;
+    function schedule(arg0: string, arg1: string, arg2: (...args: any[]) => any): any;
+    const store: {
+        character(arg0: Character): any;
+        delete(arg0: string): boolean;
+        get(arg0: string, arg1: any): any;
+        global: {
+            delete(arg0: string): boolean;
+            get(arg0: string, arg1: any): any;
+            list(arg0: string): string[];
+            readonly owner: string;
+            readonly scope: string;
+            set(arg0: string, arg1: any): boolean;
+        };
+        list(arg0: string): string[];
+        readonly owner: string;
+        readonly scope: string;
+        script(): any;
+        set(arg0: string, arg1: any): boolean;
+    };
+    function unschedule(arg0: string): boolean;
+    const util: {
+        affectedFlagNames(arg0: number): string;
+        angle2D(arg0: number, arg1: number, arg2: number, arg3: number): number;
+        angleToDirection(arg0: number): number;
+        characterFlagNames(arg0: number): string;
+        characterLocationName(arg0: Character): string;
+        characterName(arg0: Character): string;
+        characterStat(arg0: Character, arg1: number): CharacterStatValue;
+        characterTypeName(arg0: Character): string;
+        createLinkedList(): LinkedListOfAny;
+        createQuadTree(arg0: number, arg1: number): QuadTreeOfAny;
+        damageFlagNames(arg0: number): string;
+        distance2D(arg0: number, arg1: number, arg2: number, arg3: number, arg4: number, arg5: number): number;
+        effectDescription(arg0: Effect): string;
+        effectDuration(arg0: Effect): string;
+        findCharacter(arg0: Character, arg1: string): Character;
+        findCharacterFlag(arg0: string): Flag;
+        findDamageType(arg0: string): DamageType;
+        findExitFlag(arg0: string): Flag;
+        findFurnitureFlag(arg0: string): Flag;
+        findJobByName(arg0: string): Job;
+        findObjectFlag(arg0: string): ObjectFlag;
+        findRaceByName(arg0: string): Race;
+        findRoomFlag(arg0: string): Flag;
+        newPoint2D(arg0: number, arg1: number, arg2: any): PointOfAny;
+        newRect2D(arg0: number, arg1: number, arg2: number, arg3: number): Rect;
+        oneArgument(arg0: string): [string, string];
+        perlin2D(arg0: number, arg1: number, arg2: number[]): number;
+        positionName(arg0: number): string;
+        resourcePercentage(arg0: number, arg1: number): number;
+        readonly reverseDirection: { [key: number]: number };
+        severityColourFromPercentage(arg0: number): string;
+        statName(arg0: number): string;
+    };
+
+    interface Atlas {
+        plane: Plane;
+        characters: { [key: number]: LinkedListOfCharacter };
+        objects: { [key: number]: LinkedListOfObjectInstance };
+        rooms: { [key: number]: LinkedListOfRoom };
+        exits: { [key: number]: { [key: number]: Exit } };
+        characterTree: QuadTreeOfCharacter;
+        objectTree: QuadTreeOfObjectInstance;
+    }
+
+    interface AwayFromKeyboard {
+    }
+
+    interface Backup {
+    }
+
+    interface CastingContext {
+        casting: Skill;
+        arguments: string;
+        startedAt: any;
+        complexity: number;
+        proficiency: number;
+    }
+
+    interface Character {
+        game: Game;
+        client: Client;
+        inventory: LinkedListOfObjectInstance;
+        planeIndex: PointOfCharacter;
+        trail: Room[];
+        room: Room;
+        combat: Combat;
+        fighting: Character;
+        casting: CastingContext;
+        furniture: ObjectInstance;
+        following: Character;
+        leader: Character;
+        group: LinkedListOfCharacter;
+        id: number;
+        name: string;
+        shortDescription: string;
+        longDescription: string;
+        description: string;
+        wizard: boolean;
+        wiznet: boolean;
+        job: Job;
+        race: Race;
+        level: number;
+        experience: number;
+        practices: number;
+        affected: number;
+        effects: LinkedListOfEffect;
+        skills: { [key: number]: Proficiency };
+        gold: number;
+        flags: number;
+        afk: AwayFromKeyboard;
+        preferences: number;
+        wimpy: number;
+        pkConsent: boolean;
+        pkCooldown: number;
+        killer: number;
+        thief: number;
+        resist: number;
+        immune: number;
+        suscept: number;
+        health: number;
+        maxHealth: number;
+        mana: number;
+        maxMana: number;
+        stamina: number;
+        maxStamina: number;
+        conditions: number[];
+        stats: number[];
+        addEffect(arg0: Effect): void;
+        addObject(arg0: ObjectInstance): void;
+        addThreat(arg0: Character, arg1: number): void;
+        attachObject(arg0: ObjectInstance): void;
+        attachObjects(arg0: ObjectInstance[]): void;
+        canAttack(arg0: Character): boolean;
+        canCrossDeepWater(): boolean;
+        canTraverse(arg0: Terrain): boolean;
+        clearThreat(): void;
+        createMazeMap(): string;
+        createPlaneMap(): string;
+        damageModifiers(): [number, number, number];
+        detachAllObjects(): number;
+        detachObject(arg0: ObjectInstance): void;
+        disbandGroup(): void;
+        finalize(): void;
+        findCharacterInRoom(arg0: string): Character;
+        findLock(arg0: string): Lock;
+        findObjectInRoom(arg0: string): ObjectInstance;
+        findObjectOnSelf(arg0: string): ObjectInstance;
+        findProficiencyByName(arg0: string): Proficiency;
+        findShopInRoom(): Shop;
+        flagThief(): void;
+        getArmorValues(): number[];
+        getEquipment(arg0: number): ObjectInstance;
+        getEquippedLightSource(): ObjectInstance;
+        getShortDescription(arg0: Character): string;
+        getShortDescriptionUpper(arg0: Character): string;
+        getStat(arg0: number): [number, number];
+        hasEffect(arg0: Effect): boolean;
+        hasEquippedLightSource(): boolean;
+        hasKey(arg0: number): boolean;
+        hasPreference(arg0: number): boolean;
+        inSameGroup(arg0: Character): boolean;
+        interpret(arg0: string): boolean;
+        isEqual(arg0: Character): boolean;
+        loadPlayerSkills(): void;
+        mayAttack(arg0: Character): boolean;
+        mayLoot(arg0: ObjectInstance): boolean;
+        movementCost(arg0: Terrain): number;
+        pickLock(arg0: Lock): void;
+        removeEffect(arg0: Effect): void;
+        removeObject(arg0: ObjectInstance): void;
+        rollStats(): void;
+        save(): boolean;
+        savePlayerSkills(): void;
+        saveSkills(): void;
+        send(arg0: string): void;
+        setMobileResourceDefaults(): boolean;
+        sync(): void;
+        threat(arg0: Character): number;
+        topThreat(): Character;
+        transferObjectTo(arg0: Character, arg1: ObjectInstance): void;
+        visible(arg0: Character): boolean;
+        write(arg0: number[]): number;
+    }
+
+    interface CharacterStatValue {
+        modified: number;
+        base: number;
+    }
+
+    interface Client {
+        character: Character;
+        connectionState: number;
+        connectionHandler: (...args: any[]) => any;
+        close(): void;
+        delay(arg0: number): void;
+        send(arg0: number[]): boolean;
+        translateColourCodes(arg0: string): string;
+    }
+
+    interface Combat {
+        startedAt: any;
+        room: Room;
+        participants: Character[];
+    }
+
+    interface CombatAttack {
+        attacker: Character;
+        victim: Character;
+        weapon: ObjectInstance;
+        number: number;
+        damageType: number;
+    }
+
+    interface CombatHook {
+    }
+
+    interface CombatLog {
+        id: number;
+        roomId: number;
+        startedAt: any;
+        endedAt: any;
+        rounds: number;
+        participants: CombatLogParticipant[];
+        events: CombatLogEvent[];
+        seconds(): number;
+        summary(): CombatLogSummary[];
+    }
+
+    interface CombatLogEvent {
+        round: number;
+        at: any;
+        kind: string;
+        actor: string;
+        target: string;
+        damageType: string;
+        raw: number;
+        amount: number;
+        absorbed: number;
+        immune: boolean;
+        resisted: boolean;
+        susceptible: boolean;
+        sanctuary: boolean;
+        outcome: string;
+        spell: string;
+        string(): string;
+    }
+
+    interface CombatLogParticipant {
+        name: string;
+        id: number;
+        level: number;
+        player: boolean;
+    }
+
+    interface CombatLogSummary {
+        name: string;
+        dealt: number;
+        taken: number;
+        healed: number;
+        hits: number;
+        misses: number;
+        spells: number;
+        kills: number;
+        died: boolean;
+        dps: number;
+        takenDps: number;
+    }
+
+    interface DamageResult {
+        damageType: number;
+        requested: number;
+        amount: number;
+        absorbed: number;
+        immune: boolean;
+        resisted: boolean;
+        susceptible: boolean;
+        sanctuary: boolean;
+        killed: boolean;
+    }
+
+    interface DamageType {
+        name: string;
+        type: number;
+        flag: number;
+        armor: number;
+        verb: string;
+        verbOther: string;
+    }
+
+    interface District {
+        layer: MapGrid;
+        id: number;
+        plane: Plane;
+        rect: Rect;
+        flags: number;
+        terrainNameMapping: { [key: number]: string };
+    }
+
+    interface Dungeon {
+        game: Game;
+        floors: MazeGrid[];
+        entrance: Room;
+        abyss: Room;
+    }
+
+    interface Effect {
+        name: string;
+        effectType: number;
+        bits: number;
+        duration: number;
+        level: number;
+        location: number;
+        modifier: number;
+        createdAt: any;
+        onComplete: (...args: any[]) => any;
+        matches(arg0: Effect): boolean;
+    }
+
+    interface Exit {
+        id: number;
+        room: Room;
+        direction: number;
+        to: Room;
+        flags: number;
+        key: number;
+        lockDifficulty: number;
+        delete(): void;
+        finalize(): void;
+        save(): void;
+        visible(arg0: Character): boolean;
+    }
+
+    interface Flag {
+        name: string;
+        flag: number;
+    }
+
+    interface Game {
+        objects: LinkedListOfObjectInstance;
+        characters: LinkedListOfCharacter;
+        fights: LinkedListOfCombat;
+        planes: LinkedListOfPlane;
+        zones: LinkedListOfZone;
+        scriptTimers: LinkedListOfScriptTimer;
+        scripts: { [key: number]: Script };
+        addCombatParticipant(arg0: Combat, arg1: Character): void;
+        attemptLogin(arg0: string, arg1: string): boolean;
+        auditObject(arg0: ObjectInstance, arg1: string, arg2: string, arg3: string): void;
+        broadcast(arg0: string, arg1: (...args: any[]) => any): void;
+        characterStore(arg0: Character): ScriptStore;
+        combatLogs(): CombatLog[];
+        createBackup(): Backup;
+        createEffect(arg0: string, arg1: number, arg2: number, arg3: number, arg4: number, arg5: number, arg6: number, arg7: (...args: any[]) => any): Effect;
+        createGold(arg0: number): ObjectInstance;
+        createScript(arg0: string, arg1: string, arg2: string): Script;
+        createWebhook(): Webhook;
+        createZone(): Zone;
+        damage(arg0: Character, arg1: Character, arg2: boolean, arg3: number, arg4: number): DamageResult;
+        defaultSourceLoader(arg0: string): number[];
+        deleteScript(arg0: Script): void;
+        deleteWebhook(arg0: Webhook): void;
+        disposeCombat(arg0: Combat): void;
+        findCharacterInWorld(arg0: string): Character;
+        findCombatLog(arg0: number): CombatLog;
+        findDistrictByID(arg0: number): District;
+        findPlaneByID(arg0: number): Plane;
+        findPlaneByName(arg0: string): Plane;
+        findPlayerByName(arg0: string): [Character, Room];
+        findSkillByID(arg0: number): Skill;
+        findSkillByName(arg0: string): Skill;
+        findSocialByName(arg0: string): Social;
+        findZoneByID(arg0: number): Zone;
+        fixExits(): void;
+        generateDungeon(arg0: number, arg1: number, arg2: number, arg3: boolean): Dungeon;
+        globalStore(): ScriptStore;
+        hour(): number;
+        initScripting(): void;
+        invokeNamedEventHandlersWithContextAndArguments(arg0: string, arg1: any, ...arg2: any[]): [any[], Error[]];
+        isValidPCName(arg0: string): boolean;
+        loadDistricts(): void;
+        loadJobSkills(): void;
+        loadJobTable(): void;
+        loadMobileIndex(arg0: number): Character;
+        loadObjectAudit(arg0: string): ObjectAuditEntry[];
+        loadObjectIndex(arg0: number): Object;
+        loadObjectsByIndices(arg0: number[]): Object[];
+        loadPlanes(): void;
+        loadPlayerCorpses(): void;
+        loadPlayerInventory(arg0: Character): void;
+        loadRaceTable(): void;
+        loadResets(): void;
+        loadRoomIndex(arg0: number): Room;
+        loadScriptSchedules(): void;
+        loadScripts(): void;
+        loadScriptsFromDatabase(): void;
+        loadScriptsFromDirectory(arg0: string): void;
+        loadShops(): void;
+        loadSkills(): void;
+        loadSocials(): void;
+        loadTerrain(): void;
+        loadWebhooks(): void;
+        loadZones(): void;
+        mitigateDamage(arg0: Character, arg1: Character, arg2: number, arg3: number): DamageResult;
+        newCombatAttack(arg0: Character, arg1: Character, arg2: number): CombatAttack;
+        newExit(arg0: Room, arg1: number, arg2: Room, arg3: number): Exit;
+        newMaze(arg0: number, arg1: number): MazeGrid;
+        newObjectInstance(arg0: number): ObjectInstance;
+        newRoom(): Room;
+        newShop(arg0: number): Shop;
+        objectValue(arg0: number): number;
+        registerCombatHook(arg0: string, arg1: (...args: any[]) => any): CombatHook;
+        registerSkillHandler(arg0: string, arg1: (...args: any[]) => any): any;
+        registerSpellHandler(arg0: string, arg1: (...args: any[]) => any): any;
+        resetRoom(arg0: Room): void;
+        resetZone(arg0: Zone): void;
+        restockShops(arg0: Zone): void;
+        run(): void;
+        savePlayerInventory(arg0: Character): void;
+        saveRace(arg0: Race): void;
+        scheduleScript(arg0: string, arg1: string, arg2: (...args: any[]) => any): ScriptSchedule;
+        scriptStoreFor(arg0: string): ScriptStore;
+        setCombatSeed(arg0: number): void;
+        setScriptSchedulePaused(arg0: string, arg1: boolean): void;
+        unscheduleScript(arg0: string): void;
+        update(): void;
+        validZoneRange(arg0: number, arg1: number): boolean;
+        validZoneRangeExcept(arg0: number, arg1: number, arg2: number): boolean;
+        zoneUpdate(): void;
+    }
+
+    interface Job {
+        id: number;
+        name: string;
+        display_name: string;
+        playable: boolean;
+        experience_required_modifier: number;
+        skills: LinkedListOfJobSkill;
+        primaryAttribute: number;
+        healthGainMin: number;
+        healthGainMax: number;
+        manaGainDivisor: number;
+        staminaGainMin: number;
+        staminaGainMax: number;
+        staminaGainFloor: number;
+    }
+
+    interface JobSkill {
+        id: number;
+        job: Job;
+        skill: Skill;
+        level: number;
+        complexity: number;
+        cost: number;
+    }
+
+    interface LinkedListNodeOfAny {
+        next: LinkedListNodeOfAny;
+        value: any;
+    }
+
+    interface LinkedListNodeOfCharacter {
+        next: LinkedListNodeOfCharacter;
+        value: Character;
+    }
+
+    interface LinkedListNodeOfCombat {
+        next: LinkedListNodeOfCombat;
+        value: Combat;
+    }
+
+    interface LinkedListNodeOfDistrict {
+        next: LinkedListNodeOfDistrict;
+        value: District;
+    }
+
+    interface LinkedListNodeOfEffect {
+        next: LinkedListNodeOfEffect;
+        value: Effect;
+    }
+
+    interface LinkedListNodeOfJobSkill {
+        next: LinkedListNodeOfJobSkill;
+        value: JobSkill;
+    }
+
+    interface LinkedListNodeOfObjectInstance {
+        next: LinkedListNodeOfObjectInstance;
+        value: ObjectInstance;
+    }
+
+    interface LinkedListNodeOfPlane {
+        next: LinkedListNodeOfPlane;
+        value: Plane;
+    }
+
+    interface LinkedListNodeOfPointOfAny {
+        next: LinkedListNodeOfPointOfAny;
+        value: PointOfAny;
+    }
+
+    interface LinkedListNodeOfPointOfCharacter {
+        next: LinkedListNodeOfPointOfCharacter;
+        value: PointOfCharacter;
+    }
+
+    interface LinkedListNodeOfPointOfObjectInstance {
+        next: LinkedListNodeOfPointOfObjectInstance;
+        value: PointOfObjectInstance;
+    }
+
+    interface LinkedListNodeOfPortal {
+        next: LinkedListNodeOfPortal;
+        value: Portal;
+    }
+
+    interface LinkedListNodeOfReset {
+        next: LinkedListNodeOfReset;
+        value: Reset;
+    }
+
+    interface LinkedListNodeOfRoom {
+        next: LinkedListNodeOfRoom;
+        value: Room;
+    }
+
+    interface LinkedListNodeOfScriptTimer {
+        next: LinkedListNodeOfScriptTimer;
+        value: ScriptTimer;
+    }
+
+    interface LinkedListNodeOfShopListing {
+        next: LinkedListNodeOfShopListing;
+        value: ShopListing;
+    }
+
+    interface LinkedListNodeOfZone {
+        next: LinkedListNodeOfZone;
+        value: Zone;
+    }
+
+    interface LinkedListOfAny {
+        head: LinkedListNodeOfAny;
+        count: number;
+        all(): (arg0: (arg0: any) => boolean) => void;
+        concat(arg0: LinkedListOfAny): LinkedListOfAny;
+        contains(arg0: any): boolean;
+        getRandomNode(): LinkedListNodeOfAny;
+        insert(arg0: any): void;
+        remove(arg0: any): boolean;
+        values(): any[];
+    }
+
+    interface LinkedListOfCharacter {
+        head: LinkedListNodeOfCharacter;
+        count: number;
+        all(): (arg0: (arg0: Character) => boolean) => void;
+        concat(arg0: LinkedListOfCharacter): LinkedListOfCharacter;
+        contains(arg0: Character): boolean;
+        getRandomNode(): LinkedListNodeOfCharacter;
+        insert(arg0: Character): void;
+        remove(arg0: Character): boolean;
+        values(): Character[];
+    }
+
+    interface LinkedListOfCombat {
+        head: LinkedListNodeOfCombat;
+        count: number;
+        all(): (arg0: (arg0: Combat) => boolean) => void;
+        concat(arg0: LinkedListOfCombat): LinkedListOfCombat;
+        contains(arg0: Combat): boolean;
+        getRandomNode(): LinkedListNodeOfCombat;
+        insert(arg0: Combat): void;
+        remove(arg0: Combat): boolean;
+        values(): Combat[];
+    }
+
+    interface LinkedListOfDistrict {
+        head: LinkedListNodeOfDistrict;
+        count: number;
+        all(): (arg0: (arg0: District) => boolean) => void;
+        concat(arg0: LinkedListOfDistrict): LinkedListOfDistrict;
+        contains(arg0: District): boolean;
+        getRandomNode(): LinkedListNodeOfDistrict;
+        insert(arg0: District): void;
+        remove(arg0: District): boolean;
+        values(): District[];
+    }
+
+    interface LinkedListOfEffect {
+        head: LinkedListNodeOfEffect;
+        count: number;
+        all(): (arg0: (arg0: Effect) => boolean) => void;
+        concat(arg0: LinkedListOfEffect): LinkedListOfEffect;
+        contains(arg0: Effect): boolean;
+        getRandomNode(): LinkedListNodeOfEffect;
+        insert(arg0: Effect): void;
+        remove(arg0: Effect): boolean;
+        values(): Effect[];
+    }
+
+    interface LinkedListOfJobSkill {
+        head: LinkedListNodeOfJobSkill;
+        count: number;
+        all(): (arg0: (arg0: JobSkill) => boolean) => void;
+        concat(arg0: LinkedListOfJobSkill): LinkedListOfJobSkill;
+        contains(arg0: JobSkill): boolean;
+        getRandomNode(): LinkedListNodeOfJobSkill;
+        insert(arg0: JobSkill): void;
+        remove(arg0: JobSkill): boolean;
+        values(): JobSkill[];
+    }
+
+    interface LinkedListOfObjectInstance {
+        head: LinkedListNodeOfObjectInstance;
+        count: number;
+        all(): (arg0: (arg0: ObjectInstance) => boolean) => void;
+        concat(arg0: LinkedListOfObjectInstance): LinkedListOfObjectInstance;
+        contains(arg0: ObjectInstance): boolean;
+        getRandomNode(): LinkedListNodeOfObjectInstance;
+        insert(arg0: ObjectInstance): void;
+        remove(arg0: ObjectInstance): boolean;
+        values(): ObjectInstance[];
+    }
+
+    interface LinkedListOfPlane {
+        head: LinkedListNodeOfPlane;
+        count: number;
+        all(): (arg0: (arg0: Plane) => boolean) => void;
+        concat(arg0: LinkedListOfPlane): LinkedListOfPlane;
+        contains(arg0: Plane): boolean;
+        getRandomNode(): LinkedListNodeOfPlane;
+        insert(arg0: Plane): void;
+        remove(arg0: Plane): boolean;
+        values(): Plane[];
+    }
+
+    interface LinkedListOfPointOfAny {
+        head: LinkedListNodeOfPointOfAny;
+        count: number;
+        all(): (arg0: (arg0: PointOfAny) => boolean) => void;
+        concat(arg0: LinkedListOfPointOfAny): LinkedListOfPointOfAny;
+        contains(arg0: PointOfAny): boolean;
+        getRandomNode(): LinkedListNodeOfPointOfAny;
+        insert(arg0: PointOfAny): void;
+        remove(arg0: PointOfAny): boolean;
+        values(): PointOfAny[];
+    }
+
+    interface LinkedListOfPointOfCharacter {
+        head: LinkedListNodeOfPointOfCharacter;
+        count: number;
+        all(): (arg0: (arg0: PointOfCharacter) => boolean) => void;
+        concat(arg0: LinkedListOfPointOfCharacter): LinkedListOfPointOfCharacter;
+        contains(arg0: PointOfCharacter): boolean;
+        getRandomNode(): LinkedListNodeOfPointOfCharacter;
+        insert(arg0: PointOfCharacter): void;
+        remove(arg0: PointOfCharacter): boolean;
+        values(): PointOfCharacter[];
+    }
+
+    interface LinkedListOfPointOfObjectInstance {
+        head: LinkedListNodeOfPointOfObjectInstance;
+        count: number;
+        all(): (arg0: (arg0: PointOfObjectInstance) => boolean) => void;
+        concat(arg0: LinkedListOfPointOfObjectInstance): LinkedListOfPointOfObjectInstance;
+        contains(arg0: PointOfObjectInstance): boolean;
+        getRandomNode(): LinkedListNodeOfPointOfObjectInstance;
+        insert(arg0: PointOfObjectInstance): void;
+        remove(arg0: PointOfObjectInstance): boolean;
+        values(): PointOfObjectInstance[];
+    }
+
+    interface LinkedListOfPortal {
+        head: LinkedListNodeOfPortal;
+        count: number;
+        all(): (arg0: (arg0: Portal) => boolean) => void;
+        concat(arg0: LinkedListOfPortal): LinkedListOfPortal;
+        contains(arg0: Portal): boolean;
+        getRandomNode(): LinkedListNodeOfPortal;
+        insert(arg0: Portal): void;
+        remove(arg0: Portal): boolean;
+        values(): Portal[];
+    }
+
+    interface LinkedListOfReset {
+        head: LinkedListNodeOfReset;
+        count: number;
+        all(): (arg0: (arg0: Reset) => boolean) => void;
+        concat(arg0: LinkedListOfReset): LinkedListOfReset;
+        contains(arg0: Reset): boolean;
+        getRandomNode(): LinkedListNodeOfReset;
+        insert(arg0: Reset): void;
+        remove(arg0: Reset): boolean;
+        values(): Reset[];
+    }
+
+    interface LinkedListOfRoom {
+        head: LinkedListNodeOfRoom;
+        count: number;
+        all(): (arg0: (arg0: Room) => boolean) => void;
+        concat(arg0: LinkedListOfRoom): LinkedListOfRoom;
+        contains(arg0: Room): boolean;
+        getRandomNode(): LinkedListNodeOfRoom;
+        insert(arg0: Room): void;
+        remove(arg0: Room): boolean;
+        values(): Room[];
+    }
+
+    interface LinkedListOfScriptTimer {
+        head: LinkedListNodeOfScriptTimer;
+        count: number;
+        all(): (arg0: (arg0: ScriptTimer) => boolean) => void;
+        concat(arg0: LinkedListOfScriptTimer): LinkedListOfScriptTimer;
+        contains(arg0: ScriptTimer): boolean;
+        getRandomNode(): LinkedListNodeOfScriptTimer;
+        insert(arg0: ScriptTimer): void;
+        remove(arg0: ScriptTimer): boolean;
+        values(): ScriptTimer[];
+    }
+
+    interface LinkedListOfShopListing {
+        head: LinkedListNodeOfShopListing;
+        count: number;
+        all(): (arg0: (arg0: ShopListing) => boolean) => void;
+        concat(arg0: LinkedListOfShopListing): LinkedListOfShopListing;
+        contains(arg0: ShopListing): boolean;
+        getRandomNode(): LinkedListNodeOfShopListing;
+        insert(arg0: ShopListing): void;
+        remove(arg0: ShopListing): boolean;
+        values(): ShopListing[];
+    }
+
+    interface LinkedListOfZone {
+        head: LinkedListNodeOfZone;
+        count: number;
+        all(): (arg0: (arg0: Zone) => boolean) => void;
+        concat(arg0: LinkedListOfZone): LinkedListOfZone;
+        contains(arg0: Zone): boolean;
+        getRandomNode(): LinkedListNodeOfZone;
+        insert(arg0: Zone): void;
+        remove(arg0: Zone): boolean;
+        values(): Zone[];
+    }
+
+    interface Lock {
+        exit: Exit;
+        object: ObjectInstance;
+        difficulty(): number;
+        isClosed(): boolean;
+        isLocked(): boolean;
+        isPickable(): boolean;
+        key(): number;
+    }
+
+    interface Map {
+        layers: MapGrid[];
+    }
+
+    interface MapGrid {
+        observers: PlaneObserver[];
+        districts: LinkedListOfDistrict;
+        terrain: number[][];
+        atlas: Atlas;
+        width: number;
+        height: number;
+        findDistrict(arg0: number, arg1: number): District;
+        registerObserver(arg0: Rect, arg1: any, arg2: (...args: any[]) => any, arg3: (...args: any[]) => any): any;
+    }
+
+    interface MazeCell {
+        grid: MazeGrid;
+        room: Room;
+        terrain: number;
+        wall: boolean;
+        x: number;
+        y: number;
+    }
+
+    interface MazeGrid {
+        game: Game;
+        grid: MazeCell[][];
+        width: number;
+        height: number;
+        entryX: number;
+        entryY: number;
+        endX: number;
+        endY: number;
+    }
+
+    interface Object {
+        id: number;
+        itemType: string;
+        name: string;
+        shortDescription: string;
+        longDescription: string;
+        description: string;
+        flags: number;
+        value0: number;
+        value1: number;
+        value2: number;
+        value3: number;
+        weight: number;
+        ttl: number;
+    }
+
+    interface ObjectAuditEntry {
+    }
+
+    interface ObjectFlag {
+        name: string;
+        flag: number;
+    }
+
+    interface ObjectInstance {
+        game: Game;
+        contents: LinkedListOfObjectInstance;
+        inside: ObjectInstance;
+        inRoom: Room;
+        carriedBy: Character;
+        id: number;
+        parentId: number;
+        serial: string;
+        itemType: string;
+        name: string;
+        shortDescription: string;
+        longDescription: string;
+        description: string;
+        flags: number;
+        wearLocation: number;
+        value0: number;
+        value1: number;
+        value2: number;
+        value3: number;
+        weight: number;
+        createdAt: any;
+        ttl: number;
+        corpse: PlayerCorpse;
+        addObject(arg0: ObjectInstance): void;
+        countFurnitureUsers(): number;
+        finalize(arg0: ObjectInstance): void;
+        furnitureRelation(arg0: number): [string, boolean];
+        getContentsWeight(): number;
+        getFlagsString(): string;
+        getFurnitureFlagsString(): string;
+        getShortDescription(arg0: Character): string;
+        getShortDescriptionUpper(arg0: Character): string;
+        getTotalWeight(): number;
+        getWeight(): number;
+        startDecay(): void;
+        supportsFurniturePosition(arg0: number): boolean;
+        sync(): void;
+        visible(arg0: Character): boolean;
+    }
+
+    interface Plane {
+        game: Game;
+        zone: Zone;
+        dungeon: Dungeon;
+        id: number;
+        flags: number;
+        name: string;
+        width: number;
+        height: number;
+        depth: number;
+        planeType: string;
+        sourceType: string;
+        scripts: Script;
+        map: Map;
+        maze: MazeGrid;
+        portals: LinkedListOfPortal;
+        getTerrainRect(arg0: number, arg1: number, arg2: number, arg3: number, arg4: number): number[][];
+        initializeBlob(): [number[], number];
+        materializeRoom(arg0: number, arg1: number, arg2: number, arg3: boolean): Room;
+        newAtlas(): Atlas;
+        saveBlob(): void;
+        supportsPersistentCoordinates(): boolean;
+    }
+
+    interface PlaneObserver {
+        plane: Plane;
+        rect: Rect;
+        onEnterCallback: (...args: any[]) => any;
+        onLeaveCallback: (...args: any[]) => any;
+        dispose(): void;
+    }
+
+    interface PlayerCorpse {
+        id: number;
+        ownerId: number;
+        ownerName: string;
+    }
+
+    interface PointOfAny {
+        x: number;
+        y: number;
+        value: any;
+        coordinates(): [number, number];
+    }
+
+    interface PointOfCharacter {
+        x: number;
+        y: number;
+        value: Character;
+        coordinates(): [number, number];
+    }
+
+    interface PointOfObjectInstance {
+        x: number;
+        y: number;
+        value: ObjectInstance;
+        coordinates(): [number, number];
+    }
+
+    interface Portal {
+        id: number;
+        portalType: string;
+        room: Room;
+        plane: Plane;
+    }
+
+    interface Proficiency {
+        job: Job;
+        id: number;
+        skillId: number;
+        proficiency: number;
+        level: number;
+        complexity: number;
+        cost: number;
+    }
+
+    interface QuadTreeOfAny {
+        nw: QuadTreeOfAny;
+        ne: QuadTreeOfAny;
+        sw: QuadTreeOfAny;
+        se: QuadTreeOfAny;
+        boundary: Rect;
+        data: LinkedListOfPointOfAny;
+        capacity: number;
+        parent: QuadTreeOfAny;
+        collapse(): boolean;
+        insert(arg0: PointOfAny): boolean;
+        queryRect(arg0: Rect): PointOfAny[];
+        remove(arg0: PointOfAny): boolean;
+        subdivide(): boolean;
+    }
+
+    interface QuadTreeOfCharacter {
+        nw: QuadTreeOfCharacter;
+        ne: QuadTreeOfCharacter;
+        sw: QuadTreeOfCharacter;
+        se: QuadTreeOfCharacter;
+        boundary: Rect;
+        data: LinkedListOfPointOfCharacter;
+        capacity: number;
+        parent: QuadTreeOfCharacter;
+        collapse(): boolean;
+        insert(arg0: PointOfCharacter): boolean;
+        queryRect(arg0: Rect): PointOfCharacter[];
+        remove(arg0: PointOfCharacter): boolean;
+        subdivide(): boolean;
+    }
+
+    interface QuadTreeOfObjectInstance {
+        nw: QuadTreeOfObjectInstance;
+        ne: QuadTreeOfObjectInstance;
+        sw: QuadTreeOfObjectInstance;
+        se: QuadTreeOfObjectInstance;
+        boundary: Rect;
+        data: LinkedListOfPointOfObjectInstance;
+        capacity: number;
+        parent: QuadTreeOfObjectInstance;
+        collapse(): boolean;
+        insert(arg0: PointOfObjectInstance): boolean;
+        queryRect(arg0: Rect): PointOfObjectInstance[];
+        remove(arg0: PointOfObjectInstance): boolean;
+        subdivide(): boolean;
+    }
+
+    interface Race {
+        id: number;
+        race: string;
+        display_name: string;
+        playable: boolean;
+        primaryAttribute: number;
+        resist: number;
+        immune: number;
+        suscept: number;
+    }
+
+    interface Rect {
+        x: number;
+        y: number;
+        w: number;
+        h: number;
+        collidesRect(arg0: Rect): boolean;
+        contains(arg0: number, arg1: number): boolean;
+        containsPoint(arg0: any): boolean;
+        containsRect(arg0: Rect): boolean;
+    }
+
+    interface Reset {
+        id: number;
+        zone: Zone;
+        room: Room;
+        resetType: number;
+        value0: number;
+        value1: number;
+        value2: number;
+        value3: number;
+        delete(): void;
+    }
+
+    interface Room {
+        game: Game;
+        plane: Plane;
+        id: number;
+        zone: Zone;
+        flags: number;
+        virtual: boolean;
+        cell: MazeCell;
+        x: number;
+        y: number;
+        z: number;
+        name: string;
+        description: string;
+        objects: LinkedListOfObjectInstance;
+        resets: LinkedListOfReset;
+        characters: LinkedListOfCharacter;
+        exit: { [key: number]: Exit };
+        activeLightSourcePresent(): boolean;
+        addCharacter(arg0: Character): void;
+        addObject(arg0: ObjectInstance): void;
+        broadcast(arg0: string, arg1: (...args: any[]) => any): void;
+        createReset(arg0: number, arg1: number, arg2: number, arg3: number, arg4: number): Reset;
+        isEqual(arg0: Room): boolean;
+        isPK(): boolean;
+        save(): void;
+        terrain(): Terrain;
+        visible(arg0: Character): boolean;
+    }
+
+    interface Script {
+        game: Game;
+        id: number;
+        name: string;
+        script: string;
+        exports: any;
+        commit(arg0: string, arg1: string): ScriptRevision;
+        getExports(): any;
+        revision(arg0: number): ScriptRevision;
+        revisions(): ScriptRevision[];
+        rollback(arg0: number, arg1: string): ScriptRevision;
+        save(): boolean;
+    }
+
+    interface ScriptRevision {
+        id: number;
+        scriptId: number;
+        revision: number;
+        author: string;
+        script: string;
+        createdAt: any;
+    }
+
+    interface ScriptSchedule {
+        id: number;
+        name: string;
+        expression: string;
+        paused: boolean;
+        nextRunAt: any;
+        lastRunAt: any;
+    }
+
+    interface ScriptStore {
+        scope: string;
+        owner: string;
+        delete(arg0: string): boolean;
+        get(arg0: string): [string, boolean];
+        list(arg0: string): string[];
+        set(arg0: string, arg1: string): void;
+    }
+
+    interface ScriptTimer {
+    }
+
+    interface Shop {
+        game: Game;
+        id: number;
+        mobileId: number;
+        listings: LinkedListOfShopListing;
+        buyTypes: string;
+        buyMargin: number;
+        sellMargin: number;
+        openHour: number;
+        closeHour: number;
+        inventory: LinkedListOfObjectInstance;
+        addListing(arg0: Object, arg1: number, arg2: number): ShopListing;
+        appraise(arg0: ObjectInstance): number;
+        buys(arg0: string): boolean;
+        isOpen(arg0: number): boolean;
+        listingAt(arg0: number): ShopListing;
+        objectValue(arg0: number): number;
+        removeListing(arg0: ShopListing): void;
+        save(): void;
+        toggleBuyType(arg0: string): boolean;
+    }
+
+    interface ShopListing {
+        shop: Shop;
+        id: number;
+        object: Object;
+        price: number;
+        stock: number;
+        quantity: number;
+    }
+
+    interface Skill {
+    }
+
+    interface Social {
+    }
+
+    interface Terrain {
+        id: number;
+        name: string;
+        glyphColour: string;
+        mapGlyph: string;
+        movementCost: number;
+        flags: number;
+        isPassable(): boolean;
+    }
+
+    interface Webhook {
+        game: Game;
+        id: number;
+        uuid: string;
+        attachScript(arg0: Script): void;
+        detachScript(arg0: Script): void;
+    }
+
+    interface Zone {
+        game: Game;
+        id: number;
+        name: string;
+        whoDescription: string;
+        low: number;
+        high: number;
+        resetMessage: string;
+        resetFrequency: number;
+        lastReset: any;
+        createRoom(): Room;
+        findAvailableRoomID(): number;
+        save(): void;
+    }
+}
+
+declare function println(...arg0: any[]): void;
+
+declare function require(...args: any[]): any;
+
+declare function setTimeout(arg0: (...args: any[]) => any, arg1: number): any;
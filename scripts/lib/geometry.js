@@ -0,0 +1,32 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+
+/* Fill a w by h rectangle of grid d at (x, y) with f, bordered by t */
+function drawFilledRect(d, x, y, w, h, t, f) {
+    let j, s;
+
+    for (j = y; j < y + h; j++) {
+        for (s = x; s < x + w; s++) {
+            d[j][s] = f;
+        }
+    }
+
+    for (s = x; s <= x + w; s++) {
+        d[y][s] = t;
+        d[y + h][s] = t;
+    }
+
+    for (j = y; j < y + h; j++) {
+        d[j][x] = t;
+        d[j][x + w] = t;
+    }
+}
+
+module.exports = {
+    drawFilledRect,
+};
@@ -13,6 +13,10 @@ function spell_blindness(ch, args) {
         return;
     }
 
+    if (!ch.canAttack(target)) {
+        return;
+    }
+
     if(target.affected & Golem.AffectedTypes.AFFECT_BLINDNESS) {
         ch.send("{WYou failed.{x\r\n");
         return;
@@ -26,7 +26,7 @@ function spell_chain_lightning(ch, args) {
         target.send('{YYou are struck by a crackling arc of chain lightning!{x\r\n');
 
         const amount = ~~(Math.random() * 50) + 10;
-        Golem.game.damage(ch, target, false, amount, Golem.Combat.DamageTypeExotic);
+        Golem.game.damage(ch, target, false, amount, Golem.Combat.DamageTypeShock);
         if(!target || !target.room || !ch.room || !target.room.isEqual(ch.room)) {
             return;
         }
@@ -34,7 +34,7 @@ function spell_chain_lightning(ch, args) {
 
         // 40% chance to recurse to a random other group member of the target, if the target is in a group
         if(Math.random() >= 0.6 && target.group) {
-            const otherGroupMembers = target.group.values().filter(groupMember => !groupMember.isEqual(target) && groupMember.room?.isEqual(ch.room));
+            const otherGroupMembers = target.group.values().filter(groupMember => !groupMember.isEqual(target) && groupMember.room?.isEqual(ch.room) && ch.mayAttack(groupMember));
             
             // only if there are other members in the party - can't chain to yourself, but it is valid to
             // chain back & forth until recursive depth is exhausted
@@ -68,6 +68,10 @@ function spell_chain_lightning(ch, args) {
         ch.send("Your target isn't here.\r\n");
         return;
     }
+
+    if (!ch.canAttack(target)) {
+        return;
+    }
     
     // it's possible for "chain lightning" to travel up to 7 times, if the caster has this spell mastered
     performChainLightningAttack(target, ~~(this.proficiency / 20) + 2);
@@ -15,7 +15,7 @@ function spell_cure_light(ch, args) {
 
     const amount = ~~(((Math.random() * 5) + 5) * (this.proficiency / 100));
 
-    Golem.game.damage(null, target, false, -amount, Golem.Combat.DamageTypeExotic);
+    Golem.game.damage(ch, target, false, -amount, Golem.Combat.DamageTypeExotic);
     target.send('{WYou feel a little bit better.{x\r\n');
 
     for (let iter = ch.room.characters.head; iter !== null; iter = iter.next) {
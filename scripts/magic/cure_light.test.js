@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+test('cure light heals the caster and tells the room', () => {
+    const room = fixture.createRoom('A Chapel', 'Candles flicker before a modest altar.');
+    const cleric = fixture.player('Cleric', { room, job: 'cleric', skills: { 'cure light': 100 } });
+    const witness = fixture.player('Witness', { room });
+
+    cleric.health = 5;
+
+    assert.contains(fixture.command(cleric, "cast 'cure light'"), 'You start uttering the words of the spell');
+    fixture.tick(4);
+
+    const output = fixture.output(cleric);
+    assert.contains(output, 'You finish casting the magic spell.');
+    assert.contains(output, 'You feel a little bit better.');
+    assert(cleric.health > 5, 'the caster was not healed');
+    assert.equal(cleric.mana, 50, 'mana after casting');
+
+    assert.contains(fixture.output(witness), 'Cleric looks a little bit better.');
+});
+
+test('cure light needs its target in the room', () => {
+    const room = fixture.createRoom('A Vestry', 'Robes hang from a row of iron hooks.');
+    const cleric = fixture.player('Healer', { room, job: 'cleric', skills: { 'cure light': 100 } });
+
+    fixture.command(cleric, "cast 'cure light' nobody");
+    fixture.tick(4);
+
+    assert.contains(fixture.output(cleric), "Your target isn't here.");
+});
+
+test('warriors cannot cast cure light', () => {
+    const warrior = fixture.player('Brute');
+
+    assert.contains(fixture.command(warrior, "cast 'cure light'"), 'You have no knowledge of that spell');
+});
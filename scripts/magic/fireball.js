@@ -18,6 +18,10 @@ function spell_fireball(ch, args) {
         return;
     }
 
+    if (!ch.canAttack(target)) {
+        return;
+    }
+
     for (let iter = ch.room.characters.head; iter !== null; iter = iter.next) {
         const rch = iter.value;
 
@@ -33,7 +37,7 @@ function spell_fireball(ch, args) {
     target.send('{RYou are enveloped in flames!{x\r\n');
 
     const amount = ~~(((Math.random() * 30) + 5) * (this.proficiency / 100));
-    Golem.game.damage(ch, target, false, amount, Golem.Combat.DamageTypeExotic);
+    Golem.game.damage(ch, target, false, amount, Golem.Combat.DamageTypeFire);
 }
 
 Golem.registerSpellHandler('fireball', spell_fireball);
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+function spell_fly(ch, args) {
+    const target = args.length > 1 ? ch.findCharacterInRoom(args) : ch;
+
+    if (!target || !ch.room || !target.room || !target.room.isEqual(ch.room)) {
+        ch.send("Your target isn't here.\r\n");
+        return;
+    }
+
+    if (target.affected & Golem.AffectedTypes.AFFECT_FLYING) {
+        ch.send("{WYou failed.{x\r\n");
+        return;
+    }
+
+    target.addEffect(Golem.game.createEffect(
+        'fly',
+        Golem.EffectTypes.EffectTypeAffected,
+        Golem.AffectedTypes.AFFECT_FLYING,
+        ch.level * 2, // duration
+        ch.level,
+        0,
+        0,
+        function(affected) {
+            if (!affected) {
+                return;
+            }
+
+            affected.send("{CYou drift gently back to the ground.{x\r\n");
+
+            if (!affected.room) {
+                return;
+            }
+
+            for (let iter = affected.room.characters.head; iter !== null; iter = iter.next) {
+                const rch = iter.value;
+
+                if (!rch.isEqual(affected)) {
+                    rch.send('{C' + affected.getShortDescriptionUpper(rch) + ' drifts gently back to the ground.{x\r\n');
+                }
+            }
+        }));
+
+    target.send('{CYour feet rise off the ground.{x\r\n');
+
+    for (let iter = ch.room.characters.head; iter !== null; iter = iter.next) {
+        const rch = iter.value;
+
+        if (!rch.isEqual(target)) {
+            rch.send('{C' + target.getShortDescriptionUpper(rch) + "'s feet rise off the ground.{x\r\n");
+        }
+    }
+}
+
+Golem.registerSpellHandler('fly', spell_fly);
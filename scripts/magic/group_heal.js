@@ -23,7 +23,7 @@ function spell_group_heal(ch) {
             continue;
         }
 
-        Golem.game.damage(null, rch, false, -amount, Golem.Combat.DamageTypeExotic);
+        Golem.game.damage(ch, rch, false, -amount, Golem.Combat.DamageTypeExotic);
         rch.send('{WYou feel better.{x\r\n');
 
         for (let innerIter = ch.room.characters.head; innerIter !== null; innerIter = innerIter.next) {
@@ -30,6 +30,9 @@ function do_medit(ch, args) {
                 
 {Gmedit <target> save             - {gSave mobile instance properties globally
 {Gmedit <target> flag <flag name> - {gToggle target flag by name
+{Gmedit <target> resist <type>     - {gToggle resistance to a damage type
+{Gmedit <target> immune <type>     - {gToggle immunity to a damage type
+{Gmedit <target> suscept <type>    - {gToggle susceptibility to a damage type
 {Gmedit <target> description      - {gString editor for mobile's description
 
 {WThe following values may be used in a general way with the syntax:
@@ -109,6 +112,24 @@ function do_medit(ch, args) {
             ch.send("Ok.  Disabled character flag " + flag.name + " on " + target.getShortDescription(ch) + ".\r\n");
             return;
 
+        case 'resist':
+        case 'immune':
+        case 'suscept':
+            if(target.flags & Golem.CharacterFlags.CHAR_IS_PLAYER) {
+                ch.send("Failed: not an NPC.\r\n");
+                return;
+            }
+
+            const damageType = Golem.util.findDamageType(xxs);
+            if(!damageType || !damageType.flag) {
+                ch.send("No such damage type exists.  Try bash, slash, stab, exotic, fire, cold, shock or poison.\r\n");
+                return;
+            }
+
+            target[secondArgument] ^= damageType.flag;
+            ch.send("Ok.  " + secondArgument + " is now: " + Golem.util.damageFlagNames(target[secondArgument]) + ".\r\n");
+            return;
+
         case 'short_description':
             if(target.flags & Golem.CharacterFlags.CHAR_IS_PLAYER) {
                 ch.send("Failed: not an NPC.\r\n");
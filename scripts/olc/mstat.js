@@ -53,6 +53,13 @@ function do_mstat(ch, args) {
         );
         output.push(`{CFlags:{c ${Golem.util.characterFlagNames(target.flags)}`);
         output.push(`{CAffected:{c ${Golem.util.affectedFlagNames(target.affected)}`);
+
+        const [resist, immune, suscept] = target.damageModifiers();
+        output.push(
+            `{CResist:{c ${Golem.util.damageFlagNames(resist)}  ` +
+                `{CImmune:{c ${Golem.util.damageFlagNames(immune)}  ` +
+                `{CSuscept:{c ${Golem.util.damageFlagNames(suscept)}`
+        );
         output.push(
             `{CResources:{c health ${resource(target.health, target.maxHealth)}  ` +
                 `{cmana ${resource(target.mana, target.maxMana)}  ` +
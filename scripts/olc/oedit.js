@@ -15,6 +15,8 @@ function do_oedit(ch, args) {
 {Goedit <obj> flag <flag name> - {gToggle object flag by name
 {Goedit <obj> furniture <flag> - {gToggle furniture flag by name
 {Goedit <obj> ttl <minutes>    - {gSet object decay lifetime in minutes
+{Goedit <obj> key <id>         - {gSet a container's key (value2), or 0 for no keyhole
+{Goedit <obj> lock_difficulty <n> - {gSet how hard a container's lock is to pick (value3)
 
 {WThe following values may be used in a general way with the syntax:
 {Goedit <target> <attribute> <value>
@@ -162,11 +164,13 @@ function do_oedit(ch, args) {
                 ch.send("Ok.\r\n");
                 break;
 
+            case 'key':
             case 'value2':
                 target.value2 = parseInt(xxs);
                 ch.send("Ok.\r\n");
                 break;
 
+            case 'lock_difficulty':
             case 'value3':
                 target.value3 = parseInt(xxs);
                 ch.send("Ok.\r\n");
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+function do_raceedit(ch, args) {
+    function displayUsage() {
+        ch.send(
+            `{WRace editor usage:
+
+{Graceedit <race>                 - {gShow a race's damage modifiers
+{Graceedit <race> save            - {gSave a race's properties to database
+{Graceedit <race> resist <type>   - {gToggle resistance to a damage type
+{Graceedit <race> immune <type>   - {gToggle immunity to a damage type
+{Graceedit <race> suscept <type>  - {gToggle susceptibility to a damage type
+
+{GDamage types:{g bash slash stab exotic fire cold shock poison
+{x`);
+    }
+
+    function displayModifiers(race) {
+        ch.send(
+            `{C${race.display_name}{c:  ` +
+                `{CResist:{c ${Golem.util.damageFlagNames(race.resist)}  ` +
+                `{CImmune:{c ${Golem.util.damageFlagNames(race.immune)}  ` +
+                `{CSuscept:{c ${Golem.util.damageFlagNames(race.suscept)}{x\r\n`
+        );
+    }
+
+    let [firstArgument, xs] = Golem.util.oneArgument(args);
+    let [secondArgument, xxs] = Golem.util.oneArgument(xs);
+
+    if (!args.length) {
+        displayUsage();
+        return;
+    }
+
+    const race = Golem.util.findRaceByName(firstArgument);
+    if (!race) {
+        ch.send("No such race exists.\r\n");
+        return;
+    }
+
+    switch (secondArgument) {
+        case '':
+            displayModifiers(race);
+            return;
+
+        case 'save':
+            try {
+                Golem.game.saveRace(race);
+            } catch (err) {
+                ch.send("Failed: " + err + "\r\n");
+                return;
+            }
+
+            ch.send("Ok.\r\n");
+            return;
+
+        case 'resist':
+        case 'immune':
+        case 'suscept':
+            const damageType = Golem.util.findDamageType(xxs);
+            if (!damageType || !damageType.flag) {
+                ch.send("No such damage type exists.\r\n");
+                return;
+            }
+
+            race[secondArgument] ^= damageType.flag;
+            displayModifiers(race);
+            return;
+
+        default:
+            displayUsage();
+            return;
+    }
+}
+
+Golem.registerPlayerCommand('raceedit', do_raceedit, Golem.Levels.LevelBuilder);
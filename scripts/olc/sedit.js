@@ -0,0 +1,218 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+function do_sedit(ch, args) {
+    function displayUsage() {
+        ch.send(
+            `{WShop editor usage:
+
+{Gsedit create <keeper>            - {gOpen a shop for a mobile flagged as a shopkeeper
+{Gsedit show                       - {gDisplay the shop in this room
+{Gsedit add <object id> <price> [stock] - {gList an object, with stock 0 for an endless supply
+{Gsedit remove <#>                 - {gStop selling a listing
+{Gsedit price <#> <price>          - {gReprice a listing
+{Gsedit stock <#> <stock>          - {gSet how many of a listing are restocked on zone reset
+{Gsedit buys <item type>           - {gToggle whether the keeper buys an item type
+{Gsedit margin <buy|sell> <percent> - {gSet the share of value paid to players or charged for secondhand goods
+{Gsedit hours <open> <close>       - {gSet the game hours of trade, or equal hours to never close
+{Gsedit save                       - {gSave the shop and its listings
+{x`);
+    }
+
+    if (!ch.room) {
+        ch.send("You can't do that here.\r\n");
+        return;
+    }
+
+    let [firstArgument, rest] = Golem.util.oneArgument(args);
+    let [secondArgument, xxs] = Golem.util.oneArgument(rest);
+
+    if (firstArgument === 'create') {
+        const keeper = ch.findCharacterInRoom(secondArgument);
+        if (!keeper || keeper.flags & Golem.CharacterFlags.CHAR_IS_PLAYER) {
+            ch.send("No such mobile here.\r\n");
+            return;
+        }
+
+        if (!(keeper.flags & Golem.CharacterFlags.CHAR_SHOPKEEPER)) {
+            ch.send("Flag them as a shopkeeper with medit first.\r\n");
+            return;
+        }
+
+        if (ch.findShopInRoom()) {
+            ch.send("There is already a shop here.\r\n");
+            return;
+        }
+
+        const shop = Golem.game.newShop(keeper.id);
+        if (shop.save()) {
+            ch.send("Something went wrong trying to save the new shop.\r\n");
+            return;
+        }
+
+        ch.send("Ok.  " + keeper.getShortDescriptionUpper(ch) + " now keeps shop " + shop.id + ".\r\n");
+        return;
+    }
+
+    const shop = ch.findShopInRoom();
+    if (!shop) {
+        ch.send("There is no shop here.\r\n");
+        return;
+    }
+
+    function findListing(number) {
+        const listing = shop.listingAt(parseInt(number));
+        if (!listing) {
+            ch.send("There is no listing with that number.\r\n");
+        }
+
+        return listing;
+    }
+
+    switch (firstArgument) {
+        case 'show':
+            {
+                let output = "{WShop " + shop.id + " kept by mobile " + shop.mobileId + "{x\r\n" +
+                    "{GBuys:   {g" + (shop.buyTypes.length ? shop.buyTypes : "nothing") + "\r\n" +
+                    "{GMargin: {gpays " + shop.buyMargin + "%, resells at " + shop.sellMargin + "%\r\n" +
+                    "{GHours:  {g" + (shop.openHour === shop.closeHour ? "always open" : shop.openHour + " to " + shop.closeHour) + "\r\n";
+
+                let count = 1;
+                for (let iter = shop.listings.head; iter !== null; iter = iter.next) {
+                    const listing = iter.value;
+                    if (!listing.object) {
+                        continue;
+                    }
+
+                    output += "{x" + count + ") [" + listing.object.id + "] " + listing.object.shortDescription +
+                        "{x for " + listing.price + " gold, stock " + (listing.stock ? listing.quantity + "/" + listing.stock : "endless") + "\r\n";
+                    count++;
+                }
+
+                ch.send(output);
+                return;
+            }
+
+        case 'add':
+            {
+                let [priceArgument, stockArgument] = Golem.util.oneArgument(xxs);
+                const obj = Golem.game.loadObjectIndex(parseInt(secondArgument));
+                const price = parseInt(priceArgument);
+                const stock = stockArgument.length ? parseInt(stockArgument) : 0;
+
+                if (!obj) {
+                    ch.send("Failed to find that object index.\r\n");
+                    return;
+                }
+
+                if (isNaN(price) || price < 0 || isNaN(stock) || stock < 0) {
+                    ch.send("The price and stock must be numbers of zero or more.\r\n");
+                    return;
+                }
+
+                shop.addListing(obj, price, stock);
+                ch.send("Ok.  Now selling " + obj.shortDescription + "{x for " + price + " gold.\r\n");
+                return;
+            }
+
+        case 'remove':
+            {
+                const listing = findListing(secondArgument);
+                if (!listing) {
+                    return;
+                }
+
+                shop.removeListing(listing);
+                ch.send("Ok.\r\n");
+                return;
+            }
+
+        case 'price':
+        case 'stock':
+            {
+                const listing = findListing(secondArgument);
+                if (!listing) {
+                    return;
+                }
+
+                const amount = parseInt(xxs);
+                if (isNaN(amount) || amount < 0) {
+                    ch.send("That's not a valid " + firstArgument + ".\r\n");
+                    return;
+                }
+
+                if (firstArgument === 'price') {
+                    listing.price = amount;
+                } else {
+                    listing.stock = listing.quantity = amount;
+                }
+
+                ch.send("Ok.\r\n");
+                return;
+            }
+
+        case 'buys':
+            if (!secondArgument.length) {
+                ch.send("An item type argument to toggle is required.\r\nExample: sedit buys weapon\r\n");
+                return;
+            }
+
+            ch.send("Ok.  The keeper " + (shop.toggleBuyType(secondArgument) ? "now buys " : "no longer buys ") + secondArgument + ".\r\n");
+            return;
+
+        case 'margin':
+            {
+                const percent = parseInt(xxs);
+                if (!['buy', 'sell'].includes(secondArgument) || isNaN(percent) || percent < 0) {
+                    ch.send("Usage: sedit margin <buy|sell> <percent>\r\n");
+                    return;
+                }
+
+                if (secondArgument === 'buy') {
+                    shop.buyMargin = percent;
+                } else {
+                    shop.sellMargin = percent;
+                }
+
+                // a keeper paying more than it resells for, or more than full value, is a gold fountain
+                shop.buyMargin = Math.min(shop.buyMargin, shop.sellMargin, 100);
+
+                ch.send("Ok.  The keeper pays " + shop.buyMargin + "% and resells at " + shop.sellMargin + "%.\r\n");
+                return;
+            }
+
+        case 'hours':
+            {
+                const open = parseInt(secondArgument);
+                const close = parseInt(xxs);
+                if ([open, close].some((hour) => isNaN(hour) || hour < 0 || hour > 23)) {
+                    ch.send("Opening and closing hours run from 0 to 23.\r\n");
+                    return;
+                }
+
+                shop.openHour = open;
+                shop.closeHour = close;
+                ch.send("Ok.\r\n");
+                return;
+            }
+
+        case 'save':
+            if (shop.save()) {
+                ch.send("Something went wrong trying to save this shop.\r\n");
+                return;
+            }
+
+            ch.send("Ok.\r\n");
+            return;
+
+        default:
+            displayUsage();
+            break;
+    }
+}
+
+Golem.registerPlayerCommand('sedit', do_sedit, Golem.Levels.LevelBuilder);
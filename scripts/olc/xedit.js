@@ -36,6 +36,8 @@ function do_xedit(ch, args) {
 {Gxedit delete <direction>           - {gBi-directionally delete an exit
 {Gxedit dig <direction>              - {gTry to create a dig a new room
 {Gxedit flag <direction> <flag name> - {gToggle a flag for a given direction
+{Gxedit key <direction> <object id>  - {gSet the key for a door, or 0 for no keyhole
+{Gxedit difficulty <direction> <n>   - {gSet how hard a door's lock is to pick (100+ is unpickable)
 {Gxedit link <direction> <id>        - {gCreate a two-way exit to an existing room
 {Gxedit unlink <direction>           - {gUnlink this room's side of an exit
 `);
@@ -262,6 +264,57 @@ function do_xedit(ch, args) {
                 return;
             }
 
+        case 'key':
+        case 'difficulty':
+            {
+                let [direction, value] = Golem.util.oneArgument(rest);
+
+                if(!VALID_DIRECTIONS.includes(direction)) {
+                    ch.send("That's not a valid direction.\r\n");
+                    return;
+                }
+
+                const dir = DIRECTION_TO_VALUE[direction];
+                if(!ch.room.exit[dir]) {
+                    ch.send("There is no exit in that direction here.\r\n");
+                    return;
+                }
+
+                const amount = parseInt(value);
+                if(isNaN(amount) || amount < 0) {
+                    ch.send("That's not a valid " + firstArgument + ".\r\n");
+                    return;
+                }
+
+                if(firstArgument === 'key' && amount !== 0 && !Golem.game.loadObjectIndex(amount)) {
+                    ch.send("Failed to find that object index.\r\n");
+                    return;
+                }
+
+                // both sides of a door share one lock
+                const exits = [ch.room.exit[dir]];
+                const toRoom = ch.room.exit[dir].to;
+                if(toRoom && toRoom.exit[Golem.util.reverseDirection[dir]]) {
+                    exits.push(toRoom.exit[Golem.util.reverseDirection[dir]]);
+                }
+
+                for(const exit of exits) {
+                    if(firstArgument === 'key') {
+                        exit.key = amount;
+                    } else {
+                        exit.lockDifficulty = amount;
+                    }
+
+                    if(exit.save()) {
+                        ch.send("Something went wrong trying to save this exit's lock.\r\n");
+                        return;
+                    }
+                }
+
+                ch.send("Ok.  Set the " + firstArgument + " on exit " + direction + " to " + amount + ".\r\n");
+                return;
+            }
+
         default:
             displayUsage();
             break;
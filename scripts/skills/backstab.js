@@ -30,6 +30,10 @@ function do_backstab(ch, args) {
         return;
     }
 
+    if (!ch.canAttack(victim)) {
+        return;
+    }
+
     /*
      * If the skill user is 100% proficient with backstab and the victim is of a level less
      * than the skill user, then allow for a 2% chance to instant-kill the target.
@@ -59,7 +63,7 @@ function do_backstab(ch, args) {
             }
         }
 
-        Golem.game.damage(ch, victim, false, victim.health, Golem.Combat.DamageTypeStab);
+        Golem.game.damage(ch, victim, false, victim.health, Golem.Combat.DamageTypeTrue);
         ch.client.delay(2000);
         return;
     }
@@ -13,6 +13,10 @@ function do_bash(ch, args) {
         return;
     }
 
+    if (!ch.canAttack(victim)) {
+        return;
+    }
+
     if(ch.stamina < 25) {
         ch.send("You are too tired to do that.\r\n");
         return;
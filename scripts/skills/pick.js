@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+// work a locked door or container open without its key; harder locks resist even a practiced hand
+function do_pick(ch, args) {
+    if (ch.fighting || ch.combat) {
+        ch.send("You can't do that while fighting.\r\n");
+        return;
+    }
+
+    const lock = args.length ? ch.findLock(args) : null;
+
+    if (!lock) {
+        ch.send('Pick what?\r\n');
+        return;
+    }
+
+    if (!lock.isClosed()) {
+        ch.send("It isn't closed.\r\n");
+        return;
+    }
+
+    if (!lock.isLocked()) {
+        ch.send("It isn't locked.\r\n");
+        return;
+    }
+
+    if (!lock.isPickable()) {
+        ch.send('This lock is beyond your craft.\r\n');
+        return;
+    }
+
+    if (ch.client) {
+        ch.client.delay(1500);
+    }
+
+    if (Math.random() * 100 >= this.proficiency - lock.difficulty()) {
+        ch.send('{DYou fumble with the lock but it holds.{x\r\n');
+        return;
+    }
+
+    ch.pickLock(lock);
+}
+
+Golem.registerSkillHandler('pick', do_pick);
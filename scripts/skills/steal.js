@@ -13,6 +13,15 @@ function do_steal(ch, args) {
         ch.send('Steal from who?\r\n');
         return;
     }
+
+    // robbing another player is a crime, whether or not it succeeds
+    if (victim.flags & Golem.CharacterFlags.CHAR_IS_PLAYER) {
+        if (!ch.canAttack(victim)) {
+            return;
+        }
+
+        ch.flagThief();
+    }
 }
 
 Golem.registerSkillHandler('steal', do_steal);
@@ -13,6 +13,10 @@ function do_stun(ch, args) {
         return;
     }
 
+    if (!ch.canAttack(victim)) {
+        return;
+    }
+
     if(ch.stamina < 75) {
         ch.send("You are too tired to do that.\r\n");
         return;
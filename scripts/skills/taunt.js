@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+
+// draw a mobile's attention away from the rest of the group by outdoing its greatest threat
+function do_taunt(ch, args) {
+    let victim = args.length ? ch.findCharacterInRoom(args) : null;
+
+    if (!args.length) {
+        for (let iter = ch.room.characters.head; iter !== null; iter = iter.next) {
+            const rch = iter.value;
+
+            if (rch.fighting && !rch.fighting.isEqual(ch) && rch.fighting.inSameGroup(ch)) {
+                victim = rch;
+                break;
+            }
+        }
+    }
+
+    if (!victim || victim.isEqual(ch) || victim.flags & Golem.CharacterFlags.CHAR_IS_PLAYER) {
+        ch.send('Taunt who?\r\n');
+        return;
+    }
+
+    if (!victim.fighting) {
+        ch.send(victim.getShortDescriptionUpper(ch) + " isn't fighting anyone.\r\n");
+        return;
+    }
+
+    if(ch.stamina < 20) {
+        ch.send("You are too tired to do that.\r\n");
+        return;
+    }
+
+    ch.stamina = Math.max(0, ch.stamina - 20);
+    if (ch.client) {
+        ch.client.delay(1000);
+    }
+
+    if (Math.random() * 100 >= this.proficiency) {
+        ch.send('{D' + victim.getShortDescriptionUpper(ch) + '{D ignores your taunts.{x\r\n');
+        return;
+    }
+
+    const top = victim.topThreat();
+    const lead = top ? victim.threat(top) - victim.threat(ch) : 0;
+
+    victim.addThreat(ch, Math.max(0, lead) + 10 + ch.level);
+
+    ch.send('{RYou hurl insults at ' + victim.getShortDescription(ch) + '{R, drawing its fury!{x\r\n');
+
+    for (let iter = ch.room.characters.head; iter !== null; iter = iter.next) {
+        const rch = iter.value;
+
+        if (!rch.isEqual(ch)) {
+            rch.send(
+                '{R' + ch.getShortDescriptionUpper(rch) +
+                    '{R hurls insults at ' + victim.getShortDescription(rch) + '{R!{x\r\n'
+            );
+        }
+    }
+}
+
+Golem.registerSkillHandler('taunt', do_taunt);
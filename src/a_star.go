@@ -70,13 +70,18 @@ func do_path(ch *Character, arguments string) {
 
 	var output strings.Builder
 
-	pathNodes := grid.findPathAStar(ch.Room.Cell, target)
+	pathNodes := grid.findPathAStar(ch.Room.Cell, target, ch)
 	if len(pathNodes) == 0 && ch.Room.Cell != target {
 		ch.Send(fmt.Sprintf("No path from (%d, %d) to (%d, %d).\r\n", ch.Room.Cell.X, ch.Room.Cell.Y, target.X, target.Y))
 		return
 	}
 
-	output.WriteString(fmt.Sprintf("{YPath from (%d, %d) to (%d, %d) in %d moves.{x\r\n", ch.Room.Cell.X, ch.Room.Cell.Y, target.X, target.Y, int(math.Max(0, float64(len(pathNodes)-1)))))
+	stamina := 0
+	if len(pathNodes) > 0 {
+		stamina = pathNodes[0].gScore
+	}
+
+	output.WriteString(fmt.Sprintf("{YPath from (%d, %d) to (%d, %d) in %d moves, costing %d stamina.{x\r\n", ch.Room.Cell.X, ch.Room.Cell.Y, target.X, target.Y, int(math.Max(0, float64(len(pathNodes)-1))), stamina))
 	for r := len(pathNodes) - 1; r >= 0; r-- {
 		output.WriteString(fmt.Sprintf("{G(%d, %d){x\r\n", pathNodes[r].cell.X, pathNodes[r].cell.Y))
 	}
@@ -84,12 +89,39 @@ func do_path(ch *Character, arguments string) {
 	ch.Send(output.String())
 }
 
-func (maze *MazeGrid) findPathAStar(start *MazeCell, end *MazeCell) []*MazeAStarVisit {
+/* Whether a traveller may step into cell, and the stamina it costs them; with no traveller, only walls and impassable terrain block */
+func (maze *MazeGrid) stepCost(cell *MazeCell, traveller *Character) (int, bool) {
+	if cell == nil || cell.Wall {
+		return 0, false
+	}
+
+	terrain := FindTerrain(cell.Terrain)
+	if traveller == nil {
+		if !terrain.IsPassable() {
+			return 0, false
+		}
+
+		return max(1, DefaultMovementCost), true
+	}
+
+	if !traveller.CanTraverse(terrain) {
+		return 0, false
+	}
+
+	return max(1, traveller.MovementCost(terrain)), true
+}
+
+/* The cheapest route for traveller (which may be nil) from start to end, last step first */
+func (maze *MazeGrid) findPathAStar(start *MazeCell, end *MazeCell, traveller *Character) []*MazeAStarVisit {
 	visits := make([]*MazeAStarVisit, 0)
 	if maze == nil || start == nil || end == nil || start.Grid != maze || end.Grid != maze || start.Wall || end.Wall {
 		return visits
 	}
 
+	if _, ok := maze.stepCost(end, traveller); !ok {
+		return visits
+	}
+
 	if start == end {
 		return visits
 	}
@@ -100,7 +132,7 @@ func (maze *MazeGrid) findPathAStar(start *MazeCell, end *MazeCell) []*MazeAStar
 	for y := 0; y < maze.Height; y++ {
 		for x := 0; x < maze.Width; x++ {
 			cell := maze.cellAt(x, y)
-			if cell == nil || cell.Wall {
+			if _, ok := maze.stepCost(cell, traveller); !ok && cell != start {
 				continue
 			}
 
@@ -146,7 +178,12 @@ func (maze *MazeGrid) findPathAStar(start *MazeCell, end *MazeCell) []*MazeAStar
 				neighbours := currentNode.cell.getAdjacentCells(false, 1, false)
 				for neighbour := range neighbours.All() {
 					if !visited[neighbour] {
-						var gScore int = currentNode.gScore + 1
+						cost, ok := maze.stepCost(neighbour, traveller)
+						if !ok {
+							continue
+						}
+
+						var gScore int = currentNode.gScore + cost
 
 						neighbourVisit, ok := unvisited[neighbour]
 						if ok {
@@ -60,6 +60,12 @@ func do_say(ch *Character, arguments string) {
 			}
 		}
 	}
+
+	if ch.Room != nil {
+		ch.Room.fireRoomTrigger(RoomTriggerSay, ch, arguments)
+	}
+
+	ch.Game.mobileSpeechTriggers(ch, arguments)
 }
 
 func do_ooc(ch *Character, arguments string) {
@@ -284,6 +284,15 @@ func do_score(ch *Character, arguments string) {
 	if ch.hasMortalNeeds() && ch.Conditions[ConditionHunger] == 0 {
 		buf.WriteString("{Y* {yYou are hungry.{x\r\n")
 	}
+	if ch.PkConsent {
+		buf.WriteString("{Y* {rYou have chosen to fight other players.{x\r\n")
+	}
+	if ch.Killer > 0 {
+		buf.WriteString("{Y* {RYou are a KILLER.{x\r\n")
+	}
+	if ch.Thief > 0 {
+		buf.WriteString("{Y* {RYou are a THIEF.{x\r\n")
+	}
 
 	output := buf.String()
 	ch.Send(output)
@@ -346,6 +355,18 @@ func do_who(ch *Character, arguments string) {
 			}
 		}
 
+		if character.Killer > 0 {
+			flagsString.WriteString("{R(KILLER){x ")
+		}
+
+		if character.Thief > 0 {
+			flagsString.WriteString("{R(THIEF){x ")
+		}
+
+		if character.PkConsent {
+			flagsString.WriteString("{r(PK){x ")
+		}
+
 		if character.Fighting != nil {
 			extrasString.WriteString("{M[<FIGHTING>]{x ")
 		}
@@ -379,6 +400,7 @@ func do_time(ch *Character, arguments string) {
 
 	buf.WriteString(fmt.Sprintf("{GThe current server time is: {g%s\r\n", time.Now().Format(time.RFC1123)))
 	buf.WriteString(fmt.Sprintf("{YServer has been up since:   {y%s{x\r\n", ch.Game.startedAt.Format(time.RFC1123)))
+	buf.WriteString(fmt.Sprintf("{CIt is %s in the game world.{x\r\n", formatGameHour(ch.Game.Hour())))
 
 	ch.Send(buf.String())
 }
@@ -436,6 +458,10 @@ func do_look(ch *Character, arguments string) {
 		return
 	}
 
+	if ch.Room.lookHandled(ch, arguments) {
+		return
+	}
+
 	if len(arguments) > 0 {
 		var found *ObjectInstance = ch.FindObjectOnSelf(arguments)
 		if found != nil {
@@ -133,6 +133,7 @@ const (
 	TERRAIN_IMPASSABLE    = 1
 	TERRAIN_SHALLOW_WATER = 1 << 1
 	TERRAIN_DEEP_WATER    = 1 << 2
+	TERRAIN_RUGGED        = 1 << 3
 )
 
 const (
@@ -173,6 +174,10 @@ type Exit struct {
 	Direction uint  `json:"direction"`
 	To        *Room `json:"to"`
 	Flags     int   `json:"flags"`
+
+	/* Object index id of the key for a door's lock, or 0 for no keyhole, and how hard it is to pick */
+	Key            uint `json:"key"`
+	LockDifficulty int  `json:"lockDifficulty"`
 }
 
 func (game *Game) NewExit(from *Room, direction uint, to *Room, flags int) *Exit {
@@ -493,8 +498,6 @@ func FindExitFlag(flag string) *Flag {
 }
 
 func (ch *Character) move(direction uint, follow bool) bool {
-	const MovementCost = 2 /* update with terrain-based cost */
-
 	if ch.isFighting() {
 		ch.Send("{RYou are in the middle of fighting!{x\r\n")
 		return false
@@ -527,12 +530,19 @@ func (ch *Character) move(direction uint, follow bool) bool {
 		return false
 	}
 
-	if ch.Stamina-MovementCost < 0 && ch.Level <= LevelHero {
+	terrain := exit.To.Terrain()
+	if refusal := ch.terrainRefusal(terrain); refusal != "" {
+		ch.Send(fmt.Sprintf("{R%s{x\r\n", refusal))
+		return false
+	}
+
+	movementCost := ch.MovementCost(terrain)
+	if ch.Stamina-movementCost < 0 && ch.Level <= LevelHero {
 		ch.Send("{DYou are too exhausted to move!{x\r\n")
 		return false
 	}
 
-	ch.Stamina -= MovementCost
+	ch.Stamina -= movementCost
 
 	// Is the exit closed, etc.
 	from := ch.Room
@@ -542,7 +552,7 @@ func (ch *Character) move(direction uint, follow bool) bool {
 	if destination.Flags&ROOM_PLANAR != 0 {
 		destination = destination.Plane.MaterializeRoom(destination.X, destination.Y, destination.Z, true)
 		if destination == nil {
-			ch.Stamina += MovementCost
+			ch.Stamina += movementCost
 			ch.Send("{RAlas, you cannot go that way.{x\r\n")
 			return false
 		}
@@ -554,8 +564,9 @@ func (ch *Character) move(direction uint, follow bool) bool {
 		character.Send(fmt.Sprintf("{W%s{W leaves %s.{x\r\n", ch.GetShortDescriptionUpper(character), ExitName[direction]))
 	}
 
-	if from.script != nil {
-		from.script.tryEvaluate("onRoomLeave", ch.Game.vm.ToValue(from), ch.Game.vm.ToValue(ch))
+	/* Room triggers may have sent the character somewhere else entirely */
+	if ch.Room != destination {
+		return true
 	}
 
 	for character := range destination.Characters.All() {
@@ -565,6 +576,19 @@ func (ch *Character) move(direction uint, follow bool) bool {
 	}
 
 	do_look(ch, "")
+	destination.characterEntered(ch, from)
+
+	/* ...and so may the destination's enter trigger */
+	if ch.Room != destination {
+		return true
+	}
+
+	if delay := ch.terrainDelay(terrain); delay > 0 {
+		ch.Send("{DThe climb leaves you short of breath.{x\r\n")
+		if ch.Client != nil {
+			ch.Client.Delay(delay)
+		}
+	}
 
 	if destination == from {
 		return true
@@ -579,9 +603,7 @@ func (ch *Character) move(direction uint, follow bool) bool {
 		}
 	}
 
-	if destination.script != nil {
-		destination.script.tryEvaluate("onRoomEnter", ch.Game.vm.ToValue(destination), ch.Game.vm.ToValue(ch))
-	}
+	ch.Game.mobileGreetTriggers(ch, destination)
 
 	/* Aggro check... */
 	for character := range destination.Characters.All() {
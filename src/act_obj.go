@@ -151,6 +151,8 @@ func (ch *Character) consumeCarriedObject(obj *ObjectInstance) bool {
 		ch.Game.Objects.Remove(obj)
 	}
 
+	ch.Game.AuditObject(obj, ObjectAuditConsumed, auditCharacter(ch), "")
+
 	return true
 }
 
@@ -1006,6 +1008,10 @@ func do_put(ch *Character, arguments string) {
 	ch.RemoveObject(placingObj)
 	placingIn.AddObject(placingObj)
 
+	if placingIn.CarriedBy != ch {
+		ch.Game.AuditObject(placingObj, ObjectAuditDropped, auditCharacter(ch), auditContainer(placingIn))
+	}
+
 	ch.Send(fmt.Sprintf("You put %s{x inside of %s{x.\r\n", placingObj.GetShortDescription(ch), placingIn.GetShortDescription(ch)))
 
 	for rch := range ch.Room.Characters.All() {
@@ -1015,6 +1021,59 @@ func do_put(ch *Character, arguments string) {
 	}
 }
 
+/* Move takingObj out of the container into ch's hands, or its purse for coins; false if ch could not take it */
+func (ch *Character) takeFromContainer(takingObj *ObjectInstance, takingFrom *ObjectInstance) bool {
+	if !ch.MayLoot(takingFrom) {
+		ch.Send(fmt.Sprintf("You may not loot the corpse of %s.\r\n", takingFrom.Corpse.OwnerName))
+		return false
+	}
+
+	if ch.Inventory.Count+1 > ch.getMaxItemsInventory() {
+		ch.Send("You can't carry any more.\r\n")
+		return false
+	}
+
+	if !ch.canPickUpObject(takingObj) {
+		ch.Send("You can't carry that much weight.\r\n")
+		return false
+	}
+
+	if takingObj.ItemType != ItemTypeCurrency && takingFrom.CarriedBy != ch {
+		err := ch.AttachObject(takingObj)
+		if err != nil {
+			ch.Send(fmt.Sprintf("A strange force prevents you from removing %s from %s.\r\n", takingObj.GetShortDescription(ch), takingFrom.GetShortDescription(ch)))
+			return false
+		}
+	}
+
+	takingFrom.removeObject(takingObj)
+
+	if takingObj.ItemType != ItemTypeCurrency {
+		ch.AddObject(takingObj)
+
+		if takingFrom.CarriedBy != ch {
+			ch.Game.AuditObject(takingObj, ObjectAuditTaken, auditContainer(takingFrom), auditCharacter(ch))
+		}
+	} else {
+		ch.Gold = ch.Gold + takingObj.Value0
+		ch.Game.Objects.Remove(takingObj)
+
+		/* Coins left on a player's corpse were saved with it */
+		if err := ch.Game.deletePersistedObjectInstance(takingObj); err != nil {
+			log.Printf("Warning: failed to delete taken coins %d: %v\r\n", takingObj.Id, err)
+		}
+	}
+
+	ch.Send(fmt.Sprintf("You take %s{x from %s{x.\r\n", takingObj.GetShortDescription(ch), takingFrom.GetShortDescription(ch)))
+	for rch := range ch.Room.Characters.All() {
+		if rch != ch {
+			rch.Send(fmt.Sprintf("%s{x takes %s{x from %s{x.\r\n", ch.GetShortDescriptionUpper(rch), takingObj.GetShortDescription(rch), takingFrom.GetShortDescription(rch)))
+		}
+	}
+
+	return true
+}
+
 func do_take(ch *Character, arguments string) {
 	var firstArgument string = ""
 	var secondArgument string = ""
@@ -1064,38 +1123,8 @@ func do_take(ch *Character, arguments string) {
 					continue
 				}
 
-				if ch.Inventory.Count+1 > ch.getMaxItemsInventory() {
-					ch.Send("You can't carry any more.\r\n")
-					break
-				}
-
-				if !ch.canPickUpObject(takingObj) {
-					ch.Send("You can't carry that much weight.\r\n")
-					break
-				}
-
-				if takingObj.ItemType != ItemTypeCurrency && takingFrom.CarriedBy != ch {
-					err := ch.AttachObject(takingObj)
-					if err != nil {
-						ch.Send(fmt.Sprintf("A strange force prevents you from removing %s from %s.\r\n", takingObj.GetShortDescription(ch), takingFrom.GetShortDescription(ch)))
-						return
-					}
-				}
-
-				takingFrom.removeObject(takingObj)
-
-				if takingObj.ItemType != ItemTypeCurrency {
-					ch.AddObject(takingObj)
-				} else {
-					ch.Gold = ch.Gold + takingObj.Value0
-					ch.Game.Objects.Remove(takingObj)
-				}
-
-				ch.Send(fmt.Sprintf("You take %s{x from %s{x.\r\n", takingObj.GetShortDescription(ch), takingFrom.GetShortDescription(ch)))
-				for rch := range ch.Room.Characters.All() {
-					if rch != ch {
-						rch.Send(fmt.Sprintf("%s{x takes %s{x from %s{x.\r\n", ch.GetShortDescriptionUpper(rch), takingObj.GetShortDescription(rch), takingFrom.GetShortDescription(rch)))
-					}
+				if !ch.takeFromContainer(takingObj, takingFrom) {
+					return
 				}
 			}
 
@@ -1112,45 +1141,16 @@ func do_take(ch *Character, arguments string) {
 				return
 			}
 
-			if ch.Inventory.Count+1 > ch.getMaxItemsInventory() {
-				ch.Send("You can't carry any more.\r\n")
-				return
-			}
-
-			if !ch.canPickUpObject(takingObj) {
-				ch.Send("You can't carry that much weight.\r\n")
-				return
-			}
-
-			if takingObj.ItemType != ItemTypeCurrency && takingFrom.CarriedBy != ch {
-				err := ch.AttachObject(takingObj)
-				if err != nil {
-					ch.Send(fmt.Sprintf("A strange force prevents you from removing %s from %s.\r\n", takingObj.GetShortDescription(ch), takingFrom.GetShortDescription(ch)))
-					return
-				}
-			}
-
-			takingFrom.removeObject(takingObj)
-
-			if takingObj.ItemType != ItemTypeCurrency {
-				ch.AddObject(takingObj)
-			} else {
-				ch.Gold = ch.Gold + takingObj.Value0
-				ch.Game.Objects.Remove(takingObj)
-			}
-
-			ch.Send(fmt.Sprintf("You take %s{x from %s{x.\r\n", takingObj.GetShortDescription(ch), takingFrom.GetShortDescription(ch)))
-			for rch := range ch.Room.Characters.All() {
-				if rch != ch {
-					rch.Send(fmt.Sprintf("%s{x takes %s{x from %s{x.\r\n", ch.GetShortDescriptionUpper(rch), takingObj.GetShortDescription(rch), takingFrom.GetShortDescription(rch)))
-				}
-			}
+			ch.takeFromContainer(takingObj, takingFrom)
 		}
 
 		return
 	}
 
 	if firstArgument == "all" {
+		room := ch.Room
+		taken := make([]*ObjectInstance, 0)
+
 		for iter := ch.Room.Objects.Head; iter != nil; iter = iter.Next {
 			found := iter.Value
 
@@ -1186,6 +1186,7 @@ func do_take(ch *Character, arguments string) {
 
 			if found.ItemType != ItemTypeCurrency {
 				ch.AddObject(found)
+				ch.Game.AuditObject(found, ObjectAuditTaken, auditRoom(ch.Room), auditCharacter(ch))
 			} else {
 				ch.Gold = ch.Gold + found.Value0
 				ch.Game.Objects.Remove(found)
@@ -1201,6 +1202,12 @@ func do_take(ch *Character, arguments string) {
 					}
 				}
 			}
+
+			taken = append(taken, found)
+		}
+
+		for _, obj := range taken {
+			room.fireRoomTrigger(RoomTriggerTake, ch, obj)
 		}
 
 		return
@@ -1245,6 +1252,7 @@ func do_take(ch *Character, arguments string) {
 
 	if found.ItemType != ItemTypeCurrency {
 		ch.AddObject(found)
+		ch.Game.AuditObject(found, ObjectAuditTaken, auditRoom(ch.Room), auditCharacter(ch))
 	} else {
 		ch.Gold = ch.Gold + found.Value0
 		ch.Game.Objects.Remove(found)
@@ -1259,6 +1267,8 @@ func do_take(ch *Character, arguments string) {
 				rch.Send(outString)
 			}
 		}
+
+		ch.Room.fireRoomTrigger(RoomTriggerTake, ch, found)
 	}
 }
 
@@ -1317,6 +1327,7 @@ func do_give(ch *Character, arguments string) {
 			}
 		}
 
+		target.fireMobileTrigger(MobileTriggerGive, ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(goldRepresentation))
 		return
 	}
 
@@ -1355,6 +1366,7 @@ func do_give(ch *Character, arguments string) {
 
 	ch.RemoveObject(found)
 	target.AddObject(found)
+	ch.Game.AuditObject(found, ObjectAuditGiven, auditCharacter(ch), auditCharacter(target))
 
 	ch.Send(fmt.Sprintf("You give %s{x to %s{x.\r\n", found.GetShortDescription(ch), target.GetShortDescription(ch)))
 	target.Send(fmt.Sprintf("%s{x gives you %s{x.\r\n", ch.GetShortDescriptionUpper(target), found.GetShortDescription(target)))
@@ -1366,6 +1378,8 @@ func do_give(ch *Character, arguments string) {
 			}
 		}
 	}
+
+	target.fireMobileTrigger(MobileTriggerGive, ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(found))
 }
 
 func do_drop(ch *Character, arguments string) {
@@ -1395,6 +1409,9 @@ func do_drop(ch *Character, arguments string) {
 			return
 		}
 
+		room := ch.Room
+		dropped := make([]*ObjectInstance, 0, len(objects))
+
 		for _, obj := range objects {
 			// TODO: check that we have not exceeded the room object capacity, etc...
 			if ch.Flags&CHAR_IS_PLAYER != 0 {
@@ -1408,6 +1425,7 @@ func do_drop(ch *Character, arguments string) {
 
 			ch.RemoveObject(obj)
 			ch.Room.AddObject(obj)
+			ch.Game.AuditObject(obj, ObjectAuditDropped, auditCharacter(ch), auditRoom(ch.Room))
 
 			ch.Send(fmt.Sprintf("You drop %s{x.\r\n", obj.GetShortDescription(ch)))
 
@@ -1416,6 +1434,12 @@ func do_drop(ch *Character, arguments string) {
 					rch.Send(fmt.Sprintf("%s{x drops %s{x.\r\n", ch.GetShortDescriptionUpper(rch), obj.GetShortDescription(rch)))
 				}
 			}
+
+			dropped = append(dropped, obj)
+		}
+
+		for _, obj := range dropped {
+			room.fireRoomTrigger(RoomTriggerDrop, ch, obj)
 		}
 
 		return
@@ -1470,6 +1494,7 @@ func do_drop(ch *Character, arguments string) {
 			}
 		}
 
+		ch.Room.fireRoomTrigger(RoomTriggerDrop, ch, gold)
 		return
 	}
 
@@ -1493,6 +1518,8 @@ func do_drop(ch *Character, arguments string) {
 		ch.Room.AddObject(found)
 	}
 
+	ch.Game.AuditObject(found, ObjectAuditDropped, auditCharacter(ch), auditRoom(ch.Room))
+
 	ch.Send(fmt.Sprintf("You drop %s{x.\r\n", found.ShortDescription))
 	outString := fmt.Sprintf("\r\n%s drops %s{x.\r\n", ch.Name, found.ShortDescription)
 
@@ -1501,4 +1528,6 @@ func do_drop(ch *Character, arguments string) {
 			rch.Send(outString)
 		}
 	}
+
+	ch.Room.fireRoomTrigger(RoomTriggerDrop, ch, found)
 }
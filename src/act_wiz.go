@@ -9,9 +9,12 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/dop251/goja"
 )
 
 func (ch *Character) isAdmin() bool {
@@ -20,7 +23,9 @@ func (ch *Character) isAdmin() bool {
 
 func do_exec(ch *Character, arguments string) {
 	if ch.Client != nil {
-		value, err := ch.Game.vm.RunString(arguments)
+		value, err := ch.Game.runScriptLimited(ScriptHookExec, "", func() (goja.Value, error) {
+			return ch.Game.vm.RunString(arguments)
+		})
 
 		if err != nil {
 			ch.Send(fmt.Sprintf("{R\r\nError: %s{x.\r\n", err.Error()))
@@ -113,6 +118,7 @@ func do_purge(ch *Character, arguments string) {
 		}
 
 		obj := ch.Room.Objects.Head.Value
+		ch.Game.AuditObject(obj, ObjectAuditPurged, auditRoom(ch.Room), "")
 		ch.Game.removeObjectFromWorld(obj)
 	}
 
@@ -359,6 +365,28 @@ func do_webhook(ch *Character, arguments string) {
 	}
 }
 
+func scriptFromArgument(ch *Character, arguments string) *Script {
+	argument, _ := OneArgument(arguments)
+	if argument == "" {
+		ch.Send("This requires a script ID argument.\r\n")
+		return nil
+	}
+
+	id, err := strconv.Atoi(argument)
+	if err != nil {
+		ch.Send("Bad argument, please provide an integer ID.\r\n")
+		return nil
+	}
+
+	script, ok := ch.Game.Scripts[uint(id)]
+	if !ok {
+		ch.Send("A script with that ID could not be found.\r\n")
+		return nil
+	}
+
+	return script
+}
+
 func do_script(ch *Character, arguments string) {
 	if len(arguments) < 1 {
 		output := "{WScript management:\r\n" +
@@ -366,7 +394,14 @@ func do_script(ch *Character, arguments string) {
 			"{Gcreate     - {gcreate a new mutable script\r\n" +
 			"{Gshow [#]   - {gshow more details about a mutable script\r\n" +
 			"{Gedit [#]   - {gstart a line editor on a script's source\r\n" +
-			"{Gdelete [#] - {gdelete a script by ID (from {G\"script list\"){x\r\n"
+			"{Ghistory [#] - {glist a script's saved revisions\r\n" +
+			"{Gdiff [#] <rev> [rev] - {gcompare a revision with the current source or another revision\r\n" +
+			"{Grollback [#] <rev> - {grestore a revision's source as a new revision\r\n" +
+			"{Gdelete [#] - {gdelete a script by ID (from {G\"script list\"){x\r\n" +
+			"{Gdisabled   - {glist handlers disabled for exceeding their limits\r\n" +
+			"{Genable <handler> - {gre-enable a disabled handler\r\n" +
+			"{Gschedules  - {glist scheduled jobs and their next run\r\n" +
+			"{Gpause <job>, resume <job>, unschedule <job> - {gmanage a scheduled job{x\r\n"
 		ch.Send(output)
 		return
 	}
@@ -395,7 +430,7 @@ func do_script(ch *Character, arguments string) {
 			break
 		}
 
-		script, err := ch.Game.CreateScript(secondArgument, "module.exports = {};")
+		script, err := ch.Game.CreateScript(secondArgument, "module.exports = {};", ch.Name)
 		if err != nil {
 			ch.Send(fmt.Sprintf("Something went wrong trying to create a new script: %v\r\n", err))
 			break
@@ -437,13 +472,14 @@ func do_script(ch *Character, arguments string) {
 						script.script,
 						(_, string) => {
 							ch.send("{WSaving script " + script.name + " (" + script.id + ")...{x\r\n");
-							script.script = string;
 
-							if(!script.save()) {
-								ch.send("{RSave failed.{x\r\n");
+							try {
+								const revision = script.commit(string, ch.name);
+								ch.send("{WSaved as revision " + revision.revision + ", trying to re-evaluate script for exports...{x\r\n");
+							} catch(err) {
+								ch.send("{RSave failed, nothing was changed: " + err + "{x\r\n");
+								return;
 							}
-							
-							ch.send("{WSaved, trying to re-evaluate script for exports...{x\r\n");
 
 							try {
 								var newExports = script.getExports();
@@ -464,6 +500,89 @@ func do_script(ch *Character, arguments string) {
 			ch.Send(fmt.Sprintf("Failed to edit this script: %v\r\n", err))
 		}
 
+	case "history":
+		script := scriptFromArgument(ch, arguments)
+		if script == nil {
+			break
+		}
+
+		output, err := script.historyDescription()
+		if err != nil {
+			ch.Send(fmt.Sprintf("Failed to read this script's history: %v\r\n", err))
+			break
+		}
+
+		ch.Send(output)
+
+	case "diff":
+		script := scriptFromArgument(ch, arguments)
+		if script == nil {
+			break
+		}
+
+		_, arguments = OneArgument(arguments)
+		fromArgument, arguments := OneArgument(arguments)
+		toArgument, _ := OneArgument(arguments)
+
+		fromNumber, err := strconv.Atoi(fromArgument)
+		if err != nil {
+			ch.Send("Diff requires a revision number to compare.\r\n")
+			break
+		}
+
+		from, err := script.Revision(fromNumber)
+		if err != nil {
+			ch.Send(fmt.Sprintf("%v.\r\n", err))
+			break
+		}
+
+		toName, toSource := "current", script.Script
+		if toArgument != "" {
+			toNumber, err := strconv.Atoi(toArgument)
+			if err != nil {
+				ch.Send("Bad argument, please provide an integer revision.\r\n")
+				break
+			}
+
+			to, err := script.Revision(toNumber)
+			if err != nil {
+				ch.Send(fmt.Sprintf("%v.\r\n", err))
+				break
+			}
+
+			toName, toSource = fmt.Sprintf("revision %d", to.Revision), to.Script
+		}
+
+		ch.Send(scriptDiffDescription(fmt.Sprintf("revision %d", from.Revision), from.Script, toName, toSource))
+
+	case "rollback":
+		script := scriptFromArgument(ch, arguments)
+		if script == nil {
+			break
+		}
+
+		_, arguments = OneArgument(arguments)
+		revisionArgument, _ := OneArgument(arguments)
+
+		number, err := strconv.Atoi(revisionArgument)
+		if err != nil {
+			ch.Send("Rollback requires a revision number.\r\n")
+			break
+		}
+
+		revision, err := script.Rollback(number, ch.Name)
+		if revision == nil {
+			ch.Send(fmt.Sprintf("Failed to roll back this script: %v\r\n", err))
+			break
+		}
+
+		if err != nil {
+			ch.Send(fmt.Sprintf("{YRestored revision %d as revision %d, but failed to update exports: %v{x\r\n", number, revision.Revision, err))
+			break
+		}
+
+		ch.Send(fmt.Sprintf("{GRestored revision %d as revision %d.{x\r\n", number, revision.Revision))
+
 	case "delete":
 		secondArgument, _ := OneArgument(arguments)
 		if secondArgument == "" {
@@ -492,6 +611,60 @@ func do_script(ch *Character, arguments string) {
 		ch.Send("Ok.\r\n")
 		return
 
+	case "disabled":
+		keys := ch.Game.disabledScriptKeys()
+		if len(keys) == 0 {
+			ch.Send("No script handlers are disabled.\r\n")
+			break
+		}
+
+		var output strings.Builder
+
+		output.WriteString("{YDisabled script handlers:\r\n")
+		for _, key := range keys {
+			output.WriteString(fmt.Sprintf("{y  %s\r\n", key))
+		}
+
+		output.WriteString("{x")
+		ch.Send(output.String())
+
+	case "enable":
+		key := strings.TrimSpace(arguments)
+		if !ch.Game.disabledScripts[key] {
+			ch.Send("No disabled handler by that name, see {G\"script disabled\"{x.\r\n")
+			break
+		}
+
+		delete(ch.Game.disabledScripts, key)
+		delete(ch.Game.scriptViolations, key)
+
+		out := fmt.Sprintf("%s re-enabled script %s.\r\n", ch.Name, key)
+		log.Print(out)
+		ch.Game.broadcast(out, WiznetBroadcastFilter)
+
+	case "schedules":
+		ch.Send(ch.Game.scriptSchedulesDescription())
+
+	case "pause", "resume":
+		name := strings.TrimSpace(arguments)
+
+		err := ch.Game.SetScriptSchedulePaused(name, command == "pause")
+		if err != nil {
+			ch.Send(fmt.Sprintf("%v, see {G\"script schedules\"{x.\r\n", err))
+			break
+		}
+
+		ch.Send("Ok.\r\n")
+
+	case "unschedule":
+		err := ch.Game.UnscheduleScript(strings.TrimSpace(arguments))
+		if err != nil {
+			ch.Send(fmt.Sprintf("%v, see {G\"script schedules\"{x.\r\n", err))
+			break
+		}
+
+		ch.Send("Ok.\r\n")
+
 	default:
 		ch.Send("Unrecognized command.\r\n")
 	}
@@ -541,8 +714,9 @@ func do_goto(ch *Character, arguments string) {
 			}
 		}
 
-		if ch.Room != nil {
-			ch.Room.moveCharacter(ch, destination)
+		from := ch.Room
+		if from != nil {
+			from.moveCharacter(ch, destination)
 
 			for character := range destination.Characters.All() {
 				if character != ch {
@@ -552,6 +726,7 @@ func do_goto(ch *Character, arguments string) {
 		}
 
 		do_look(ch, "")
+		destination.characterEntered(ch, from)
 		return
 	}
 
@@ -586,14 +761,15 @@ func do_goto(ch *Character, arguments string) {
 		return
 	}
 
-	if ch.Room != nil {
-		for character := range ch.Room.Characters.All() {
+	from := ch.Room
+	if from != nil {
+		for character := range from.Characters.All() {
 			if character != ch {
 				character.Send(fmt.Sprintf("\r\n{W%s{W disappears in a puff of smoke.{x\r\n", ch.GetShortDescriptionUpper(character)))
 			}
 		}
 
-		ch.Room.moveCharacter(ch, room)
+		from.moveCharacter(ch, room)
 	} else {
 		room.AddCharacter(ch)
 	}
@@ -605,4 +781,5 @@ func do_goto(ch *Character, arguments string) {
 	}
 
 	do_look(ch, "")
+	room.characterEntered(ch, from)
 }
@@ -0,0 +1,420 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+const (
+	backupDirectoryTimeLayout = "20060102-150405"
+	backupDatabaseFileName    = "golem.sqlite3"
+	defaultBackupDirectory    = "backups"
+	defaultBackupRetention    = 24
+)
+
+type Backup struct {
+	Name      string
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+/* Take a consistent copy of the live database into a new timestamped backup directory */
+func (game *Game) CreateBackup() (*Backup, error) {
+	directory := Config.BackupConfiguration.Directory
+
+	createdAt := time.Now()
+	name := createdAt.Format(backupDirectoryTimeLayout)
+	backupDirectory := filepath.Join(directory, name)
+
+	_, err := os.Stat(backupDirectory)
+	if err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	err = os.MkdirAll(backupDirectory, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	backupPath := filepath.Join(backupDirectory, backupDatabaseFileName)
+
+	/* VACUUM INTO produces a transactionally consistent, compacted copy without stopping the world */
+	_, err = game.db.Exec(`VACUUM INTO ?`, backupPath)
+	if err != nil {
+		os.RemoveAll(backupDirectory)
+		return nil, err
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pruneBackups(directory, Config.BackupConfiguration.Retention)
+	if err != nil {
+		log.Printf("Failed to prune old backups: %v.\r\n", err)
+	}
+
+	return &Backup{
+		Name:      name,
+		Path:      backupPath,
+		CreatedAt: createdAt,
+		Size:      info.Size(),
+	}, nil
+}
+
+/* Scheduled backups are run from the game loop so they never race a player save */
+func (game *Game) backupUpdate() {
+	backup, err := game.CreateBackup()
+	if err != nil {
+		out := fmt.Sprintf("Scheduled backup failed: %v\r\n", err)
+
+		log.Print(out)
+		game.broadcast(out, WiznetBroadcastFilter)
+		return
+	}
+
+	log.Printf("Scheduled backup written to %s (%d bytes).\r\n", backup.Path, backup.Size)
+}
+
+/* List backups in a directory, newest first */
+func listBackups(directory string) ([]*Backup, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []*Backup{}, nil
+		}
+
+		return nil, err
+	}
+
+	backups := make([]*Backup, 0)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		createdAt, err := time.ParseInLocation(backupDirectoryTimeLayout, entry.Name(), time.Local)
+		if err != nil {
+			continue
+		}
+
+		backup := &Backup{
+			Name:      entry.Name(),
+			Path:      filepath.Join(directory, entry.Name(), backupDatabaseFileName),
+			CreatedAt: createdAt,
+		}
+
+		info, err := os.Stat(backup.Path)
+		if err == nil {
+			backup.Size = info.Size()
+		}
+
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i int, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+/* Remove all but the newest retention-many backups; a retention below one keeps everything */
+func pruneBackups(directory string, retention int) error {
+	if retention < 1 {
+		return nil
+	}
+
+	backups, err := listBackups(directory)
+	if err != nil {
+		return err
+	}
+
+	for index, backup := range backups {
+		if index < retention {
+			continue
+		}
+
+		err = os.RemoveAll(filepath.Join(directory, backup.Name))
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Pruned backup %s.\r\n", backup.Name)
+	}
+
+	return nil
+}
+
+/* Find the highest migration version available to this build */
+func latestMigrationVersion() (uint, error) {
+	driver, err := source.Open("file://migrations")
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		version = next
+	}
+}
+
+/* Read the migration state recorded in a database file without modifying it */
+func databaseMigrationVersion(path string) (uint, bool, error) {
+	db, err := sql.Open(databaseDriverSQLite, fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return 0, false, err
+	}
+	defer db.Close()
+
+	var result string
+
+	err = db.QueryRow(`PRAGMA integrity_check`).Scan(&result)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if result != "ok" {
+		return 0, false, fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version uint
+	var dirty bool
+
+	err = db.QueryRow(`
+		SELECT
+			version,
+			dirty
+		FROM
+			schema_migrations
+		LIMIT 1
+	`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, errors.New("no migrations have been applied")
+		}
+
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+/* A backup may be restored if it is clean and no newer than the migrations this build knows about */
+func validateRestoreCandidate(path string, latest uint) (uint, error) {
+	version, dirty, err := databaseMigrationVersion(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return version, fmt.Errorf("migration version %d is marked dirty", version)
+	}
+
+	if version > latest {
+		return version, fmt.Errorf("migration version %d is newer than the latest known migration %d", version, latest)
+	}
+
+	return version, nil
+}
+
+func copyFile(from string, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	err = out.Sync()
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+/* Swap a validated backup in place of the configured database, keeping the previous file aside */
+func restoreDatabase(from string, to string) (string, error) {
+	staging := to + ".restoring"
+
+	err := copyFile(from, staging)
+	if err != nil {
+		os.Remove(staging)
+		return "", err
+	}
+
+	var previous string
+
+	_, err = os.Stat(to)
+	if err == nil {
+		previous = fmt.Sprintf("%s.pre-restore-%s", to, time.Now().Format(backupDirectoryTimeLayout))
+
+		err = os.Rename(to, previous)
+		if err != nil {
+			os.Remove(staging)
+			return "", err
+		}
+	}
+
+	/* Stale journals from the old database must not be replayed against the restored one */
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		os.Remove(to + suffix)
+	}
+
+	err = os.Rename(staging, to)
+	if err != nil {
+		return previous, err
+	}
+
+	return previous, nil
+}
+
+/* golem restore <file> */
+func runRestoreCommand(arguments []string) int {
+	if len(arguments) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: golem restore <backup file or directory>\n")
+		fmt.Fprintf(os.Stderr, "The server must be stopped before restoring.\n")
+		return 2
+	}
+
+	from := arguments[0]
+
+	info, err := os.Stat(from)
+	if err != nil {
+		log.Printf("Unable to read backup: %v.\r\n", err)
+		return 1
+	}
+
+	if info.IsDir() {
+		from = filepath.Join(from, backupDatabaseFileName)
+	}
+
+	if Config.DatabaseConfiguration.DSN != "" {
+		log.Printf("Restore requires a database path; a DSN is configured.\r\n")
+		return 1
+	}
+
+	to := Config.DatabaseConfiguration.Path
+
+	fromAbsolute, _ := filepath.Abs(from)
+	toAbsolute, _ := filepath.Abs(to)
+	if fromAbsolute == toAbsolute {
+		log.Printf("Refusing to restore the database over itself.\r\n")
+		return 1
+	}
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		log.Printf("Unable to read available migrations: %v.\r\n", err)
+		return 1
+	}
+
+	version, err := validateRestoreCandidate(from, latest)
+	if err != nil {
+		log.Printf("Backup %s cannot be restored: %v.\r\n", from, err)
+		return 1
+	}
+
+	previous, err := restoreDatabase(from, to)
+	if err != nil {
+		log.Printf("Restore failed: %v.\r\n", err)
+		return 1
+	}
+
+	if previous != "" {
+		log.Printf("Previous database moved to %s.\r\n", previous)
+	}
+
+	log.Printf("Restored %s (migration version %d of %d) to %s.\r\n", from, version, latest, to)
+	return 0
+}
+
+func do_backup(ch *Character, arguments string) {
+	firstArgument, _ := OneArgument(arguments)
+
+	switch strings.ToLower(firstArgument) {
+	case "":
+		ch.Send("{WWriting a database backup...{x\r\n")
+
+		backup, err := ch.Game.CreateBackup()
+		if err != nil {
+			ch.Send(fmt.Sprintf("{RBackup failed: %v{x\r\n", err))
+			return
+		}
+
+		out := fmt.Sprintf("%s wrote database backup %s (%d bytes).\r\n", ch.Name, backup.Name, backup.Size)
+		log.Print(out)
+		ch.Game.broadcast(out, WiznetBroadcastFilter)
+
+		ch.Send(fmt.Sprintf("{GBackup written to {g%s{G.{x\r\n", backup.Path))
+
+	case "list":
+		backups, err := listBackups(Config.BackupConfiguration.Directory)
+		if err != nil {
+			ch.Send(fmt.Sprintf("{RUnable to list backups: %v{x\r\n", err))
+			return
+		}
+
+		var output strings.Builder
+
+		output.WriteString(fmt.Sprintf("{Y%-16s %-30s %s\r\n", "Backup", "Created", "Size"))
+		for _, backup := range backups {
+			output.WriteString(fmt.Sprintf("%-16s %-30s %d\r\n", backup.Name, backup.CreatedAt.Format(time.RFC1123), backup.Size))
+		}
+
+		output.WriteString(fmt.Sprintf("\r\n%d backups in %s, keeping %d.{x\r\n", len(backups), Config.BackupConfiguration.Directory, Config.BackupConfiguration.Retention))
+		ch.Send(output.String())
+
+	default:
+		ch.Send("{WBackup management:\r\n" +
+			"{Gbackup      - {gwrite a consistent snapshot of the live database\r\n" +
+			"{Gbackup list - {glist existing snapshots, newest first\r\n" +
+			"{WRestore a snapshot offline with {Ggolem restore <file>{W.{x\r\n")
+	}
+}
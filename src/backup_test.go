@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeMigrationFixture(t *testing.T, path string, version uint, dirty bool) {
+	t.Helper()
+
+	db, err := sql.Open(databaseDriverSQLite, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (version uint64, dirty bool)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)`, version, dirty); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRestoreCandidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version uint
+		dirty   bool
+		latest  uint
+		wantErr bool
+	}{
+		{name: "current", version: 18, latest: 18},
+		{name: "older", version: 12, latest: 18},
+		{name: "newer", version: 19, latest: 18, wantErr: true},
+		{name: "dirty", version: 18, dirty: true, latest: 18, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), backupDatabaseFileName)
+			writeMigrationFixture(t, path, tt.version, tt.dirty)
+
+			version, err := validateRestoreCandidate(path, tt.latest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRestoreCandidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if version != tt.version {
+				t.Fatalf("version = %d, want %d", version, tt.version)
+			}
+		})
+	}
+}
+
+func TestPruneBackupsKeepsNewest(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		name := now.Add(-time.Duration(i) * time.Hour).Format(backupDirectoryTimeLayout)
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "not-a-backup"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneBackups(root, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := listBackups(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 2 {
+		t.Fatalf("len(backups) = %d, want 2", len(backups))
+	}
+
+	if want := now.Format(backupDirectoryTimeLayout); backups[0].Name != want {
+		t.Fatalf("newest backup = %s, want %s", backups[0].Name, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "not-a-backup")); err != nil {
+		t.Fatalf("unrelated directory was pruned: %v", err)
+	}
+}
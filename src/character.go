@@ -76,6 +76,11 @@ type Race struct {
 	DisplayName      string `json:"display_name"`
 	Playable         bool   `json:"playable"`
 	PrimaryAttribute int    `json:"primaryAttribute"`
+
+	/* RESIST_*, IMMUNE_* and SUSCEPT_* bits shared by every member of the race */
+	Resist  int `json:"resist"`
+	Immune  int `json:"immune"`
+	Suscept int `json:"suscept"`
 }
 
 const LevelAdmin = 60
@@ -123,6 +128,9 @@ const (
 	RESIST_COLD   = 1 << 2
 	RESIST_SHOCK  = 1 << 3
 	RESIST_POISON = 1 << 4
+	RESIST_SLASH  = 1 << 5
+	RESIST_STAB   = 1 << 6
+	RESIST_EXOTIC = 1 << 7
 )
 
 const (
@@ -131,6 +139,9 @@ const (
 	IMMUNE_COLD   = 1 << 2
 	IMMUNE_SHOCK  = 1 << 3
 	IMMUNE_POISON = 1 << 4
+	IMMUNE_SLASH  = 1 << 5
+	IMMUNE_STAB   = 1 << 6
+	IMMUNE_EXOTIC = 1 << 7
 )
 
 const (
@@ -139,6 +150,9 @@ const (
 	SUSCEPT_COLD   = 1 << 2
 	SUSCEPT_SHOCK  = 1 << 3
 	SUSCEPT_POISON = 1 << 4
+	SUSCEPT_SLASH  = 1 << 5
+	SUSCEPT_STAB   = 1 << 6
+	SUSCEPT_EXOTIC = 1 << 7
 )
 
 const (
@@ -151,6 +165,8 @@ const (
 	AFFECT_FIRESHIELD   = 1 << 6
 	AFFECT_PARALYSIS    = 1 << 7
 	AFFECT_BLINDNESS    = 1 << 8
+	AFFECT_CHARM        = 1 << 9
+	AFFECT_FLYING       = 1 << 10
 )
 
 const (
@@ -204,12 +220,16 @@ type Character struct {
 	outputLines  int
 	inputCursor  int
 
+	/* Everything sent to a headless character, e.g. one spawned by a script test */
+	transcript *bytes.Buffer
+
 	PlaneIndex *Point[*Character] `json:"planeIndex"`
 	Trail      []*Room            `json:"trail"`
 	Room       *Room              `json:"room"`
 	moveOrigin *Room
-	Combat     *Combat         `json:"combat"`
-	Fighting   *Character      `json:"fighting"`
+	Combat     *Combat    `json:"combat"`
+	Fighting   *Character `json:"fighting"`
+	threat     []threatEntry
 	Casting    *CastingContext `json:"casting"`
 	Furniture  *ObjectInstance `json:"furniture"`
 
@@ -241,6 +261,21 @@ type Character struct {
 	Flags int               `json:"flags"`
 	Afk   *AwayFromKeyboard `json:"afk"`
 
+	/* A player's PREF_* toggles, and the health below which they flee automatically */
+	Preferences int `json:"preferences"`
+	Wimpy       int `json:"wimpy"`
+
+	/* Player killing consent, and the seconds of play left on its toggle cooldown and the KILLER and THIEF flags */
+	PkConsent  bool `json:"pkConsent"`
+	PkCooldown int  `json:"pkCooldown"`
+	Killer     int  `json:"killer"`
+	Thief      int  `json:"thief"`
+
+	/* A mobile's own damage modifiers, on top of its race's */
+	Resist  int `json:"resist"`
+	Immune  int `json:"immune"`
+	Suscept int `json:"suscept"`
+
 	Health     int `json:"health"`
 	MaxHealth  int `json:"maxHealth"`
 	Mana       int `json:"mana"`
@@ -478,28 +513,33 @@ func (location playerCharacterLocation) hasPlaneCoordinates() bool {
 }
 
 func (ch *Character) playerLocation() playerCharacterLocation {
+	return roomLocation(ch.Room)
+}
+
+/* Where room can be found again after a reboot, falling back to limbo */
+func roomLocation(room *Room) playerCharacterLocation {
 	location := playerCharacterLocation{RoomId: RoomLimbo}
 
-	if ch.Room == nil {
+	if room == nil {
 		return location
 	}
 
-	if ch.Room.Id != 0 {
-		location.RoomId = ch.Room.Id
+	if room.Id != 0 {
+		location.RoomId = room.Id
 	}
 
-	if ch.Room.Flags&ROOM_PLANAR == 0 || ch.Room.Plane == nil || !ch.Room.Plane.SupportsPersistentCoordinates() {
+	if room.Flags&ROOM_PLANAR == 0 || room.Plane == nil || !room.Plane.SupportsPersistentCoordinates() {
 		return location
 	}
 
-	if ch.Room.Plane.Id <= 0 || !ch.Room.Plane.containsCoordinates(ch.Room.X, ch.Room.Y, ch.Room.Z) {
+	if room.Plane.Id <= 0 || !room.Plane.containsCoordinates(room.X, room.Y, room.Z) {
 		return location
 	}
 
-	location.PlaneId = validLocationInt(ch.Room.Plane.Id)
-	location.PlaneX = validLocationInt(ch.Room.X)
-	location.PlaneY = validLocationInt(ch.Room.Y)
-	location.PlaneZ = validLocationInt(ch.Room.Z)
+	location.PlaneId = validLocationInt(room.Plane.Id)
+	location.PlaneX = validLocationInt(room.X)
+	location.PlaneY = validLocationInt(room.Y)
+	location.PlaneZ = validLocationInt(room.Z)
 
 	return location
 }
@@ -569,10 +609,21 @@ func (game *Game) AttemptLogin(username string, password string) bool {
 		return false
 	}
 
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(saltPassword(password))) == nil
+}
+
+func saltPassword(password string) string {
 	sha256Sum := sha256.Sum256([]byte(password + Config.HashSalt))
-	saltedHash := hex.EncodeToString(sha256Sum[:])
+	return hex.EncodeToString(sha256Sum[:])
+}
 
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(saltedHash)) == nil
+func hashPassword(password string) (string, error) {
+	ciphertext, err := bcrypt.GenerateFromPassword([]byte(saltPassword(password)), 10)
+	if err != nil {
+		return "", err
+	}
+
+	return string(ciphertext), nil
 }
 
 func FindCharacterFlag(flag string) *Flag {
@@ -618,6 +669,21 @@ func (ch *Character) Finalize() error {
 		return nil
 	}
 
+	err := ch.insertPlayerCharacter()
+	if err != nil {
+		return err
+	}
+
+	limbo, err := ch.Game.LoadRoomIndex(RoomLimbo)
+	if err != nil {
+		return err
+	}
+
+	ch.Room = limbo
+	return nil
+}
+
+func (ch *Character) insertPlayerCharacter() error {
 	result, err := ch.Game.db.Exec(`
 		INSERT INTO
 			player_characters(username, password_hash, wizard, room_id, race_id, job_id, level, gold, experience, practices, health, max_health, mana, max_mana, stamina, max_stamina, condition_drunk, condition_full, condition_thirst, condition_hunger, stat_str, stat_dex, stat_int, stat_wis, stat_con, stat_cha, stat_lck)
@@ -637,13 +703,6 @@ func (ch *Character) Finalize() error {
 	}
 
 	ch.Id = int(userId)
-
-	limbo, err := ch.Game.LoadRoomIndex(RoomLimbo)
-	if err != nil {
-		return err
-	}
-
-	ch.Room = limbo
 	return nil
 }
 
@@ -902,6 +961,12 @@ func (ch *Character) Save() bool {
 			stat_con = ?,
 			stat_cha = ?,
 			stat_lck = ?,
+			wimpy = ?,
+			preference_flags = ?,
+			pk_consent = ?,
+			pk_cooldown = ?,
+			killer = ?,
+			thief = ?,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE
 			id = ?
@@ -935,6 +1000,12 @@ func (ch *Character) Save() bool {
 		ch.Stats[STAT_CONSTITUTION],
 		ch.Stats[STAT_CHARISMA],
 		ch.Stats[STAT_LUCK],
+		ch.Wimpy,
+		ch.Preferences,
+		ch.PkConsent,
+		ch.PkCooldown,
+		ch.Killer,
+		ch.Thief,
 		ch.Id,
 	)
 	if err != nil {
@@ -1160,6 +1231,19 @@ func (ch *Character) attachObjectTx(ctx context.Context, tx *sql.Tx, obj *Object
 		return reified, err
 	}
 
+	/* Carried now, whatever container it came out of */
+	_, err = tx.ExecContext(ctx, `
+		UPDATE
+			object_instances
+		SET
+			inside_object_instance_id = NULL
+		WHERE
+			id = ?
+	`, obj.Id)
+	if err != nil {
+		return reified, err
+	}
+
 	var values strings.Builder
 	args := make([]interface{}, 0, len(ids)*2)
 
@@ -1343,6 +1427,7 @@ func (game *Game) SavePlayerInventory(ch *Character) error {
 		}
 
 		obj.ensureDecayState(time.Now())
+		obj.ensureSerial()
 
 		if obj.Inside != nil {
 			_, err = tx.ExecContext(ctx, `
@@ -1362,10 +1447,11 @@ func (game *Game) SavePlayerInventory(ch *Character) error {
 					weight = ?,
 					ttl = ?,
 					created_at = ?,
+					serial = ?,
 					inside_object_instance_id = ?
 				WHERE
 					id = ?
-			`, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.WearLocation, obj.Flags, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt, obj.Inside.Id, obj.Id)
+			`, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.WearLocation, obj.Flags, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt, obj.Serial, obj.Inside.Id, obj.Id)
 		} else {
 			_, err = tx.ExecContext(ctx, `
 				UPDATE
@@ -1384,10 +1470,11 @@ func (game *Game) SavePlayerInventory(ch *Character) error {
 					weight = ?,
 					ttl = ?,
 					created_at = ?,
+					serial = ?,
 					inside_object_instance_id = NULL
 				WHERE
 					id = ?
-			`, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.WearLocation, obj.Flags, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt, obj.Id)
+			`, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.WearLocation, obj.Flags, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt, obj.Serial, obj.Id)
 		}
 
 		if err != nil {
@@ -1410,6 +1497,7 @@ func (game *Game) LoadPlayerInventory(ch *Character) error {
 		SELECT
 			object_instances.id,
 			object_instances.parent_id,
+			object_instances.serial,
 			object_instances.name,
 			object_instances.short_description,
 			object_instances.long_description,
@@ -1450,12 +1538,14 @@ func (game *Game) LoadPlayerInventory(ch *Character) error {
 			WearLocation: -1,
 		}
 
+		var serial sql.NullString
 		var createdAt sql.NullInt64
-		err = rows.Scan(&obj.Id, &obj.ParentId, &obj.Name, &obj.ShortDescription, &obj.LongDescription, &obj.Description, &obj.Flags, &obj.ItemType, &obj.WearLocation, &obj.Value0, &obj.Value1, &obj.Value2, &obj.Value3, &obj.Weight, &obj.Ttl, &createdAt)
+		err = rows.Scan(&obj.Id, &obj.ParentId, &serial, &obj.Name, &obj.ShortDescription, &obj.LongDescription, &obj.Description, &obj.Flags, &obj.ItemType, &obj.WearLocation, &obj.Value0, &obj.Value1, &obj.Value2, &obj.Value3, &obj.Weight, &obj.Ttl, &createdAt)
 		if err != nil {
 			return err
 		}
 
+		obj.Serial = serial.String
 		obj.CreatedAt = objectCreatedAtFromUnix(createdAt)
 		obj.Ttl = normalizeObjectTtl(obj.Flags, obj.Ttl)
 		ch.addObject(obj, true)
@@ -1466,57 +1556,10 @@ func (game *Game) LoadPlayerInventory(ch *Character) error {
 	}
 
 	for obj := range ch.Inventory.All() {
-		rows, err := game.db.Query(`
-			SELECT
-				object_instances.id,
-				object_instances.parent_id,
-				object_instances.name,
-				object_instances.short_description,
-				object_instances.long_description,
-				object_instances.description,
-				object_instances.flags,
-				object_instances.item_type,
-				object_instances.value_1,
-				object_instances.value_2,
-				object_instances.value_3,
-				object_instances.value_4,
-				object_instances.weight,
-				object_instances.ttl,
-				CAST(strftime('%s', object_instances.created_at) AS INTEGER)
-			FROM
-				object_instances
-			WHERE
-				object_instances.inside_object_instance_id = ?
-		`, obj.Id)
+		err = game.loadContainedObjects(obj, false)
 		if err != nil {
 			return err
 		}
-
-		defer rows.Close()
-
-		for rows.Next() {
-			containedObj := &ObjectInstance{
-				Game:         game,
-				Contents:     NewLinkedList[*ObjectInstance](),
-				Inside:       nil,
-				CarriedBy:    nil,
-				WearLocation: -1,
-			}
-
-			var createdAt sql.NullInt64
-			err = rows.Scan(&containedObj.Id, &containedObj.ParentId, &containedObj.Name, &containedObj.ShortDescription, &containedObj.LongDescription, &containedObj.Description, &containedObj.Flags, &containedObj.ItemType, &containedObj.Value0, &containedObj.Value1, &containedObj.Value2, &containedObj.Value3, &containedObj.Weight, &containedObj.Ttl, &createdAt)
-			if err != nil {
-				return err
-			}
-
-			containedObj.CreatedAt = objectCreatedAtFromUnix(createdAt)
-			containedObj.Ttl = normalizeObjectTtl(containedObj.Flags, containedObj.Ttl)
-			obj.AddObject(containedObj)
-		}
-
-		if err := rows.Err(); err != nil {
-			return err
-		}
 	}
 
 	return nil
@@ -1569,7 +1612,13 @@ func (game *Game) FindPlayerByName(username string) (*Character, *Room, error) {
 			stat_wis,
 			stat_con,
 			stat_cha,
-			stat_lck
+			stat_lck,
+			wimpy,
+			preference_flags,
+			pk_consent,
+			pk_cooldown,
+			killer,
+			thief
 		FROM
 			player_characters
 		WHERE
@@ -1617,6 +1666,12 @@ func (game *Game) FindPlayerByName(username string) (*Character, *Room, error) {
 		&ch.Stats[STAT_CONSTITUTION],
 		&ch.Stats[STAT_CHARISMA],
 		&ch.Stats[STAT_LUCK],
+		&ch.Wimpy,
+		&ch.Preferences,
+		&ch.PkConsent,
+		&ch.PkCooldown,
+		&ch.Killer,
+		&ch.Thief,
 	)
 
 	if err != nil {
@@ -1803,6 +1858,10 @@ func (ch *Character) isFighting() bool {
 }
 
 func (ch *Character) Write(data []byte) (n int, err error) {
+	if ch.transcript != nil {
+		ch.transcript.Write(data)
+	}
+
 	if ch.Client == nil {
 		/* If there is no client, succeed silently. */
 		return len(data), nil
@@ -2334,6 +2393,8 @@ func NewCharacter() *Character {
 	character.Conditions[ConditionHunger] = ConditionMaximum
 
 	character.Defense = 0
+	character.Preferences = DefaultPreferences
+	character.Wimpy = 0
 
 	character.Stats = make([]int, STAT_MAX)
 	for index := range character.Stats {
@@ -0,0 +1,493 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+const cliUsage = `Usage: golem [command]
+
+With no command, golem starts the game server.
+
+Commands:
+  player list                            list all player characters
+  player show <name>                     show a player character's details
+  player set-level <name> <level>        set a player character's level
+  player reset-password <name>          set a new password, read from stdin
+  player delete <name>                   soft-delete a player character
+  migrate up [n]                         apply all or n pending migrations
+  migrate down [n]                       roll back n migrations (default 1)
+  migrate force <version>                mark a version as applied and clean, or -1 for none
+  migrate version                        print the current migration version
+  check-world                            check database integrity and world references
+  restore <file>                         restore a database backup
+  dts [file]                             write TypeScript declarations for the scripting API
+  test-scripts [-v] [-run re] [path...]  run *.test.js script suites against a fixture world
+
+Player changes made while the server is running are overwritten when that player next saves.
+`
+
+/* Offline maintenance subcommands which operate without starting the listener */
+func runCommand(command string, arguments []string) int {
+	var err error
+
+	switch command {
+	case "restore":
+		return runRestoreCommand(arguments)
+
+	case "player":
+		err = withDatabase(func(db *sql.DB) error {
+			return runPlayerCommand(db, arguments, os.Stdin, os.Stdout)
+		})
+
+	case "migrate":
+		err = withDatabase(func(db *sql.DB) error {
+			return runMigrateCommand(db, arguments, os.Stdout)
+		})
+
+	case "check-world":
+		err = withDatabase(func(db *sql.DB) error {
+			return runCheckWorldCommand(db, os.Stdout)
+		})
+
+	case "dts":
+		err = runDeclarationsCommand(arguments)
+
+	case "test-scripts":
+		err = runScriptTestsCommand(arguments)
+
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, cliUsage)
+		return 0
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n%s", command, cliUsage)
+		return 2
+	}
+
+	if err != nil {
+		if errors.Is(err, errCommandUsage) {
+			fmt.Fprint(os.Stderr, cliUsage)
+			return 2
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+		return 1
+	}
+
+	return 0
+}
+
+var errCommandUsage = errors.New("invalid command usage")
+
+func withDatabase(fn func(db *sql.DB) error) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return fn(db)
+}
+
+func runPlayerCommand(db *sql.DB, arguments []string, in io.Reader, out io.Writer) error {
+	if len(arguments) < 1 {
+		return errCommandUsage
+	}
+
+	switch arguments[0] {
+	case "list":
+		return listPlayers(db, out)
+
+	case "show":
+		if len(arguments) != 2 {
+			return errCommandUsage
+		}
+
+		return showPlayer(db, arguments[1], out)
+
+	case "set-level":
+		if len(arguments) != 3 {
+			return errCommandUsage
+		}
+
+		level, err := strconv.Atoi(arguments[2])
+		if err != nil || level < 1 || level > LevelAdmin {
+			return fmt.Errorf("level must be an integer between 1 and %d", LevelAdmin)
+		}
+
+		err = updatePlayer(db, arguments[1], `UPDATE player_characters SET level = ?, updated_at = CURRENT_TIMESTAMP WHERE username = ? AND deleted_at IS NULL`, level, arguments[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s is now level %d.\n", arguments[1], level)
+		return nil
+
+	case "reset-password":
+		/* Never take the password on the command line, where it would be visible in ps and shell history */
+		if len(arguments) != 2 {
+			return errCommandUsage
+		}
+
+		fmt.Fprintf(out, "New password for %s: ", arguments[1])
+
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		password := strings.TrimRight(line, "\r\n")
+
+		if password == "" {
+			return errors.New("password must not be empty")
+		}
+
+		hash, err := hashPassword(password)
+		if err != nil {
+			return err
+		}
+
+		err = updatePlayer(db, arguments[1], `UPDATE player_characters SET password_hash = ?, updated_at = CURRENT_TIMESTAMP WHERE username = ? AND deleted_at IS NULL`, hash, arguments[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Password for %s has been reset.\n", arguments[1])
+		return nil
+
+	case "delete":
+		if len(arguments) != 2 {
+			return errCommandUsage
+		}
+
+		err := updatePlayer(db, arguments[1], `UPDATE player_characters SET deleted_at = CURRENT_TIMESTAMP WHERE username = ? AND deleted_at IS NULL`, arguments[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s has been deleted.\n", arguments[1])
+		return nil
+	}
+
+	return errCommandUsage
+}
+
+func updatePlayer(db *sql.DB, username string, query string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("no player named %s", username)
+	}
+
+	return nil
+}
+
+func listPlayers(db *sql.DB, out io.Writer) error {
+	rows, err := db.Query(`
+		SELECT
+			player_characters.id,
+			player_characters.username,
+			player_characters.level,
+			races.display_name,
+			jobs.display_name,
+			player_characters.room_id
+		FROM
+			player_characters
+		INNER JOIN
+			races
+		ON
+			races.id = player_characters.race_id
+		INNER JOIN
+			jobs
+		ON
+			jobs.id = player_characters.job_id
+		WHERE
+			player_characters.deleted_at IS NULL
+		ORDER BY
+			player_characters.level DESC,
+			player_characters.username ASC
+	`)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tNAME\tLEVEL\tRACE\tJOB\tROOM")
+
+	count := 0
+	for rows.Next() {
+		var id, level, roomId int
+		var username, race, job string
+
+		err := rows.Scan(&id, &username, &level, &race, &job, &roomId)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%s\t%s\t%d\n", id, username, level, strings.TrimSpace(race), strings.TrimSpace(job), roomId)
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	fmt.Fprintf(out, "\n%d players.\n", count)
+	return nil
+}
+
+func showPlayer(db *sql.DB, username string, out io.Writer) error {
+	var id, level, experience, practices, gold, roomId int
+	var health, maxHealth, mana, maxMana, stamina, maxStamina int
+	var wizard bool
+	var name, race, job, createdAt string
+
+	err := db.QueryRow(`
+		SELECT
+			player_characters.id,
+			player_characters.username,
+			player_characters.wizard,
+			player_characters.level,
+			player_characters.experience,
+			player_characters.practices,
+			player_characters.gold,
+			player_characters.room_id,
+			player_characters.health,
+			player_characters.max_health,
+			player_characters.mana,
+			player_characters.max_mana,
+			player_characters.stamina,
+			player_characters.max_stamina,
+			races.display_name,
+			jobs.display_name,
+			player_characters.created_at
+		FROM
+			player_characters
+		INNER JOIN
+			races
+		ON
+			races.id = player_characters.race_id
+		INNER JOIN
+			jobs
+		ON
+			jobs.id = player_characters.job_id
+		WHERE
+			player_characters.username = ?
+		AND
+			player_characters.deleted_at IS NULL
+	`, username).Scan(&id, &name, &wizard, &level, &experience, &practices, &gold, &roomId, &health, &maxHealth, &mana, &maxMana, &stamina, &maxStamina, &race, &job, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no player named %s", username)
+		}
+
+		return err
+	}
+
+	var items int
+
+	err = db.QueryRow(`
+		SELECT
+			COUNT(*)
+		FROM
+			player_character_object
+		WHERE
+			player_character_id = ?
+	`, id).Scan(&items)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "ID:\t%d\n", id)
+	fmt.Fprintf(writer, "Name:\t%s\n", name)
+	fmt.Fprintf(writer, "Wizard:\t%t\n", wizard)
+	fmt.Fprintf(writer, "Level:\t%d\n", level)
+	fmt.Fprintf(writer, "Race:\t%s\n", strings.TrimSpace(race))
+	fmt.Fprintf(writer, "Job:\t%s\n", strings.TrimSpace(job))
+	fmt.Fprintf(writer, "Experience:\t%d\n", experience)
+	fmt.Fprintf(writer, "Practices:\t%d\n", practices)
+	fmt.Fprintf(writer, "Gold:\t%d\n", gold)
+	fmt.Fprintf(writer, "Health:\t%d/%d\n", health, maxHealth)
+	fmt.Fprintf(writer, "Mana:\t%d/%d\n", mana, maxMana)
+	fmt.Fprintf(writer, "Stamina:\t%d/%d\n", stamina, maxStamina)
+	fmt.Fprintf(writer, "Room:\t%d\n", roomId)
+	fmt.Fprintf(writer, "Items:\t%d\n", items)
+	fmt.Fprintf(writer, "Created:\t%s\n", createdAt)
+
+	return writer.Flush()
+}
+
+func runMigrateCommand(db *sql.DB, arguments []string, out io.Writer) error {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return errCommandUsage
+	}
+
+	driver, databaseName, migrationSource, err := databaseMigrationDriver(db)
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(migrationSource, databaseName, driver)
+	if err != nil {
+		return err
+	}
+
+	/* Forcing version -1 clears a dirty first migration back to no version at all */
+	steps := 0
+	if len(arguments) == 2 {
+		steps, err = strconv.Atoi(arguments[1])
+		if err != nil || (steps < 0 && !(arguments[0] == "force" && steps == -1)) {
+			return fmt.Errorf("invalid argument %q", arguments[1])
+		}
+	}
+
+	switch arguments[0] {
+	case "up":
+		if steps > 0 {
+			err = m.Steps(steps)
+		} else {
+			err = m.Up()
+		}
+
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+
+		err = m.Steps(-steps)
+
+	case "force":
+		if len(arguments) != 2 {
+			return errCommandUsage
+		}
+
+		err = m.Force(steps)
+
+	case "version":
+		if len(arguments) != 1 {
+			return errCommandUsage
+		}
+
+	default:
+		return errCommandUsage
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintln(out, "No migrations applied.")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Fprintf(out, "Migration version %d of %d (dirty).\n", version, latest)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Migration version %d of %d.\n", version, latest)
+	return nil
+}
+
+func runCheckWorldCommand(db *sql.DB, out io.Writer) error {
+	var result string
+
+	err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result)
+	if err != nil {
+		return err
+	}
+
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	fmt.Fprintln(out, "Integrity check passed.")
+
+	rows, err := db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	violations := 0
+	for rows.Next() {
+		var table, parent string
+		var rowId sql.NullInt64
+		var foreignKey int
+
+		err := rows.Scan(&table, &rowId, &parent, &foreignKey)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s row %d references a missing %s.\n", table, rowId.Int64, parent)
+		violations++
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if violations > 0 {
+		return fmt.Errorf("%d foreign key violations", violations)
+	}
+
+	fmt.Fprintln(out, "Foreign key check passed.")
+
+	issues, err := CheckWorld(db)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		fmt.Fprintf(out, "[%s] %s\n", issue.Kind, issue.Message)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d world issues (%s)", len(issues), summarizeWorldIssues(issues))
+	}
+
+	fmt.Fprintln(out, "World check passed.")
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/* A temporary SQLite database brought up by the migrate command, which reads migrations relative to the project root */
+func newCommandTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Chdir("..")
+
+	db, err := sql.Open(databaseDriverSQLite, filepath.Join(t.TempDir(), "golem.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	var out bytes.Buffer
+	if err := runMigrateCommand(db, []string{"up"}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestMigrateCommandStepsAndReportsVersions(t *testing.T) {
+	db := newCommandTestDatabase(t)
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		arguments []string
+		want      string
+	}{
+		{[]string{"version"}, fmt.Sprintf("Migration version %d of %d.", latest, latest)},
+		{[]string{"down", "2"}, fmt.Sprintf("Migration version %d of %d.", latest-2, latest)},
+		{[]string{"up", "1"}, fmt.Sprintf("Migration version %d of %d.", latest-1, latest)},
+		{[]string{"up"}, fmt.Sprintf("Migration version %d of %d.", latest, latest)},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := runMigrateCommand(db, test.arguments, &out); err != nil {
+			t.Fatalf("migrate %v: %v", test.arguments, err)
+		}
+
+		if got := strings.TrimSpace(out.String()); got != test.want {
+			t.Fatalf("migrate %v printed %q, want %q", test.arguments, got, test.want)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runMigrateCommand(db, []string{"force", "-1"}, &out); err != nil || strings.TrimSpace(out.String()) != "No migrations applied." {
+		t.Fatalf("migrate force -1 = %v, printed %q", err, out.String())
+	}
+
+	for _, arguments := range [][]string{{}, {"sideways"}, {"down", "-1"}, {"force", "-2"}, {"version", "1"}} {
+		if err := runMigrateCommand(db, arguments, &bytes.Buffer{}); err == nil {
+			t.Errorf("migrate %v was accepted", arguments)
+		}
+	}
+}
+
+func TestPlayerCommandsManageCharactersOffline(t *testing.T) {
+	db := newCommandTestDatabase(t)
+
+	_, err := db.Exec(`
+		INSERT INTO
+			player_characters(username, password_hash, wizard, room_id, race_id, job_id, level, gold, experience, practices, health, max_health, mana, max_mana, stamina, max_stamina, condition_drunk, condition_full, condition_thirst, condition_hunger, stat_str, stat_dex, stat_int, stat_wis, stat_con, stat_cha, stat_lck)
+		VALUES
+			('Tester', '', 0, ?, (SELECT MIN(id) FROM races), (SELECT MIN(id) FROM jobs), 5, 120, 0, 0, 20, 20, 100, 100, 100, 100, 0, 48, 48, 48, 13, 13, 13, 13, 13, 13, 13)
+	`, RoomLimbo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(input string, arguments ...string) (string, error) {
+		var out bytes.Buffer
+		err := runPlayerCommand(db, arguments, strings.NewReader(input), &out)
+
+		return out.String(), err
+	}
+
+	out, err := run("", "list")
+	if err != nil || !strings.Contains(out, "Tester") || !strings.Contains(out, "2 players.") {
+		t.Fatalf("player list = %q, %v", out, err)
+	}
+
+	if _, err := run("", "set-level", "Tester", "12"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = run("", "show", "Tester")
+	fields := strings.Join(strings.Fields(out), " ")
+	if err != nil || !strings.Contains(fields, "Level: 12") || !strings.Contains(fields, "Gold: 120") {
+		t.Fatalf("player show = %q, %v", out, err)
+	}
+
+	if _, err := run("", "set-level", "Tester", "0"); err == nil {
+		t.Error("an out of range level was accepted")
+	}
+
+	if _, err := run("hunter2\n", "reset-password", "Tester", "hunter2"); !errors.Is(err, errCommandUsage) {
+		t.Errorf("a password on the command line was accepted: %v", err)
+	}
+
+	if _, err := run("\n", "reset-password", "Tester"); err == nil {
+		t.Error("an empty password was accepted")
+	}
+
+	out, err = run("correct horse\r\n", "reset-password", "Tester")
+	if err != nil || !strings.Contains(out, "New password for Tester: ") {
+		t.Fatalf("player reset-password = %q, %v", out, err)
+	}
+
+	var hash string
+	if err := db.QueryRow(`SELECT password_hash FROM player_characters WHERE username = 'Tester'`).Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(saltPassword("correct horse"))) != nil {
+		t.Error("the new password was not read from stdin and stored")
+	}
+
+	if _, err := run("", "delete", "Tester"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := run("", "show", "Tester"); err == nil {
+		t.Error("a deleted player can still be shown")
+	}
+
+	if _, err := run("", "delete", "Nobody"); err == nil {
+		t.Error("deleting a missing player succeeded")
+	}
+}
+
+func TestCheckWorldCommandReportsBrokenReferences(t *testing.T) {
+	db := newCommandTestDatabase(t)
+
+	var out bytes.Buffer
+	if err := runCheckWorldCommand(db, &out); err != nil {
+		t.Fatalf("the seed world failed its check: %v\n%s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), "World check passed.") {
+		t.Fatalf("check-world printed %q", out.String())
+	}
+
+	if _, err := db.Exec(`UPDATE resets SET value_1 = 999999 WHERE id = (SELECT MIN(id) FROM resets WHERE type = 'mobile')`); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err := runCheckWorldCommand(db, &out)
+	if err == nil || !strings.Contains(out.String(), "references missing or deleted mobile 999999") {
+		t.Fatalf("check-world = %v, printed %q", err, out.String())
+	}
+}
@@ -9,6 +9,7 @@ package main
 
 import (
 	"regexp"
+	"strings"
 )
 
 const AnsiBoldRed = "\u001b[31;1m"
@@ -105,8 +106,16 @@ func init() {
 	}
 }
 
+/* Stands in for an escaped brace while colour codes are translated, as game text never holds a NUL */
+const escapedColourBrace = "\x00"
+
+/* Show text such as script source as written, without its braces read as colour codes */
+func escapeColourCodes(s string) string {
+	return strings.ReplaceAll(s, "{", "{{")
+}
+
 func (client *Client) TranslateColourCodes(s string) string {
-	input := string(s)
+	input := strings.ReplaceAll(s, "{{", escapedColourBrace)
 
 	for colour := range AnsiColourCodeTable {
 		if client.ansiEnabled {
@@ -120,7 +129,18 @@ func (client *Client) TranslateColourCodes(s string) string {
 		}
 	}
 
-	return input
+	return strings.ReplaceAll(input, escapedColourBrace, "{")
+}
+
+/* Remove colour codes as a client without ANSI enabled would see the text */
+func stripColourCodes(s string) string {
+	s = strings.ReplaceAll(s, "{{", escapedColourBrace)
+
+	for colour := range AnsiColourCodeTable {
+		s = AnsiColourCodeTable[colour].regularExpression.ReplaceAllString(s, "")
+	}
+
+	return strings.ReplaceAll(s, escapedColourBrace, "{")
 }
 
 func SeverityColourFromPercentage(percentage int) string {
@@ -0,0 +1,578 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCombatLogCapacity  = 100
+	defaultCombatLogDirectory = "combatlogs"
+
+	CombatEventDamage = "damage"
+	CombatEventHeal   = "heal"
+	CombatEventMiss   = "miss"
+	CombatEventSpell  = "spell"
+	CombatEventDeath  = "death"
+)
+
+type CombatLogParticipant struct {
+	Name   string `json:"name"`
+	Id     int    `json:"id"`
+	Level  uint   `json:"level"`
+	Player bool   `json:"player"`
+}
+
+/*
+ * One thing that happened in a fight.  Damage and heals carry the amount
+ * asked for (raw) and what landed after mitigation; misses carry whether
+ * the attack missed outright or was parried, dodged and so on.
+ */
+type CombatLogEvent struct {
+	Round       int       `json:"round"`
+	At          time.Time `json:"at"`
+	Kind        string    `json:"kind"`
+	Actor       string    `json:"actor,omitempty"`
+	Target      string    `json:"target,omitempty"`
+	DamageType  string    `json:"damageType,omitempty"`
+	Raw         int       `json:"raw,omitempty"`
+	Amount      int       `json:"amount,omitempty"`
+	Absorbed    int       `json:"absorbed,omitempty"`
+	Immune      bool      `json:"immune,omitempty"`
+	Resisted    bool      `json:"resisted,omitempty"`
+	Susceptible bool      `json:"susceptible,omitempty"`
+	Sanctuary   bool      `json:"sanctuary,omitempty"`
+	Outcome     string    `json:"outcome,omitempty"`
+	Spell       string    `json:"spell,omitempty"`
+}
+
+/* A fight from its first blow to its end, as shown by combatlog and written out by combatlog export */
+type CombatLog struct {
+	Id           uint                    `json:"id"`
+	RoomId       uint                    `json:"roomId"`
+	StartedAt    time.Time               `json:"startedAt"`
+	EndedAt      time.Time               `json:"endedAt"`
+	Rounds       int                     `json:"rounds"`
+	Participants []*CombatLogParticipant `json:"participants"`
+	Events       []*CombatLogEvent       `json:"events"`
+
+	/* Participant names, made unique when several share a short description */
+	names map[*Character]string
+}
+
+/* What one participant did and suffered over a fight */
+type CombatLogSummary struct {
+	Name     string  `json:"name"`
+	Dealt    int     `json:"dealt"`
+	Taken    int     `json:"taken"`
+	Healed   int     `json:"healed"`
+	Hits     int     `json:"hits"`
+	Misses   int     `json:"misses"`
+	Spells   int     `json:"spells"`
+	Kills    int     `json:"kills"`
+	Died     bool    `json:"died"`
+	Dps      float64 `json:"dps"`
+	TakenDps float64 `json:"takenDps"`
+}
+
+func (combat *Combat) combatLog() *CombatLog {
+	if combat.log == nil {
+		combat.log = &CombatLog{
+			StartedAt:    combat.StartedAt,
+			Participants: make([]*CombatLogParticipant, 0),
+			Events:       make([]*CombatLogEvent, 0),
+			names:        make(map[*Character]string),
+		}
+
+		if combat.log.StartedAt.IsZero() {
+			combat.log.StartedAt = time.Now()
+		}
+
+		if combat.Room != nil {
+			combat.log.RoomId = combat.Room.Id
+		}
+	}
+
+	return combat.log
+}
+
+/* The name ch goes by in this log, adding it as a participant the first time it appears */
+func (combatLog *CombatLog) addParticipant(ch *Character) string {
+	if ch == nil {
+		return ""
+	}
+
+	if name, ok := combatLog.names[ch]; ok {
+		return name
+	}
+
+	name := ch.GetShortDescriptionUpper(ch)
+	taken := func(name string) bool {
+		for _, participant := range combatLog.Participants {
+			if participant.Name == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for n := 2; taken(name); n++ {
+		name = fmt.Sprintf("%s #%d", ch.GetShortDescriptionUpper(ch), n)
+	}
+
+	combatLog.names[ch] = name
+	combatLog.Participants = append(combatLog.Participants, &CombatLogParticipant{
+		Name:   name,
+		Id:     ch.Id,
+		Level:  ch.Level,
+		Player: ch.Flags&CHAR_IS_PLAYER != 0,
+	})
+
+	return name
+}
+
+func (combatLog *CombatLog) record(event *CombatLogEvent) {
+	event.Round = combatLog.Rounds
+	event.At = time.Now()
+
+	combatLog.Events = append(combatLog.Events, event)
+}
+
+/* The fight ch or target is part of, if any */
+func combatOf(ch *Character, target *Character) *Combat {
+	if ch != nil && ch.Combat != nil {
+		return ch.Combat
+	}
+
+	if target != nil && target.Combat != nil {
+		return target.Combat
+	}
+
+	return nil
+}
+
+func (game *Game) logCombatDamage(ch *Character, target *Character, result *DamageResult) {
+	combat := combatOf(ch, target)
+	if combat == nil || result == nil {
+		return
+	}
+
+	combatLog := combat.combatLog()
+	event := &CombatLogEvent{
+		Kind:        CombatEventDamage,
+		Actor:       combatLog.addParticipant(ch),
+		Target:      combatLog.addParticipant(target),
+		Raw:         result.Requested,
+		Amount:      result.Amount,
+		Absorbed:    result.Absorbed,
+		Immune:      result.Immune,
+		Resisted:    result.Resisted,
+		Susceptible: result.Susceptible,
+		Sanctuary:   result.Sanctuary,
+	}
+
+	if entry := FindDamageType(result.DamageType); entry != nil {
+		event.DamageType = entry.Name
+	}
+
+	if result.Amount < 0 {
+		event.Kind = CombatEventHeal
+		event.Raw, event.Amount = -result.Requested, -result.Amount
+	}
+
+	combatLog.record(event)
+}
+
+/* An attack that never landed: outcome is "miss", or the defense that stopped it */
+func (game *Game) logCombatMiss(attack *CombatAttack, outcome string) {
+	combat := combatOf(attack.Attacker, attack.Victim)
+	if combat == nil {
+		return
+	}
+
+	combatLog := combat.combatLog()
+	event := &CombatLogEvent{
+		Kind:    CombatEventMiss,
+		Actor:   combatLog.addParticipant(attack.Attacker),
+		Target:  combatLog.addParticipant(attack.Victim),
+		Outcome: outcome,
+	}
+
+	if entry := FindDamageType(attack.DamageType); entry != nil {
+		event.DamageType = entry.Name
+	}
+
+	combatLog.record(event)
+}
+
+/* A spell ch finished casting, or lost to a fizzle, while fighting */
+func (game *Game) logCombatSpell(ch *Character, spell string, outcome string) {
+	if ch == nil || ch.Combat == nil {
+		return
+	}
+
+	combatLog := ch.Combat.combatLog()
+	combatLog.record(&CombatLogEvent{
+		Kind:    CombatEventSpell,
+		Actor:   combatLog.addParticipant(ch),
+		Spell:   spell,
+		Outcome: outcome,
+	})
+}
+
+func (game *Game) logCombatDeath(killer *Character, victim *Character) {
+	combat := combatOf(victim, killer)
+	if combat == nil {
+		return
+	}
+
+	combatLog := combat.combatLog()
+	event := &CombatLogEvent{
+		Kind:   CombatEventDeath,
+		Target: combatLog.addParticipant(victim),
+	}
+
+	if killer != nil && killer != victim {
+		event.Actor = combatLog.addParticipant(killer)
+	}
+
+	combatLog.record(event)
+}
+
+/* Seconds the fight lasted in combat rounds; at least one round, so short fights still have a rate */
+func (combatLog *CombatLog) Seconds() float64 {
+	return float64(max(1, combatLog.Rounds)) * combatPulse.Seconds()
+}
+
+func (combatLog *CombatLog) Summary() []*CombatLogSummary {
+	summaries := make([]*CombatLogSummary, 0, len(combatLog.Participants))
+	byName := make(map[string]*CombatLogSummary)
+
+	for _, participant := range combatLog.Participants {
+		summary := &CombatLogSummary{Name: participant.Name}
+
+		summaries = append(summaries, summary)
+		byName[participant.Name] = summary
+	}
+
+	for _, event := range combatLog.Events {
+		actor, target := byName[event.Actor], byName[event.Target]
+
+		switch event.Kind {
+		case CombatEventDamage:
+			if actor != nil {
+				actor.Dealt += event.Amount
+				actor.Hits++
+			}
+
+			if target != nil {
+				target.Taken += event.Amount
+			}
+
+		case CombatEventHeal:
+			if actor != nil {
+				actor.Healed += event.Amount
+			}
+
+		case CombatEventMiss:
+			if actor != nil {
+				actor.Misses++
+			}
+
+		case CombatEventSpell:
+			if actor != nil {
+				actor.Spells++
+			}
+
+		case CombatEventDeath:
+			if actor != nil {
+				actor.Kills++
+			}
+
+			if target != nil {
+				target.Died = true
+			}
+		}
+	}
+
+	for _, summary := range summaries {
+		summary.Dps = float64(summary.Dealt) / combatLog.Seconds()
+		summary.TakenDps = float64(summary.Taken) / combatLog.Seconds()
+	}
+
+	return summaries
+}
+
+/* Keep a finished fight, dropping the oldest beyond the configured capacity, and save it if asked to */
+func (game *Game) recordCombatLog(combat *Combat) {
+	combatLog := combat.log
+	if combatLog == nil || len(combatLog.Events) == 0 {
+		return
+	}
+
+	combatLog.EndedAt = time.Now()
+
+	if Config.CombatLogConfiguration.Persist && game.db != nil {
+		err := game.saveCombatLog(combatLog)
+		if err != nil {
+			log.Printf("Failed to save combat log: %v.\r\n", err)
+		}
+	}
+
+	if combatLog.Id == 0 {
+		game.combatLogSequence++
+		combatLog.Id = game.combatLogSequence
+	}
+
+	game.combatLogs = append(game.combatLogs, combatLog)
+	if excess := len(game.combatLogs) - Config.CombatLogConfiguration.Capacity; excess > 0 {
+		game.combatLogs = append([]*CombatLog(nil), game.combatLogs[excess:]...)
+	}
+}
+
+func (game *Game) saveCombatLog(combatLog *CombatLog) error {
+	data, err := json.Marshal(combatLog)
+	if err != nil {
+		return err
+	}
+
+	result, err := game.db.Exec(`
+		INSERT INTO
+			combat_logs(room_id, rounds, data, started_at, ended_at)
+		VALUES
+			(?, ?, ?, ?, ?)
+	`, combatLog.RoomId, combatLog.Rounds, string(data), combatLog.StartedAt, combatLog.EndedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	combatLog.Id = uint(id)
+	return nil
+}
+
+/* The most recently finished fights, oldest first */
+func (game *Game) CombatLogs() []*CombatLog {
+	return game.combatLogs
+}
+
+/* A fight still in memory, or one saved to the database */
+func (game *Game) FindCombatLog(id uint) *CombatLog {
+	for _, combatLog := range game.combatLogs {
+		if combatLog.Id == id {
+			return combatLog
+		}
+	}
+
+	if !Config.CombatLogConfiguration.Persist || game.db == nil {
+		return nil
+	}
+
+	var data string
+
+	err := game.db.QueryRow(`
+		SELECT
+			data
+		FROM
+			combat_logs
+		WHERE
+			id = ?
+	`, id).Scan(&data)
+	if err != nil {
+		return nil
+	}
+
+	combatLog := &CombatLog{}
+	if err := json.Unmarshal([]byte(data), combatLog); err != nil {
+		log.Printf("Failed to read combat log %d: %v.\r\n", id, err)
+		return nil
+	}
+
+	combatLog.Id = id
+	return combatLog
+}
+
+/* Write fights to a JSON file in the configured directory, returning its path */
+func exportCombatLogs(name string, combatLogs []*CombatLog) (string, error) {
+	data, err := json.MarshalIndent(combatLogs, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(Config.CombatLogConfiguration.Directory, 0o755)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(Config.CombatLogConfiguration.Directory, name)
+	err = os.WriteFile(path, data, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func combatLogParticipantNames(combatLog *CombatLog) string {
+	names := make([]string, 0, len(combatLog.Participants))
+	for _, participant := range combatLog.Participants {
+		names = append(names, participant.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (event *CombatLogEvent) String() string {
+	switch event.Kind {
+	case CombatEventDamage, CombatEventHeal:
+		var notes []string
+		if event.Absorbed > 0 {
+			notes = append(notes, fmt.Sprintf("%d absorbed", event.Absorbed))
+		}
+
+		for _, note := range []struct {
+			set  bool
+			name string
+		}{{event.Immune, "immune"}, {event.Resisted, "resisted"}, {event.Susceptible, "susceptible"}, {event.Sanctuary, "sanctuary"}} {
+			if note.set {
+				notes = append(notes, note.name)
+			}
+		}
+
+		detail := ""
+		if len(notes) > 0 {
+			detail = " (" + strings.Join(notes, ", ") + ")"
+		}
+
+		if event.Kind == CombatEventHeal {
+			return fmt.Sprintf("{G%s heals %s for %d of %d%s", event.Actor, event.Target, event.Amount, event.Raw, detail)
+		}
+
+		return fmt.Sprintf("{R%s hits %s with %s for %d of %d%s", event.Actor, event.Target, event.DamageType, event.Amount, event.Raw, detail)
+
+	case CombatEventMiss:
+		return fmt.Sprintf("{D%s misses %s (%s)", event.Actor, event.Target, event.Outcome)
+
+	case CombatEventSpell:
+		return fmt.Sprintf("{C%s casts %s (%s)", event.Actor, event.Spell, event.Outcome)
+
+	case CombatEventDeath:
+		if event.Actor == "" {
+			return fmt.Sprintf("{W%s dies", event.Target)
+		}
+
+		return fmt.Sprintf("{W%s is slain by %s", event.Target, event.Actor)
+	}
+
+	return event.Kind
+}
+
+func do_combatlog(ch *Character, arguments string) {
+	firstArgument, rest := OneArgument(arguments)
+
+	switch strings.ToLower(firstArgument) {
+	case "", "list":
+		combatLogs := ch.Game.CombatLogs()
+		if len(combatLogs) == 0 {
+			ch.Send("{DNo fights have been recorded yet.{x\r\n")
+			return
+		}
+
+		var output strings.Builder
+
+		output.WriteString(fmt.Sprintf("{Y%-6s %-6s %-6s %-7s %s\r\n", "Fight", "Room", "Rounds", "Events", "Participants"))
+		for i := len(combatLogs) - 1; i >= 0; i-- {
+			combatLog := combatLogs[i]
+			output.WriteString(fmt.Sprintf("{W%-6d {w%-6d %-6d %-7d %s\r\n", combatLog.Id, combatLog.RoomId, combatLog.Rounds, len(combatLog.Events), combatLogParticipantNames(combatLog)))
+		}
+
+		output.WriteString("{x")
+		ch.Send(output.String())
+
+	case "export":
+		var combatLogs []*CombatLog
+		var name string
+
+		if rest == "" {
+			combatLogs = ch.Game.CombatLogs()
+			name = fmt.Sprintf("combatlogs-%s.json", time.Now().Format("20060102-150405"))
+		} else {
+			id, err := strconv.Atoi(rest)
+			combatLog := ch.Game.FindCombatLog(uint(id))
+			if err != nil || id <= 0 || combatLog == nil {
+				ch.Send("No fight by that number has been recorded.\r\n")
+				return
+			}
+
+			combatLogs = []*CombatLog{combatLog}
+			name = fmt.Sprintf("combatlog-%d.json", combatLog.Id)
+		}
+
+		if len(combatLogs) == 0 {
+			ch.Send("{DNo fights have been recorded yet.{x\r\n")
+			return
+		}
+
+		path, err := exportCombatLogs(name, combatLogs)
+		if err != nil {
+			ch.Send(fmt.Sprintf("{RExport failed: %v{x\r\n", err))
+			return
+		}
+
+		ch.Send(fmt.Sprintf("{G%d fights written to {g%s{G.{x\r\n", len(combatLogs), path))
+
+	default:
+		id, err := strconv.Atoi(firstArgument)
+		if err != nil || id <= 0 {
+			ch.Send("{WCombat logs:\r\n" +
+				"{Gcombatlog                - {glist recent fights, newest first\r\n" +
+				"{Gcombatlog <fight>        - {gshow who did what in a fight, round by round\r\n" +
+				"{Gcombatlog export [fight] - {gwrite one fight, or every recent fight, to JSON{x\r\n")
+			return
+		}
+
+		combatLog := ch.Game.FindCombatLog(uint(id))
+		if combatLog == nil {
+			ch.Send("No fight by that number has been recorded.\r\n")
+			return
+		}
+
+		var output strings.Builder
+
+		output.WriteString(fmt.Sprintf("{YFight %d in room %d, %d rounds from %s to %s:{x\r\n", combatLog.Id, combatLog.RoomId, combatLog.Rounds, combatLog.StartedAt.Format(time.RFC1123), combatLog.EndedAt.Format(time.Kitchen)))
+		output.WriteString(fmt.Sprintf("{Y%-24s %6s %6s %6s %5s %6s %6s %7s{x\r\n", "Participant", "Dealt", "Taken", "Healed", "Hits", "Misses", "Kills", "DPS"))
+		for _, summary := range combatLog.Summary() {
+			died := ""
+			if summary.Died {
+				died = " {R(died){x"
+			}
+
+			output.WriteString(fmt.Sprintf("{W%-24s {w%6d %6d %6d %5d %6d %6d %7.1f%s{x\r\n", summary.Name, summary.Dealt, summary.Taken, summary.Healed, summary.Hits, summary.Misses, summary.Kills, summary.Dps, died))
+		}
+
+		output.WriteString("\r\n")
+		for _, event := range combatLog.Events {
+			output.WriteString(fmt.Sprintf("{D[%3d] %s{x\r\n", event.Round, event.String()))
+		}
+
+		ch.Send(output.String())
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import "testing"
+
+func TestCombatLogRecordsTheFight(t *testing.T) {
+	game := &Game{}
+	game.SetCombatSeed(7)
+
+	ch, victim := newCombatTestFight(game)
+
+	for round := 0; round < 10; round++ {
+		game.combatRound()
+	}
+
+	combat := ch.Combat
+	game.DisposeCombat(combat)
+
+	logs := game.CombatLogs()
+	if len(logs) != 1 || logs[0].Id != 1 {
+		t.Fatalf("%d combat logs kept, want the one fight", len(logs))
+	}
+
+	combatLog := logs[0]
+	if combatLog.Rounds != 10 || len(combatLog.Participants) != 2 {
+		t.Fatalf("%d rounds between %d participants, want 10 between 2", combatLog.Rounds, len(combatLog.Participants))
+	}
+
+	summaries := combatLog.Summary()
+	first, second := summaries[0], summaries[1]
+
+	if first.Dealt != 1000-victim.Health || second.Dealt != 1000-ch.Health || first.Taken != second.Dealt {
+		t.Errorf("logged %d and %d damage dealt, but health fell by %d and %d", first.Dealt, second.Dealt, 1000-victim.Health, 1000-ch.Health)
+	}
+
+	if first.Hits+first.Misses == 0 || first.Dps != float64(first.Dealt)/20 {
+		t.Errorf("%d hits, %d misses at %.1f damage per second", first.Hits, first.Misses, first.Dps)
+	}
+}
+
+func TestCombatLogsKeepOnlyTheMostRecent(t *testing.T) {
+	capacity := Config.CombatLogConfiguration.Capacity
+	Config.CombatLogConfiguration.Capacity = 2
+	defer func() { Config.CombatLogConfiguration.Capacity = capacity }()
+
+	game := &Game{}
+
+	for fight := 0; fight < 3; fight++ {
+		ch, victim := newCombatTestFight(game)
+		game.Damage(ch, victim, false, 10, DamageTypeBash)
+		game.DisposeCombat(ch.Combat)
+	}
+
+	logs := game.CombatLogs()
+	if len(logs) != 2 || logs[0].Id != 2 || logs[1].Id != 3 {
+		t.Fatalf("kept %d logs, want fights 2 and 3", len(logs))
+	}
+
+	if game.FindCombatLog(1) != nil || game.FindCombatLog(3) != logs[1] {
+		t.Error("found a fight that should have been dropped, or lost one that was kept")
+	}
+}
+
+func TestCombatLogTellsNamesakesApart(t *testing.T) {
+	combat := &Combat{}
+
+	for i := 0; i < 3; i++ {
+		slime := NewCharacter()
+		slime.ShortDescription = "a slime"
+		combat.addParticipant(slime)
+	}
+
+	participants := combat.combatLog().Participants
+	if len(participants) != 3 || participants[0].Name != "A slime" || participants[1].Name != "A slime #2" || participants[2].Name != "A slime #3" {
+		t.Fatalf("participants %+v", participants)
+	}
+}
@@ -0,0 +1,430 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/dop251/goja"
+)
+
+/*
+ * Steps of the combat round which scripts may override with
+ * Golem.registerCombatHook.  Each hook is handed the value the engine worked
+ * out for that step as its last argument and returns a replacement; returning
+ * undefined or null, or throwing, keeps the engine's value.
+ *
+ *   initiative (ch, roll)         - higher rolls act first
+ *   target     (ch, target)       - who ch attacks this round; mobiles pick by threat
+ *   attacks    (ch, count)        - attacks made this round
+ *   toHit      (attack, chance)   - percent chance for an attack to land
+ *   defense    (attack, outcome)  - "parry", "dodge", "acrobatics" or "" if the attack is not avoided
+ *   damage     (attack, amount)   - damage dealt by a landed attack, of attack.damageType
+ */
+const (
+	CombatStepInitiative = "initiative"
+	CombatStepTarget     = "target"
+	CombatStepAttacks    = "attacks"
+	CombatStepToHit      = "toHit"
+	CombatStepDefense    = "defense"
+	CombatStepDamage     = "damage"
+)
+
+var CombatSteps []string = []string{
+	CombatStepInitiative,
+	CombatStepTarget,
+	CombatStepAttacks,
+	CombatStepToHit,
+	CombatStepDefense,
+	CombatStepDamage,
+}
+
+const (
+	CombatDefenseNone       = ""
+	CombatDefenseParry      = "parry"
+	CombatDefenseDodge      = "dodge"
+	CombatDefenseAcrobatics = "acrobatics"
+)
+
+type CombatHook struct {
+	step     string
+	callback goja.Callable
+
+	/* Script module that registered the hook */
+	module string
+}
+
+/* A single swing at a victim, as seen by the toHit, defense and damage hooks */
+type CombatAttack struct {
+	Attacker   *Character      `json:"attacker"`
+	Victim     *Character      `json:"victim"`
+	Weapon     *ObjectInstance `json:"weapon"`
+	Number     int             `json:"number"`
+	DamageType int             `json:"damageType"`
+}
+
+func (game *Game) RegisterCombatHook(step string, fn goja.Callable) (*CombatHook, error) {
+	known := false
+	for _, name := range CombatSteps {
+		if name == step {
+			known = true
+			break
+		}
+	}
+
+	if !known {
+		return nil, fmt.Errorf("no such combat step %q", step)
+	}
+
+	if game.combatHooks == nil {
+		game.combatHooks = make(map[string]*CombatHook)
+	}
+
+	hook := &CombatHook{step: step, callback: fn, module: game.currentScriptModule()}
+	game.combatHooks[step] = hook
+
+	return hook, nil
+}
+
+/* Call the override for step with arguments converted for the VM, returning nil to keep the engine's value */
+func (game *Game) callCombatHook(step string, arguments ...interface{}) goja.Value {
+	hook := game.combatHooks[step]
+	if hook == nil || game.vm == nil {
+		return nil
+	}
+
+	values := make([]goja.Value, len(arguments))
+	for i, argument := range arguments {
+		values[i] = game.vm.ToValue(argument)
+	}
+
+	result, err := game.callScriptFunction(ScriptHookCombat, "combat "+step, hook.callback, game.vm.ToValue(game), values...)
+	if err != nil {
+		logScriptHandlerError(fmt.Sprintf("combat %q", step), err)
+		return nil
+	}
+
+	if result == nil || goja.IsUndefined(result) || goja.IsNull(result) {
+		return nil
+	}
+
+	return result
+}
+
+func (game *Game) combatHookInt(step string, value int, arguments ...interface{}) int {
+	result := game.callCombatHook(step, append(arguments, value)...)
+	if result == nil {
+		return value
+	}
+
+	return int(result.ToInteger())
+}
+
+/* Combat rolls come from combatRandom when one is set, so that rounds can be replayed from a seed */
+func (game *Game) combatIntn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	if game.combatRandom != nil {
+		return game.combatRandom.Intn(n)
+	}
+
+	return rand.Intn(n)
+}
+
+func (game *Game) SetCombatSeed(seed int64) {
+	game.combatRandom = rand.New(rand.NewSource(seed))
+}
+
+/* A d20 plus a bonus for every two points of dexterity over ten */
+func (game *Game) combatInitiative(ch *Character) int {
+	dexterity, _ := ch.GetStat(STAT_DEXTERITY)
+	roll := 1 + game.combatIntn(20) + (dexterity-10)/2
+
+	return game.combatHookInt(CombatStepInitiative, roll, ch)
+}
+
+/* Participants still in the world, in the order they act this round */
+func (game *Game) combatInitiativeOrder(combat *Combat) []*Character {
+	order := make([]*Character, 0, len(combat.Participants))
+	rolls := make(map[*Character]int)
+
+	for _, vch := range combat.Participants {
+		if vch == nil || vch.Room == nil {
+			continue
+		}
+
+		if _, ok := rolls[vch]; ok {
+			continue
+		}
+
+		rolls[vch] = game.combatInitiative(vch)
+		order = append(order, vch)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return rolls[order[i]] > rolls[order[j]]
+	})
+
+	return order
+}
+
+/* One attack, plus one for every four points of dexterity over ten, adjusted by haste and slow */
+func (game *Game) combatAttackCount(ch *Character) int {
+	dexterity, _ := ch.GetStat(STAT_DEXTERITY)
+	count := 1 + (dexterity-10)/4
+
+	if ch.Affected&AFFECT_HASTE != 0 {
+		count++
+	}
+
+	if ch.Affected&AFFECT_SLOW != 0 {
+		count--
+	}
+
+	count = max(1, count)
+
+	return max(0, game.combatHookInt(CombatStepAttacks, count, ch))
+}
+
+/*
+ * An attacker lands most blows on an equal, gaining or losing a point for each
+ * level of difference and losing the victim's defense rating.  Fighting blind
+ * or in the dark cuts what is left to a quarter.
+ */
+func (game *Game) combatHitChance(attack *CombatAttack) int {
+	ch, victim := attack.Attacker, attack.Victim
+
+	chance := 100 + int(ch.Level) - int(victim.Level) - victim.Defense
+	chance = min(100, max(5, chance))
+
+	if ch.Affected&AFFECT_BLINDNESS != 0 || (ch.Room != nil && !ch.Room.ActiveLightSourcePresent()) {
+		chance /= 4
+	}
+
+	return game.combatHookInt(CombatStepToHit, chance, attack)
+}
+
+/* Each defensive proficiency gives its holder a fifth of its percentage to avoid an attack */
+func (game *Game) combatDefense(attack *CombatAttack) string {
+	victim := attack.Victim
+	outcome := CombatDefenseNone
+
+	candidates := []string{CombatDefenseParry, CombatDefenseDodge, CombatDefenseAcrobatics}
+	for _, candidate := range candidates {
+		if candidate == CombatDefenseParry && victim.GetEquipment(WearLocationWielded) == nil {
+			continue
+		}
+
+		proficiency := victim.FindProficiencyByName(candidate)
+		if proficiency == nil {
+			continue
+		}
+
+		if game.combatIntn(500) < proficiency.Proficiency {
+			outcome = candidate
+			break
+		}
+	}
+
+	result := game.callCombatHook(CombatStepDefense, attack, outcome)
+	if result == nil {
+		return outcome
+	}
+
+	return result.String()
+}
+
+/*
+ * Weapons roll Value0 dice of Value1 sides, add Value2 and deal damage of type
+ * Value3.  Bare hands deal up to a third of strength in bash damage, with a
+ * point for every ten percent of unarmed combat proficiency.
+ */
+func (game *Game) combatDamage(attack *CombatAttack) int {
+	ch := attack.Attacker
+	amount := 0
+
+	if attack.Weapon != nil {
+		for i := 0; i < attack.Weapon.Value0; i++ {
+			amount += 1 + game.combatIntn(attack.Weapon.Value1)
+		}
+
+		amount += attack.Weapon.Value2
+	} else {
+		strength, _ := ch.GetStat(STAT_STRENGTH)
+		amount = game.combatIntn(2) + game.combatIntn(strength/3)
+
+		proficiency := ch.FindProficiencyByName("unarmed combat")
+		if proficiency != nil {
+			amount += proficiency.Proficiency / 10
+		}
+	}
+
+	return max(0, game.combatHookInt(CombatStepDamage, amount, attack))
+}
+
+func (game *Game) NewCombatAttack(ch *Character, victim *Character, number int) *CombatAttack {
+	attack := &CombatAttack{
+		Attacker:   ch,
+		Victim:     victim,
+		Weapon:     ch.GetEquipment(WearLocationWielded),
+		Number:     number,
+		DamageType: DamageTypeBash,
+	}
+
+	if attack.Weapon != nil {
+		attack.DamageType = attack.Weapon.Value3
+	}
+
+	return attack
+}
+
+func combatAvoidedMessages(attack *CombatAttack, outcome string) {
+	ch, victim := attack.Attacker, attack.Victim
+
+	switch outcome {
+	case CombatDefenseParry:
+		ch.Send(fmt.Sprintf("{D%s{D parries your attack!{x\r\n", victim.GetShortDescriptionUpper(ch)))
+		victim.Send(fmt.Sprintf("{DYou parry an attack by %s{D!{x\r\n", ch.GetShortDescription(victim)))
+
+	case CombatDefenseDodge:
+		ch.Send(fmt.Sprintf("{D%s{D dodges out of the way of your attack!{x\r\n", victim.GetShortDescriptionUpper(ch)))
+		victim.Send(fmt.Sprintf("{DYou dodge an attack by %s{D!{x\r\n", ch.GetShortDescription(victim)))
+
+	case CombatDefenseAcrobatics:
+		ch.Send(fmt.Sprintf("{D%s{D nimbly backflips out of the way of your attack!{x\r\n", victim.GetShortDescriptionUpper(ch)))
+		victim.Send(fmt.Sprintf("{DYou nimbly backflip out of the way of %s{D's attack!{x\r\n", ch.GetShortDescription(victim)))
+
+	default:
+		ch.Send(fmt.Sprintf("{D%s{D avoids your attack!{x\r\n", victim.GetShortDescriptionUpper(ch)))
+		victim.Send(fmt.Sprintf("{DYou avoid an attack by %s{D!{x\r\n", ch.GetShortDescription(victim)))
+	}
+}
+
+/* Roll one attack through to damage, returning whether it landed */
+func (game *Game) combatAttack(attack *CombatAttack) bool {
+	ch, victim := attack.Attacker, attack.Victim
+
+	if game.combatIntn(100) >= game.combatHitChance(attack) {
+		game.logCombatMiss(attack, "miss")
+		ch.Send(fmt.Sprintf("{DYou miss %s{D.{x\r\n", victim.GetShortDescription(ch)))
+		victim.Send(fmt.Sprintf("{D%s {Dmisses while trying to attack you!{x\r\n", ch.GetShortDescriptionUpper(victim)))
+		return false
+	}
+
+	outcome := game.combatDefense(attack)
+	if outcome != CombatDefenseNone {
+		game.logCombatMiss(attack, outcome)
+		combatAvoidedMessages(attack, outcome)
+		return false
+	}
+
+	amount := game.combatDamage(attack)
+	game.Damage(ch, victim, true, amount, attack.DamageType)
+	return true
+}
+
+/* A victim who is not yet fighting strikes back, and its allies in the room may come to its defense */
+func (game *Game) combatEngage(ch *Character, victim *Character) {
+	combat := victim.Combat
+	if combat == nil {
+		combat = ch.Combat
+	}
+
+	if victim.Fighting == nil || victim.Combat == nil {
+		victim.Fighting = ch
+		game.AddCombatParticipant(combat, victim)
+	}
+
+	if combat != nil {
+		game.autoAssist(combat)
+	}
+}
+
+func (game *Game) combatFireshield(ch *Character, victim *Character) {
+	if victim.Combat == nil || victim.Affected&AFFECT_FIRESHIELD == 0 {
+		return
+	}
+
+	ch.Send(fmt.Sprintf("{ROuch!  You are burned by %s{R's reactive fireshield!{x\r\n", victim.GetShortDescription(ch)))
+	victim.Send(fmt.Sprintf("{RYour reactive fireshield lights up and burns %s{R!{x\r\n", ch.GetShortDescription(victim)))
+
+	for rch := range ch.Room.Characters.All() {
+		if rch != ch && rch != victim {
+			rch.Send(fmt.Sprintf("{R%s{R is burned by the reactive fireshield protecting %s{R!{x\r\n", ch.GetShortDescriptionUpper(rch), victim.GetShortDescription(rch)))
+		}
+	}
+
+	game.Damage(victim, ch, false, game.combatIntn(int(victim.Level)), DamageTypeFire)
+}
+
+/* Make ch's attacks for the round, returning whether ch had anyone to fight */
+func (game *Game) combatTurn(ch *Character) bool {
+	found := false
+
+	game.combatSelectTarget(ch)
+	attacks := game.combatAttackCount(ch)
+
+	for number := 1; number <= attacks; number++ {
+		victim := ch.Fighting
+		if victim == nil || victim.Room == nil || ch.Room == nil || victim.Room != ch.Room {
+			break
+		}
+
+		if victim.Room.Flags&ROOM_SAFE != 0 {
+			break
+		}
+
+		found = true
+
+		if !game.combatAttack(game.NewCombatAttack(ch, victim, number)) {
+			continue
+		}
+
+		if victim.Room == nil || ch.Room == nil || victim.Room != ch.Room {
+			break
+		}
+
+		if game.checkWimpy(victim) {
+			break
+		}
+
+		game.combatEngage(ch, victim)
+		game.combatFireshield(ch, victim)
+	}
+
+	return found
+}
+
+/* Fight one round of every combat, disposing of those in which nobody could attack */
+func (game *Game) combatRound() {
+	for _, combat := range game.Fights.Values() {
+		found := false
+
+		combat.combatLog().Rounds++
+
+		game.autoAssist(combat)
+
+		for _, vch := range game.combatInitiativeOrder(combat) {
+			if vch.Room == nil {
+				continue
+			}
+
+			if game.combatTurn(vch) {
+				found = true
+			}
+		}
+
+		if !found {
+			game.DisposeCombat(combat)
+		}
+	}
+}
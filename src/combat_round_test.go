@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+func newCombatTestCharacter(game *Game, level uint) *Character {
+	ch := NewCharacter()
+	ch.Game = game
+	ch.Level = level
+	ch.Health = 1000
+	ch.MaxHealth = 1000
+
+	for stat := range ch.Stats {
+		ch.Stats[stat] = 10
+	}
+
+	return ch
+}
+
+/* Two characters already fighting each other in a lit room */
+func newCombatTestFight(game *Game) (*Character, *Character) {
+	game.Fights = NewLinkedList[*Combat]()
+
+	room := game.NewRoom()
+	room.Characters = NewLinkedList[*Character]()
+	room.Objects = NewLinkedList[*ObjectInstance]()
+
+	ch := newCombatTestCharacter(game, 10)
+	victim := newCombatTestCharacter(game, 10)
+	room.AddCharacter(ch)
+	room.AddCharacter(victim)
+
+	ch.Fighting = victim
+	victim.Fighting = ch
+	game.combatForAttack(ch, victim)
+
+	return ch, victim
+}
+
+func TestCombatAttackCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		dexterity int
+		affected  int
+		want      int
+	}{
+		{"average", 10, 0, 1},
+		{"clumsy still attacks", 3, 0, 1},
+		{"nimble", 18, 0, 3},
+		{"hasted", 14, AFFECT_HASTE, 3},
+		{"slowed", 14, AFFECT_SLOW, 1},
+	}
+
+	game := &Game{}
+
+	for _, test := range tests {
+		ch := newCombatTestCharacter(game, 1)
+		ch.Stats[STAT_DEXTERITY] = test.dexterity
+		ch.Affected = test.affected
+
+		if got := game.combatAttackCount(ch); got != test.want {
+			t.Errorf("%s: %d attacks, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCombatHitChance(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    uint
+		victim   uint
+		defense  int
+		affected int
+		want     int
+	}{
+		{"equals", 10, 10, 0, 0, 100},
+		{"outmatched", 10, 30, 0, 0, 80},
+		{"defense", 10, 10, 25, 0, 75},
+		{"never below five percent", 1, 50, 100, 0, 5},
+		{"blind", 10, 30, 0, AFFECT_BLINDNESS, 20},
+	}
+
+	game := &Game{}
+
+	for _, test := range tests {
+		ch := newCombatTestCharacter(game, test.level)
+		ch.Affected = test.affected
+
+		victim := newCombatTestCharacter(game, test.victim)
+		victim.Defense = test.defense
+
+		if got := game.combatHitChance(game.NewCombatAttack(ch, victim, 1)); got != test.want {
+			t.Errorf("%s: %d%% to hit, want %d%%", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCombatWeaponDamageRollsDice(t *testing.T) {
+	game := &Game{}
+	game.SetCombatSeed(1)
+
+	ch := newCombatTestCharacter(game, 10)
+	weapon := &ObjectInstance{ItemType: "weapon", WearLocation: WearLocationWielded, Value0: 2, Value1: 6, Value2: 3, Value3: DamageTypeSlash}
+	ch.Inventory.Insert(weapon)
+
+	attack := game.NewCombatAttack(ch, newCombatTestCharacter(game, 10), 1)
+	if attack.Weapon != weapon || attack.DamageType != DamageTypeSlash {
+		t.Fatalf("attack with %v of type %d, want the wielded weapon's slash", attack.Weapon, attack.DamageType)
+	}
+
+	seen := make(map[int]bool)
+	for i := 0; i < 500; i++ {
+		amount := game.combatDamage(attack)
+		if amount < 5 || amount > 15 {
+			t.Fatalf("2d6+3 rolled %d", amount)
+		}
+
+		seen[amount] = true
+	}
+
+	if len(seen) != 11 {
+		t.Errorf("2d6+3 rolled %d distinct totals in 500 tries, want 11", len(seen))
+	}
+}
+
+func TestCombatRoundIsReproducibleFromSeed(t *testing.T) {
+	fight := func(seed int64) (int, int) {
+		game := &Game{}
+		game.SetCombatSeed(seed)
+
+		ch, victim := newCombatTestFight(game)
+		ch.Skills[1] = &Proficiency{SkillId: 1, Proficiency: 100}
+		game.skills = map[uint]*Skill{1: {Id: 1, Name: "dodge"}}
+
+		for round := 0; round < 10; round++ {
+			game.combatRound()
+		}
+
+		return ch.Health, victim.Health
+	}
+
+	first, second := fight(42)
+	if first == 1000 || second == 1000 {
+		t.Fatalf("ten rounds left health at %d and %d", first, second)
+	}
+
+	for i := 0; i < 3; i++ {
+		if again, againSecond := fight(42); again != first || againSecond != second {
+			t.Fatalf("seed 42 gave %d/%d, then %d/%d", first, second, again, againSecond)
+		}
+	}
+}
+
+func TestCombatHooksOverrideSteps(t *testing.T) {
+	game := &Game{vm: goja.New(), eventHandlers: make(map[string]*LinkedList[*EventHandler])}
+	game.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	game.installScriptGlobals()
+	game.SetCombatSeed(1)
+
+	ch, victim := newCombatTestFight(game)
+
+	/* Both fighters make every attack the hooks allow, so each round costs the victim twice the damage */
+	steps := []struct {
+		setup  func()
+		source string
+		want   int
+	}{
+		{nil, `Golem.registerCombatHook('attacks', (ch, count) => 2); Golem.registerCombatHook('damage', (attack, amount) => 7);`, 986},
+		{nil, `Golem.registerCombatHook('toHit', (attack, chance) => 0);`, 986},
+		{nil, `Golem.registerCombatHook('toHit', (attack, chance) => undefined); Golem.registerCombatHook('defense', () => 'dodge');`, 986},
+		{func() { victim.Immune = IMMUNE_BASH }, `Golem.registerCombatHook('defense', (attack, outcome) => outcome);
+			Golem.registerCombatHook('damage', (attack, amount) => { attack.damageType = Golem.Combat.DamageTypeTrue; return 5; });`, 976},
+	}
+
+	for _, step := range steps {
+		if step.setup != nil {
+			step.setup()
+		}
+
+		if _, err := game.vm.RunString(step.source); err != nil {
+			t.Fatal(err)
+		}
+
+		game.combatRound()
+
+		if victim.Health != step.want {
+			t.Fatalf("after %s the victim has %d health, want %d", step.source, victim.Health, step.want)
+		}
+	}
+
+	if ch.Health != victim.Health {
+		t.Errorf("the attacker has %d health and the victim %d, want the victim to have struck back alike", ch.Health, victim.Health)
+	}
+
+	if _, err := game.vm.RunString(`Golem.registerCombatHook('parley', () => 0)`); err == nil {
+		t.Error("a hook for an unknown step was registered")
+	}
+}
@@ -33,12 +33,50 @@ type AppWebConfiguration struct {
 	PublicRoot string `json:"publicRoot"`
 }
 
+type AppBackupConfiguration struct {
+	Directory       string `json:"directory"`
+	IntervalMinutes int    `json:"intervalMinutes"`
+	Retention       int    `json:"retention"`
+}
+
+type AppScriptingConfiguration struct {
+	DefaultBudgetMilliseconds int            `json:"defaultBudgetMilliseconds"`
+	BudgetMilliseconds        map[string]int `json:"budgetMilliseconds"`
+	MaxCallStackSize          int            `json:"maxCallStackSize"`
+	MaxAllocationMegabytes    int            `json:"maxAllocationMegabytes"`
+	MaxViolations             int            `json:"maxViolations"`
+}
+
+/* Who may loot a player's corpse ("owner", "group" or "anyone"), how long it lasts and what a healer charges to fetch it */
+type AppCorpseConfiguration struct {
+	Looting               string `json:"looting"`
+	DecayMinutes          int    `json:"decayMinutes"`
+	RetrievalCostPerLevel int    `json:"retrievalCostPerLevel"`
+}
+
+/* How many finished fights to keep in memory, whether to save each to the combat_logs table, and where exports are written */
+type AppCombatLogConfiguration struct {
+	Capacity  int    `json:"capacity"`
+	Persist   bool   `json:"persist"`
+	Directory string `json:"directory"`
+}
+
+/* Conveniences for working on the game itself; not for production */
+type AppDevelopmentConfiguration struct {
+	Enabled bool `json:"enabled"`
+}
+
 type AppConfiguration struct {
-	HashSalt               string                    `json:"hashSalt"`
-	Port                   int                       `json:"port"`
-	DatabaseConfiguration  AppDatabaseConfiguration  `json:"database"`
-	ProfilingConfiguration AppProfilingConfiguration `json:"profiling"`
-	WebConfiguration       AppWebConfiguration       `json:"web"`
+	HashSalt                 string                      `json:"hashSalt"`
+	Port                     int                         `json:"port"`
+	DatabaseConfiguration    AppDatabaseConfiguration    `json:"database"`
+	ProfilingConfiguration   AppProfilingConfiguration   `json:"profiling"`
+	WebConfiguration         AppWebConfiguration         `json:"web"`
+	BackupConfiguration      AppBackupConfiguration      `json:"backup"`
+	ScriptingConfiguration   AppScriptingConfiguration   `json:"scripting"`
+	CorpseConfiguration      AppCorpseConfiguration      `json:"corpses"`
+	CombatLogConfiguration   AppCombatLogConfiguration   `json:"combatLog"`
+	DevelopmentConfiguration AppDevelopmentConfiguration `json:"development"`
 
 	greeting []byte
 	motd     []byte
@@ -49,8 +87,12 @@ type AppConfiguration struct {
 func init() {
 	/* Defaults */
 	Config = &AppConfiguration{
-		Port:                  4000,
-		DatabaseConfiguration: defaultDatabaseConfiguration(),
+		Port:                   4000,
+		DatabaseConfiguration:  defaultDatabaseConfiguration(),
+		BackupConfiguration:    defaultBackupConfiguration(),
+		ScriptingConfiguration: defaultScriptingConfiguration(),
+		CorpseConfiguration:    defaultCorpseConfiguration(),
+		CombatLogConfiguration: defaultCombatLogConfiguration(),
 	}
 
 	/* Attempt read of config JSON file */
@@ -58,6 +100,10 @@ func init() {
 	if err != nil {
 		log.Printf("Warning: failed to read local config file: %v.\r\n", err)
 		Config.normalizeDatabaseConfiguration()
+		Config.normalizeBackupConfiguration()
+		Config.normalizeScriptingConfiguration()
+		Config.normalizeCorpseConfiguration()
+		Config.normalizeCombatLogConfiguration()
 	} else {
 		err = json.Unmarshal(configBytes, Config)
 		if err != nil {
@@ -65,6 +111,10 @@ func init() {
 		}
 
 		Config.normalizeDatabaseConfiguration()
+		Config.normalizeBackupConfiguration()
+		Config.normalizeScriptingConfiguration()
+		Config.normalizeCorpseConfiguration()
+		Config.normalizeCombatLogConfiguration()
 	}
 
 	/* Read greeting */
@@ -105,3 +155,92 @@ func (config *AppConfiguration) normalizeDatabaseConfiguration() {
 		config.DatabaseConfiguration.Path = defaultDatabasePath
 	}
 }
+
+func defaultBackupConfiguration() AppBackupConfiguration {
+	return AppBackupConfiguration{
+		Directory: defaultBackupDirectory,
+		Retention: defaultBackupRetention,
+	}
+}
+
+func (config *AppConfiguration) normalizeBackupConfiguration() {
+	if config.BackupConfiguration.Directory == "" {
+		config.BackupConfiguration.Directory = defaultBackupDirectory
+	}
+
+	if config.BackupConfiguration.IntervalMinutes < 0 {
+		config.BackupConfiguration.IntervalMinutes = 0
+	}
+}
+
+func defaultScriptingConfiguration() AppScriptingConfiguration {
+	return AppScriptingConfiguration{
+		DefaultBudgetMilliseconds: defaultScriptBudgetMilliseconds,
+		MaxCallStackSize:          defaultScriptMaxCallStackSize,
+		MaxAllocationMegabytes:    defaultScriptMaxAllocationMegabyte,
+		MaxViolations:             defaultScriptMaxViolations,
+	}
+}
+
+/*
+ * Non-positive limits fall back to the defaults rather than disabling the
+ * guard entirely, except for the allocation ceiling, which 0 turns off.
+ */
+func (config *AppConfiguration) normalizeScriptingConfiguration() {
+	if config.ScriptingConfiguration.DefaultBudgetMilliseconds <= 0 {
+		config.ScriptingConfiguration.DefaultBudgetMilliseconds = defaultScriptBudgetMilliseconds
+	}
+
+	if config.ScriptingConfiguration.MaxCallStackSize <= 0 {
+		config.ScriptingConfiguration.MaxCallStackSize = defaultScriptMaxCallStackSize
+	}
+
+	if config.ScriptingConfiguration.MaxAllocationMegabytes < 0 {
+		config.ScriptingConfiguration.MaxAllocationMegabytes = defaultScriptMaxAllocationMegabyte
+	}
+
+	if config.ScriptingConfiguration.MaxViolations <= 0 {
+		config.ScriptingConfiguration.MaxViolations = defaultScriptMaxViolations
+	}
+}
+
+func defaultCorpseConfiguration() AppCorpseConfiguration {
+	return AppCorpseConfiguration{
+		Looting:               CorpseLootingGroup,
+		DecayMinutes:          defaultPlayerCorpseDecayMinutes,
+		RetrievalCostPerLevel: defaultCorpseRetrievalCostPerLevel,
+	}
+}
+
+func (config *AppConfiguration) normalizeCorpseConfiguration() {
+	switch config.CorpseConfiguration.Looting {
+	case CorpseLootingOwner, CorpseLootingGroup, CorpseLootingAnyone:
+	default:
+		config.CorpseConfiguration.Looting = CorpseLootingGroup
+	}
+
+	if config.CorpseConfiguration.DecayMinutes <= 0 {
+		config.CorpseConfiguration.DecayMinutes = defaultPlayerCorpseDecayMinutes
+	}
+
+	if config.CorpseConfiguration.RetrievalCostPerLevel < 0 {
+		config.CorpseConfiguration.RetrievalCostPerLevel = 0
+	}
+}
+
+func defaultCombatLogConfiguration() AppCombatLogConfiguration {
+	return AppCombatLogConfiguration{
+		Capacity:  defaultCombatLogCapacity,
+		Directory: defaultCombatLogDirectory,
+	}
+}
+
+func (config *AppConfiguration) normalizeCombatLogConfiguration() {
+	if config.CombatLogConfiguration.Capacity <= 0 {
+		config.CombatLogConfiguration.Capacity = defaultCombatLogCapacity
+	}
+
+	if config.CombatLogConfiguration.Directory == "" {
+		config.CombatLogConfiguration.Directory = defaultCombatLogDirectory
+	}
+}
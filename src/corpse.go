@@ -0,0 +1,480 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+const (
+	CorpseLootingOwner  = "owner"
+	CorpseLootingGroup  = "group"
+	CorpseLootingAnyone = "anyone"
+
+	defaultPlayerCorpseDecayMinutes    = 120
+	defaultCorpseRetrievalCostPerLevel = 20
+)
+
+/* Marks a corpse as a fallen player's, kept in the database until it decays */
+type PlayerCorpse struct {
+	Id        int    `json:"id"`
+	OwnerId   int    `json:"ownerId"`
+	OwnerName string `json:"ownerName"`
+}
+
+/* The corpse's owner, if they are in the world */
+func (game *Game) playerCorpseOwner(corpse *PlayerCorpse) *Character {
+	for ch := range game.Characters.All() {
+		if ch.Flags&CHAR_IS_PLAYER != 0 && ch.Id == corpse.OwnerId {
+			return ch
+		}
+	}
+
+	return nil
+}
+
+/* Whether ch may take from container; player corpses are for their owner and, by default, the owner's group */
+func (ch *Character) MayLoot(container *ObjectInstance) bool {
+	if container == nil || container.Corpse == nil || ch.Level > LevelHero {
+		return true
+	}
+
+	if ch.Flags&CHAR_IS_PLAYER != 0 && ch.Id == container.Corpse.OwnerId {
+		return true
+	}
+
+	switch Config.CorpseConfiguration.Looting {
+	case CorpseLootingAnyone:
+		return true
+
+	case CorpseLootingGroup:
+		owner := ch.Game.playerCorpseOwner(container.Corpse)
+		return owner != nil && owner.InSameGroup(ch)
+	}
+
+	return false
+}
+
+/*
+ * Hand a fallen player's belongings from their inventory over to their new
+ * corpse in the database, so that neither reboots nor the player logging out
+ * lose them before the corpse decays.
+ */
+func (game *Game) persistPlayerCorpse(corpse *ObjectInstance, owner *Character, room *Room) error {
+	if game.db == nil || owner.Id <= 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	tx, err := game.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	reified, err := corpse.reifyTx(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	err = deleteObjectOwnersTx(ctx, tx, corpse.objectInstanceIDs())
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	for obj := range corpse.Contents.All() {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE
+				object_instances
+			SET
+				inside_object_instance_id = ?,
+				wear_location = -1
+			WHERE
+				id = ?
+		`, corpse.Id, obj.Id)
+		if err != nil {
+			tx.Rollback()
+			resetReifiedObjectIDs(reified)
+			return err
+		}
+	}
+
+	location := roomLocation(room)
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			player_corpses(object_instance_id, player_character_id, room_id, plane_id, plane_x, plane_y, plane_z)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?)
+	`, corpse.Id, owner.Id, location.RoomId, location.PlaneId, location.PlaneX, location.PlaneY, location.PlaneZ)
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	corpse.Corpse.Id = int(id)
+	return nil
+}
+
+/*
+ * Give a fallen player back everything their corpse took when it could not be
+ * saved.  The database still has the items on the player, so leaving them in
+ * the corpse as well would let them be looted and then restored on next login.
+ */
+func (game *Game) restoreCorpseContents(corpse *ObjectInstance, ch *Character, gold *ObjectInstance, wearLocations map[*ObjectInstance]int) {
+	contents := make([]*ObjectInstance, 0, corpse.Contents.Count)
+	for obj := range corpse.Contents.All() {
+		contents = append(contents, obj)
+	}
+
+	for i := len(contents) - 1; i >= 0; i-- {
+		obj := contents[i]
+		corpse.removeObject(obj)
+
+		if obj == gold {
+			ch.Gold += gold.Value0
+			game.Objects.Remove(gold)
+			continue
+		}
+
+		obj.WearLocation = wearLocations[obj]
+		ch.addObject(obj, true)
+		game.AuditObject(obj, ObjectAuditTaken, auditContainer(corpse), auditCharacter(ch))
+	}
+
+	corpse.Corpse = nil
+}
+
+func (game *Game) savePlayerCorpseLocation(corpse *ObjectInstance) error {
+	if game.db == nil || corpse.Corpse == nil || corpse.Corpse.Id <= 0 {
+		return nil
+	}
+
+	location := roomLocation(corpse.InRoom)
+	_, err := game.db.Exec(`
+		UPDATE
+			player_corpses
+		SET
+			room_id = ?,
+			plane_id = ?,
+			plane_x = ?,
+			plane_y = ?,
+			plane_z = ?
+		WHERE
+			id = ?
+	`, location.RoomId, location.PlaneId, location.PlaneX, location.PlaneY, location.PlaneZ, corpse.Corpse.Id)
+	return err
+}
+
+/* Put every player corpse that had not decayed back where it lay */
+func (game *Game) LoadPlayerCorpses() error {
+	rows, err := game.db.Query(`
+		SELECT
+			player_corpses.id,
+			player_corpses.player_character_id,
+			player_characters.username,
+			player_corpses.room_id,
+			player_corpses.plane_id,
+			player_corpses.plane_x,
+			player_corpses.plane_y,
+			player_corpses.plane_z,
+			object_instances.id,
+			object_instances.parent_id,
+			object_instances.serial,
+			object_instances.name,
+			object_instances.short_description,
+			object_instances.long_description,
+			object_instances.description,
+			object_instances.flags,
+			object_instances.item_type,
+			object_instances.value_1,
+			object_instances.value_2,
+			object_instances.value_3,
+			object_instances.value_4,
+			object_instances.weight,
+			object_instances.ttl,
+			CAST(strftime('%s', object_instances.created_at) AS INTEGER)
+		FROM
+			player_corpses
+		INNER JOIN
+			object_instances ON object_instances.id = player_corpses.object_instance_id
+		INNER JOIN
+			player_characters ON player_characters.id = player_corpses.player_character_id
+	`)
+	if err != nil {
+		return err
+	}
+
+	type loadedCorpse struct {
+		obj      *ObjectInstance
+		location playerCharacterLocation
+	}
+
+	loaded := make([]loadedCorpse, 0)
+
+	for rows.Next() {
+		corpse := loadedCorpse{
+			obj: &ObjectInstance{
+				Game:         game,
+				Contents:     NewLinkedList[*ObjectInstance](),
+				WearLocation: -1,
+				Corpse:       &PlayerCorpse{},
+			},
+		}
+
+		var serial sql.NullString
+		var createdAt sql.NullInt64
+		obj := corpse.obj
+
+		err = rows.Scan(&obj.Corpse.Id, &obj.Corpse.OwnerId, &obj.Corpse.OwnerName, &corpse.location.RoomId, &corpse.location.PlaneId, &corpse.location.PlaneX, &corpse.location.PlaneY, &corpse.location.PlaneZ,
+			&obj.Id, &obj.ParentId, &serial, &obj.Name, &obj.ShortDescription, &obj.LongDescription, &obj.Description, &obj.Flags, &obj.ItemType, &obj.Value0, &obj.Value1, &obj.Value2, &obj.Value3, &obj.Weight, &obj.Ttl, &createdAt)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		obj.Serial = serial.String
+		obj.CreatedAt = objectCreatedAtFromUnix(createdAt)
+		obj.Ttl = normalizeObjectTtl(obj.Flags, obj.Ttl)
+		loaded = append(loaded, corpse)
+	}
+
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, corpse := range loaded {
+		err = game.loadContainedObjects(corpse.obj, true)
+		if err != nil {
+			return err
+		}
+
+		room, err := game.loadPlayerRoom(corpse.location)
+		if err != nil {
+			log.Printf("Leaving the corpse of %s in limbo: %v.\r\n", corpse.obj.Corpse.OwnerName, err)
+
+			room, err = game.LoadRoomIndex(RoomLimbo)
+			if err != nil {
+				return err
+			}
+		}
+
+		room.AddObject(corpse.obj)
+		game.insertObjectTree(corpse.obj)
+	}
+
+	log.Printf("Loaded %d player corpses.\r\n", len(loaded))
+	return nil
+}
+
+/* Read the objects persisted inside container, and theirs in turn if recursive */
+func (game *Game) loadContainedObjects(container *ObjectInstance, recursive bool) error {
+	rows, err := game.db.Query(`
+		SELECT
+			object_instances.id,
+			object_instances.parent_id,
+			object_instances.serial,
+			object_instances.name,
+			object_instances.short_description,
+			object_instances.long_description,
+			object_instances.description,
+			object_instances.flags,
+			object_instances.item_type,
+			object_instances.value_1,
+			object_instances.value_2,
+			object_instances.value_3,
+			object_instances.value_4,
+			object_instances.weight,
+			object_instances.ttl,
+			CAST(strftime('%s', object_instances.created_at) AS INTEGER)
+		FROM
+			object_instances
+		WHERE
+			object_instances.inside_object_instance_id = ?
+	`, container.Id)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	contained := make([]*ObjectInstance, 0)
+
+	for rows.Next() {
+		containedObj := &ObjectInstance{
+			Game:         game,
+			Contents:     NewLinkedList[*ObjectInstance](),
+			Inside:       nil,
+			CarriedBy:    nil,
+			WearLocation: -1,
+		}
+
+		var serial sql.NullString
+		var createdAt sql.NullInt64
+		err = rows.Scan(&containedObj.Id, &containedObj.ParentId, &serial, &containedObj.Name, &containedObj.ShortDescription, &containedObj.LongDescription, &containedObj.Description, &containedObj.Flags, &containedObj.ItemType, &containedObj.Value0, &containedObj.Value1, &containedObj.Value2, &containedObj.Value3, &containedObj.Weight, &containedObj.Ttl, &createdAt)
+		if err != nil {
+			return err
+		}
+
+		containedObj.Serial = serial.String
+		containedObj.CreatedAt = objectCreatedAtFromUnix(createdAt)
+		containedObj.Ttl = normalizeObjectTtl(containedObj.Flags, containedObj.Ttl)
+		container.AddObject(containedObj)
+		contained = append(contained, containedObj)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	/* Finish reading before descending, as the pool may hold a single connection */
+	rows.Close()
+
+	if !recursive {
+		return nil
+	}
+
+	for _, obj := range contained {
+		if obj.ItemType != ItemTypeContainer {
+			continue
+		}
+
+		err = game.loadContainedObjects(obj, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (game *Game) insertObjectTree(obj *ObjectInstance) {
+	game.Objects.Insert(obj)
+
+	if obj.Contents != nil {
+		for containedObj := range obj.Contents.All() {
+			game.insertObjectTree(containedObj)
+		}
+	}
+}
+
+func (game *Game) findPlayerCorpses(ch *Character) []*ObjectInstance {
+	corpses := make([]*ObjectInstance, 0)
+
+	for obj := range game.Objects.All() {
+		if obj.Corpse != nil && obj.Corpse.OwnerId == ch.Id && obj.InRoom != nil {
+			corpses = append(corpses, obj)
+		}
+	}
+
+	return corpses
+}
+
+/* For a fee, a healer in the room summons ch's corpses to it; coins on the corpses make up any shortfall */
+func do_retrieve(ch *Character, arguments string) {
+	if ch.Room == nil || ch.Flags&CHAR_IS_PLAYER == 0 {
+		return
+	}
+
+	var healer *Character
+	for rch := range ch.Room.Characters.All() {
+		if rch.Flags&CHAR_HEALER != 0 && rch.Flags&CHAR_IS_PLAYER == 0 {
+			healer = rch
+			break
+		}
+	}
+
+	if healer == nil {
+		ch.Send("There is nobody here who can retrieve your corpse.\r\n")
+		return
+	}
+
+	corpses := make([]*ObjectInstance, 0)
+	for _, corpse := range ch.Game.findPlayerCorpses(ch) {
+		if corpse.InRoom != ch.Room {
+			corpses = append(corpses, corpse)
+		}
+	}
+
+	if len(corpses) == 0 {
+		ch.Send(fmt.Sprintf("%s{x tells you, 'I can find no corpse of yours that needs retrieving.'\r\n", healer.GetShortDescriptionUpper(ch)))
+		return
+	}
+
+	cost := int(ch.Level) * Config.CorpseConfiguration.RetrievalCostPerLevel
+
+	coins := make([]*ObjectInstance, 0)
+	available := ch.Gold
+	for _, corpse := range corpses {
+		for obj := range corpse.Contents.All() {
+			if obj.ItemType == ItemTypeCurrency {
+				coins = append(coins, obj)
+				available += obj.Value0
+			}
+		}
+	}
+
+	if available < cost {
+		ch.Send(fmt.Sprintf("%s{x tells you, 'My services cost %d gold coins, which you do not have.'\r\n", healer.GetShortDescriptionUpper(ch), cost))
+		return
+	}
+
+	if ch.Gold < cost {
+		/* Settle up with the coins on the corpses, handing back the change */
+		for _, obj := range coins {
+			obj.Inside.removeObject(obj)
+			ch.Game.Objects.Remove(obj)
+
+			if err := ch.Game.deletePersistedObjectInstance(obj); err != nil {
+				log.Printf("Warning: failed to delete coins taken from a corpse: %v\r\n", err)
+			}
+		}
+
+		ch.Gold = available
+		ch.Send(fmt.Sprintf("%s{x takes the coins from your corpse to cover the fee.\r\n", healer.GetShortDescriptionUpper(ch)))
+	}
+
+	ch.Gold -= cost
+
+	for _, corpse := range corpses {
+		corpse.removeFromLocation()
+		ch.Room.AddObject(corpse)
+
+		if err := ch.Game.savePlayerCorpseLocation(corpse); err != nil {
+			log.Printf("Warning: failed to save the location of %s's corpse: %v\r\n", ch.Name, err)
+		}
+	}
+
+	ch.Send(fmt.Sprintf("{WYou pay %s{W %d gold coins.  After a murmured prayer, your corpse appears at your feet.{x\r\n", healer.GetShortDescription(ch), cost))
+	for rch := range ch.Room.Characters.All() {
+		if rch != ch {
+			rch.Send(fmt.Sprintf("{W%s{W murmurs a prayer, and the corpse of %s{W appears.{x\r\n", healer.GetShortDescriptionUpper(rch), ch.GetShortDescription(rch)))
+		}
+	}
+}
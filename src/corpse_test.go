@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPlayerCorpsesSurviveAReboot(t *testing.T) {
+	game, err := newScriptTestGame(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer game.db.Close()
+
+	suite := &scriptTestSuite{game: game}
+	fallen := suite.player("Fallen", nil)
+
+	cell, err := game.LoadRoomIndex(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cell.AddCharacter(fallen)
+	suite.object(6, game.vm.ToValue(fallen))
+	fallen.Gold = 35
+
+	game.Damage(nil, fallen, false, fallen.Health+10, DamageTypeSlash)
+	if fallen.Room == cell {
+		t.Fatal("the player did not die")
+	}
+
+	reboot := newGame()
+	reboot.db = game.db
+
+	if err := reboot.loadWorld(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reboot.LoadPlayerCorpses(); err != nil {
+		t.Fatal(err)
+	}
+
+	room, err := reboot.LoadRoomIndex(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var corpse *ObjectInstance
+	for obj := range room.Objects.All() {
+		if obj.Corpse != nil {
+			corpse = obj
+		}
+	}
+
+	if corpse == nil {
+		t.Fatal("the corpse was not reloaded")
+	}
+
+	if corpse.Corpse.OwnerId != fallen.Id || corpse.Corpse.OwnerName != "Fallen" {
+		t.Fatalf("the corpse belongs to %s (%d)", corpse.Corpse.OwnerName, corpse.Corpse.OwnerId)
+	}
+
+	var sword, gold bool
+	for obj := range corpse.Contents.All() {
+		sword = sword || obj.ParentId == 6
+		gold = gold || (obj.ItemType == ItemTypeCurrency && obj.Value0 == 35)
+	}
+
+	if !sword || !gold {
+		t.Fatalf("the corpse lost its contents: sword %v, gold %v", sword, gold)
+	}
+}
+
+func TestUnsavedCorpsesLeaveBelongingsWithTheirOwner(t *testing.T) {
+	game, err := newScriptTestGame(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer game.db.Close()
+
+	suite := &scriptTestSuite{game: game}
+	fallen := suite.player("Unlucky", nil)
+
+	cell, err := game.LoadRoomIndex(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cell.AddCharacter(fallen)
+	sword := suite.object(6, game.vm.ToValue(fallen))
+	sword.WearLocation = WearLocationWielded
+	fallen.Gold = 35
+
+	/* Without anywhere to record the corpse, saving it fails after its contents have been handed over */
+	if _, err := game.db.Exec(`DROP TABLE player_corpses`); err != nil {
+		t.Fatal(err)
+	}
+
+	game.Damage(nil, fallen, false, fallen.Health+10, DamageTypeSlash)
+	if fallen.Room == cell {
+		t.Fatal("the player did not die")
+	}
+
+	if sword.CarriedBy != fallen || sword.WearLocation != WearLocationWielded || fallen.Inventory.Count != 1 {
+		t.Fatalf("the sword was not handed back: carried by %v, worn at %d", sword.CarriedBy, sword.WearLocation)
+	}
+
+	if fallen.Gold != 35 {
+		t.Fatalf("the player has %d gold, want 35", fallen.Gold)
+	}
+
+	for obj := range cell.Objects.All() {
+		if obj.Corpse != nil || (obj.Contents != nil && obj.Contents.Count > 0) {
+			t.Fatal("the corpse still holds the player's belongings")
+		}
+	}
+}
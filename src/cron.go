@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* Give up looking for a matching time after this long, e.g. for "0 0 30 2 *" */
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+/* A standard five-field crontab expression: minute hour day-of-month month day-of-week */
+type CronSchedule struct {
+	Expression string
+
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	/* Per cron, a day matches either restricted day field when both are restricted */
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+func ParseCronSchedule(expression string) (*CronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+
+	fields := strings.Fields(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		fields = strings.Fields(macro)
+	}
+
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", expression, len(cronFields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+
+		bits[i], err = parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %v", expression, err)
+		}
+	}
+
+	/* Sunday may be written as either 0 or 7 */
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &CronSchedule{
+		Expression:    expression,
+		minutes:       bits[0],
+		hours:         bits[1],
+		daysOfMonth:   bits[2],
+		months:        bits[3],
+		daysOfWeek:    bits[4],
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if rangePart, stepPart, ok := strings.Cut(part, "/"); ok {
+			var err error
+
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step %q in %s field", stepPart, spec.name)
+			}
+
+			part = rangePart
+		}
+
+		low, high := spec.min, spec.max
+
+		switch {
+		case part == "*":
+
+		case strings.Contains(part, "-"):
+			lowPart, highPart, _ := strings.Cut(part, "-")
+
+			var err error
+			low, err = strconv.Atoi(lowPart)
+			if err != nil {
+				return 0, fmt.Errorf("bad range %q in %s field", part, spec.name)
+			}
+
+			high, err = strconv.Atoi(highPart)
+			if err != nil {
+				return 0, fmt.Errorf("bad range %q in %s field", part, spec.name)
+			}
+
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q in %s field", part, spec.name)
+			}
+
+			low = value
+			if step == 1 {
+				high = value
+			}
+		}
+
+		if low < spec.min || high > spec.max || low > high {
+			return 0, fmt.Errorf("%s field %q is outside of %d-%d", spec.name, part, spec.min, spec.max)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	if bits == 0 {
+		return 0, errors.New("empty " + spec.name + " field")
+	}
+
+	return bits, nil
+}
+
+func (schedule *CronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := schedule.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := schedule.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case schedule.anyDayOfMonth && schedule.anyDayOfWeek:
+		return true
+	case schedule.anyDayOfMonth:
+		return dayOfWeek
+	case schedule.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+/* Next returns the first matching minute strictly after the given time, or the zero time if none is found */
+func (schedule *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if schedule.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !schedule.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if schedule.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if schedule.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
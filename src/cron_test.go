@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	/* Wednesday */
+	after := time.Date(2024, time.January, 17, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 17, 10, 31, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 17, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 17, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, time.January, 17, 13, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+		{"30 6 1,15 * *", time.Date(2024, time.February, 1, 6, 30, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCronSchedule(test.expression)
+		if err != nil {
+			t.Fatalf("ParseCronSchedule(%q): %v", test.expression, err)
+		}
+
+		if got := schedule.Next(after); !got.Equal(test.want) {
+			t.Fatalf("%q.Next() = %v, want %v", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestParseCronScheduleRejectsBadExpressions(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@fortnightly"} {
+		if _, err := ParseCronSchedule(expression); err == nil {
+			t.Fatalf("ParseCronSchedule(%q) succeeded, want an error", expression)
+		}
+	}
+}
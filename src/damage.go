@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import "strings"
+
+/*
+ * Each damage type names the RESIST_*, IMMUNE_* and SUSCEPT_* bit that
+ * modifies it (the three families share bit positions) and the armor value,
+ * from GetArmorValues, that absorbs it.  Elemental damage is stopped by the
+ * same exotic armor as magic.
+ */
+type DamageType struct {
+	Name      string `json:"name"`
+	Type      int    `json:"type"`
+	Flag      int    `json:"flag"`
+	Armor     int    `json:"armor"`
+	Verb      string `json:"verb"`
+	VerbOther string `json:"verbOther"`
+}
+
+var DamageTypeTable []DamageType = []DamageType{
+	{Name: "bash", Type: DamageTypeBash, Flag: RESIST_BASH, Armor: DamageTypeBash, Verb: "hit", VerbOther: "hits"},
+	{Name: "slash", Type: DamageTypeSlash, Flag: RESIST_SLASH, Armor: DamageTypeSlash, Verb: "slash", VerbOther: "slashes"},
+	{Name: "stab", Type: DamageTypeStab, Flag: RESIST_STAB, Armor: DamageTypeStab, Verb: "stab", VerbOther: "stabs"},
+	{Name: "exotic", Type: DamageTypeExotic, Flag: RESIST_EXOTIC, Armor: DamageTypeExotic, Verb: "zap", VerbOther: "zaps"},
+	{Name: "fire", Type: DamageTypeFire, Flag: RESIST_FIRE, Armor: DamageTypeExotic, Verb: "burn", VerbOther: "burns"},
+	{Name: "cold", Type: DamageTypeCold, Flag: RESIST_COLD, Armor: DamageTypeExotic, Verb: "freeze", VerbOther: "freezes"},
+	{Name: "shock", Type: DamageTypeShock, Flag: RESIST_SHOCK, Armor: DamageTypeExotic, Verb: "shock", VerbOther: "shocks"},
+	{Name: "poison", Type: DamageTypePoison, Flag: RESIST_POISON, Armor: DamageTypeExotic, Verb: "poison", VerbOther: "poisons"},
+	{Name: "true", Type: DamageTypeTrue, Flag: 0, Armor: -1, Verb: "hit", VerbOther: "hits"},
+}
+
+/* The flag table for resist, immune and suscept masks, which share their bits */
+var DamageFlagTable []Flag = []Flag{
+	{Name: "bash", Flag: RESIST_BASH},
+	{Name: "fire", Flag: RESIST_FIRE},
+	{Name: "cold", Flag: RESIST_COLD},
+	{Name: "shock", Flag: RESIST_SHOCK},
+	{Name: "poison", Flag: RESIST_POISON},
+	{Name: "slash", Flag: RESIST_SLASH},
+	{Name: "stab", Flag: RESIST_STAB},
+	{Name: "exotic", Flag: RESIST_EXOTIC},
+}
+
+func FindDamageType(damageType int) *DamageType {
+	for i := range DamageTypeTable {
+		if DamageTypeTable[i].Type == damageType {
+			return &DamageTypeTable[i]
+		}
+	}
+
+	return nil
+}
+
+func FindDamageTypeByName(name string) *DamageType {
+	for i := range DamageTypeTable {
+		if strings.EqualFold(DamageTypeTable[i].Name, name) {
+			return &DamageTypeTable[i]
+		}
+	}
+
+	return nil
+}
+
+func DamageFlagNames(flags int) string {
+	return FlagNames(flags, DamageFlagTable)
+}
+
+/* The breakdown of one application of damage, as returned by Game.Damage */
+type DamageResult struct {
+	DamageType  int  `json:"damageType"`
+	Requested   int  `json:"requested"`
+	Amount      int  `json:"amount"`
+	Absorbed    int  `json:"absorbed"`
+	Immune      bool `json:"immune"`
+	Resisted    bool `json:"resisted"`
+	Susceptible bool `json:"susceptible"`
+	Sanctuary   bool `json:"sanctuary"`
+	Killed      bool `json:"killed"`
+}
+
+/* Modifier masks from the character, its race, and any resist, immunity or susceptibility effects */
+func (ch *Character) DamageModifiers() (resist int, immune int, suscept int) {
+	resist, immune, suscept = ch.Resist, ch.Immune, ch.Suscept
+
+	if ch.Race != nil {
+		resist |= ch.Race.Resist
+		immune |= ch.Race.Immune
+		suscept |= ch.Race.Suscept
+	}
+
+	if ch.Effects != nil {
+		for fx := range ch.Effects.All() {
+			switch fx.EffectType {
+			case EffectTypeResist:
+				resist |= fx.Bits
+			case EffectTypeImmunity:
+				immune |= fx.Bits
+			case EffectTypeSuscept:
+				suscept |= fx.Bits
+			}
+		}
+	}
+
+	return resist, immune, suscept
+}
+
+/*
+ * Work out how much of amount reaches target without applying it.  Immunity
+ * stops the damage outright; otherwise armor absorbs a quarter of its rating,
+ * resistance takes off a third, susceptibility adds half (the two cancel when
+ * both apply) and sanctuary halves what is left.  Healing, a negative amount,
+ * and true damage pass through untouched.
+ */
+func (game *Game) MitigateDamage(ch *Character, target *Character, amount int, damageType int) *DamageResult {
+	result := &DamageResult{DamageType: damageType, Requested: amount, Amount: amount}
+	if target == nil || amount <= 0 || damageType == DamageTypeTrue {
+		return result
+	}
+
+	resist, immune, suscept := target.DamageModifiers()
+
+	flag := 0
+	armor := -1
+
+	entry := FindDamageType(damageType)
+	if entry != nil {
+		flag = entry.Flag
+		armor = entry.Armor
+	}
+
+	if immune&flag != 0 {
+		result.Immune = true
+		result.Amount = 0
+		return result
+	}
+
+	if armor >= 0 {
+		ac := target.GetArmorValues()
+		result.Absorbed = min(result.Amount, max(0, ac[armor]/4))
+		result.Amount -= result.Absorbed
+	}
+
+	result.Resisted = resist&flag != 0 && suscept&flag == 0
+	result.Susceptible = suscept&flag != 0 && resist&flag == 0
+
+	if result.Resisted {
+		result.Amount -= result.Amount / 3
+	}
+
+	if result.Susceptible {
+		result.Amount += result.Amount / 2
+	}
+
+	if target.Affected&AFFECT_SANCTUARY != 0 {
+		result.Sanctuary = true
+		result.Amount /= 2
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"testing"
+)
+
+func TestMitigateDamage(t *testing.T) {
+	ogre := &Race{Name: "ogre", Resist: RESIST_BASH, Suscept: SUSCEPT_FIRE}
+
+	tests := []struct {
+		name       string
+		setup      func(target *Character)
+		amount     int
+		damageType int
+		want       int
+	}{
+		{"unmodified", nil, 30, DamageTypeFire, 30},
+		{"healing is never reduced", func(target *Character) { target.Immune = IMMUNE_EXOTIC }, -30, DamageTypeExotic, -30},
+		{"immunity stops everything", func(target *Character) { target.Immune = IMMUNE_COLD }, 30, DamageTypeCold, 0},
+		{"armor absorbs a quarter of its rating", func(target *Character) {
+			target.Inventory.Insert(&ObjectInstance{ItemType: "armor", WearLocation: WearLocationTorso, Value1: 40, Value3: 8})
+		}, 30, DamageTypeSlash, 20},
+		{"elements meet exotic armor", func(target *Character) {
+			target.Inventory.Insert(&ObjectInstance{ItemType: "armor", WearLocation: WearLocationTorso, Value1: 40, Value3: 8})
+		}, 30, DamageTypeFire, 28},
+		{"race resistance takes a third", func(target *Character) { target.Race = ogre }, 30, DamageTypeBash, 20},
+		{"race susceptibility adds half", func(target *Character) { target.Race = ogre }, 30, DamageTypeFire, 45},
+		{"resistance and susceptibility cancel", func(target *Character) { target.Race = ogre; target.Resist = RESIST_FIRE }, 30, DamageTypeFire, 30},
+		{"resist effect", func(target *Character) {
+			target.AddEffect(&Effect{EffectType: EffectTypeResist, Bits: RESIST_POISON, Duration: EffectDurationPermanent})
+		}, 30, DamageTypePoison, 20},
+		{"immunity effect", func(target *Character) {
+			target.AddEffect(&Effect{EffectType: EffectTypeImmunity, Bits: IMMUNE_SHOCK, Duration: EffectDurationPermanent})
+		}, 30, DamageTypeShock, 0},
+		{"sanctuary halves after resistance", func(target *Character) {
+			target.Race = ogre
+			target.AddEffect(&Effect{EffectType: EffectTypeAffected, Bits: AFFECT_SANCTUARY, Duration: EffectDurationPermanent})
+		}, 30, DamageTypeBash, 10},
+		{"true damage ignores all of it", func(target *Character) {
+			target.Immune = IMMUNE_STAB
+			target.AddEffect(&Effect{EffectType: EffectTypeAffected, Bits: AFFECT_SANCTUARY, Duration: EffectDurationPermanent})
+		}, 30, DamageTypeTrue, 30},
+	}
+
+	game := &Game{}
+
+	for _, test := range tests {
+		target := NewCharacter()
+		if test.setup != nil {
+			test.setup(target)
+		}
+
+		result := game.MitigateDamage(nil, target, test.amount, test.damageType)
+		if result.Amount != test.want {
+			t.Errorf("%s: %d damage became %d, want %d", test.name, test.amount, result.Amount, test.want)
+		}
+	}
+}
@@ -71,7 +71,10 @@ func (game *Game) LoadRaceTable() error {
 			name,
 			display_name,
 			playable,
-			primary_attribute
+			primary_attribute,
+			resist_flags,
+			immune_flags,
+			suscept_flags
 		FROM
 			races
 		WHERE
@@ -87,7 +90,7 @@ func (game *Game) LoadRaceTable() error {
 		race := &Race{}
 		var primaryAttribute string = "none"
 
-		err := rows.Scan(&race.Id, &race.Name, &race.DisplayName, &race.Playable, &primaryAttribute)
+		err := rows.Scan(&race.Id, &race.Name, &race.DisplayName, &race.Playable, &primaryAttribute, &race.Resist, &race.Immune, &race.Suscept)
 		if err != nil {
 			log.Printf("Unable to scan race row: %v.\r\n", err)
 			continue
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+const (
+	DiffEqual = iota
+	DiffDelete
+	DiffInsert
+)
+
+type DiffLine struct {
+	Operation int
+	Text      string
+}
+
+/* A run of changes with surrounding context, numbered from 1 as in diff -u */
+type DiffHunk struct {
+	FromLine  int
+	FromCount int
+	ToLine    int
+	ToCount   int
+	Lines     []DiffLine
+}
+
+/* Line diff by longest common subsequence, after trimming the common prefix and suffix */
+func DiffLines(from []string, to []string) []DiffLine {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	a := from[prefix : len(from)-suffix]
+	b := to[prefix : len(to)-suffix]
+
+	/* lengths[i][j] is the LCS length of a[i:] and b[j:] */
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]DiffLine, 0, len(from)+len(to))
+	for _, text := range from[:prefix] {
+		lines = append(lines, DiffLine{Operation: DiffEqual, Text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, DiffLine{Operation: DiffEqual, Text: a[i]})
+			i++
+			j++
+
+		/* Prefer deletions so that a replaced line reads as - then + */
+		case i < len(a) && (j == len(b) || lengths[i+1][j] >= lengths[i][j+1]):
+			lines = append(lines, DiffLine{Operation: DiffDelete, Text: a[i]})
+			i++
+
+		default:
+			lines = append(lines, DiffLine{Operation: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, DiffLine{Operation: DiffEqual, Text: text})
+	}
+
+	return lines
+}
+
+/* Group changed lines into hunks, keeping up to context unchanged lines around each change */
+func DiffHunks(lines []DiffLine, context int) []DiffHunk {
+	hunks := make([]DiffHunk, 0)
+
+	var hunk *DiffHunk
+	fromLine, toLine := 1, 1
+	trailing := 0
+
+	for index, line := range lines {
+		if line.Operation != DiffEqual {
+			if hunk == nil {
+				start := max(0, index-context)
+
+				hunk = &DiffHunk{
+					FromLine: fromLine - (index - start),
+					ToLine:   toLine - (index - start),
+					Lines:    append([]DiffLine{}, lines[start:index]...),
+				}
+
+				hunk.FromCount = index - start
+				hunk.ToCount = index - start
+			}
+
+			trailing = 0
+		}
+
+		if hunk != nil {
+			if line.Operation == DiffEqual {
+				/* Close the hunk unless another change follows within the context window */
+				if trailing >= context && !diffChangeWithin(lines[index:], context+1) {
+					hunks = append(hunks, *hunk)
+					hunk = nil
+				} else {
+					trailing++
+				}
+			}
+
+			if hunk != nil {
+				hunk.Lines = append(hunk.Lines, line)
+
+				if line.Operation != DiffInsert {
+					hunk.FromCount++
+				}
+
+				if line.Operation != DiffDelete {
+					hunk.ToCount++
+				}
+			}
+		}
+
+		if line.Operation != DiffInsert {
+			fromLine++
+		}
+
+		if line.Operation != DiffDelete {
+			toLine++
+		}
+	}
+
+	if hunk != nil {
+		hunks = append(hunks, *hunk)
+	}
+
+	return hunks
+}
+
+func diffChangeWithin(lines []DiffLine, count int) bool {
+	for index := 0; index < count && index < len(lines); index++ {
+		if lines[index].Operation != DiffEqual {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func renderDiffHunks(hunks []DiffHunk) string {
+	var output strings.Builder
+
+	for _, hunk := range hunks {
+		output.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", hunk.FromLine, hunk.FromCount, hunk.ToLine, hunk.ToCount))
+
+		for _, line := range hunk.Lines {
+			output.WriteString([]string{" ", "-", "+"}[line.Operation] + line.Text + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+func TestDiffHunks(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"a\nb\nc", "a\nb\nc", ""},
+		{"a\nb\nc", "a\nx\nc", "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"", "a", "@@ -1,1 +1,1 @@\n-\n+a\n"},
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10", "@@ -8,2 +8,3 @@\n 8\n 9\n+10\n"},
+		{"a\n1\n2\n3\n4\n5\nb", "A\n1\n2\n3\n4\n5\nB", "@@ -1,3 +1,3 @@\n-a\n+A\n 1\n 2\n@@ -5,3 +5,3 @@\n 4\n 5\n-b\n+B\n"},
+		{"a\n1\n2\n3\nb", "A\n1\n2\n3\nB", "@@ -1,5 +1,5 @@\n-a\n+A\n 1\n 2\n 3\n-b\n+B\n"},
+	}
+
+	for _, test := range tests {
+		got := renderDiffHunks(DiffHunks(DiffLines(strings.Split(test.from, "\n"), strings.Split(test.to, "\n")), 2))
+		if got != test.want {
+			t.Fatalf("diff of %q and %q:\n%s\nwant:\n%s", test.from, test.to, got, test.want)
+		}
+	}
+}
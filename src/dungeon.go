@@ -80,7 +80,7 @@ func (game *Game) GenerateDungeon(floorCount int, dungeonWidth int, dungeonHeigh
 		for y := 0; y < floor.Height; y++ {
 			for x := 0; x < floor.Width; x++ {
 				if !floor.Grid[x][y].Wall && floor.Grid[x][y].Room != nil {
-					nodes := floor.findPathAStar(entryPoint, floor.Grid[x][y])
+					nodes := floor.findPathAStar(entryPoint, floor.Grid[x][y], nil)
 					difficulty := len(nodes) - 1
 
 					if difficulty < 0 {
@@ -26,6 +26,8 @@ const (
 	EffectTypeAffected      = 0
 	EffectTypeStat          = 1
 	EffectTypeImmunity      = 2
+	EffectTypeResist        = 3
+	EffectTypeSuscept       = 4
 )
 
 type Effect struct {
@@ -71,6 +73,8 @@ var AffectedFlagTable []Flag = []Flag{
 	{Name: "poison", Flag: AFFECT_POISON},
 	{Name: "paralysis", Flag: AFFECT_PARALYSIS},
 	{Name: "blindness", Flag: AFFECT_BLINDNESS},
+	{Name: "charm", Flag: AFFECT_CHARM},
+	{Name: "flying", Flag: AFFECT_FLYING},
 }
 
 func GetAffectedFlagName(bit int) string {
@@ -10,7 +10,6 @@ package main
 import (
 	"fmt"
 	"log"
-	"math/rand"
 	"time"
 )
 
@@ -18,6 +17,13 @@ type Combat struct {
 	StartedAt    time.Time    `json:"startedAt"`
 	Room         *Room        `json:"room"`
 	Participants []*Character `json:"participants"`
+
+	/* Damage dealt and kills made by each participant, for the summary when the fight ends */
+	damage map[*Character]int
+	kills  map[*Character]int
+
+	/* Everything that happened, see combat_log.go */
+	log *CombatLog
 }
 
 func (combat *Combat) hasParticipant(ch *Character) bool {
@@ -41,6 +47,7 @@ func (combat *Combat) addParticipant(ch *Character) {
 
 	if !combat.hasParticipant(ch) {
 		combat.Participants = append(combat.Participants, ch)
+		combat.combatLog().addParticipant(ch)
 	}
 
 	ch.Combat = combat
@@ -178,6 +185,13 @@ const (
 	DamageTypeSlash  = 1
 	DamageTypeStab   = 2
 	DamageTypeExotic = 3
+	DamageTypeFire   = 4
+	DamageTypeCold   = 5
+	DamageTypeShock  = 6
+	DamageTypePoison = 7
+
+	/* Bypasses armor, resistances and effects entirely */
+	DamageTypeTrue = 8
 )
 
 func (ch *Character) GetArmorValues() []int {
@@ -221,6 +235,7 @@ func (game *Game) createCorpse(ch *Character) *ObjectInstance {
 	obj := &ObjectInstance{Game: game}
 
 	obj.ParentId = 1
+	obj.Serial = newObjectSerial()
 	obj.Description = fmt.Sprintf("The slain corpse of %s.", ch.GetShortDescription(ch))
 	obj.ShortDescription = fmt.Sprintf("the corpse of %s", ch.GetShortDescription(ch))
 	obj.LongDescription = fmt.Sprintf("The corpse of %s is lying here.", ch.GetShortDescription(ch))
@@ -231,20 +246,18 @@ func (game *Game) createCorpse(ch *Character) *ObjectInstance {
 	obj.Ttl = 20
 	obj.WearLocation = -1
 
+	if ch.Flags&CHAR_IS_PLAYER != 0 {
+		obj.Ttl = Config.CorpseConfiguration.DecayMinutes
+		obj.Corpse = &PlayerCorpse{OwnerId: ch.Id, OwnerName: ch.Name}
+	}
+
 	obj.Contents = NewLinkedList[*ObjectInstance]()
+	wearLocations := make(map[*ObjectInstance]int)
 	if ch.Inventory != nil {
 		carriedObjects := make([]*ObjectInstance, 0, ch.Inventory.Count)
 		for carriedObj := range ch.Inventory.All() {
 			carriedObjects = append(carriedObjects, carriedObj)
-		}
-
-		if ch.Flags&CHAR_IS_PLAYER != 0 {
-			for _, carriedObj := range carriedObjects {
-				err := ch.DetachObject(carriedObj)
-				if err != nil {
-					log.Printf("Warning: failed to detach object from slain PC: %v\r\n", err)
-				}
-			}
+			wearLocations[carriedObj] = carriedObj.WearLocation
 		}
 
 		for _, carriedObj := range carriedObjects {
@@ -253,6 +266,7 @@ func (game *Game) createCorpse(ch *Character) *ObjectInstance {
 
 		for i := len(carriedObjects) - 1; i >= 0; i-- {
 			obj.AddObject(carriedObjects[i])
+			game.AuditObject(carriedObjects[i], ObjectAuditDropped, auditCharacter(ch), auditContainer(obj))
 		}
 	}
 
@@ -267,6 +281,14 @@ func (game *Game) createCorpse(ch *Character) *ObjectInstance {
 	// Remove any gold on their person
 	ch.Gold = 0
 
+	if obj.Corpse != nil {
+		err := game.persistPlayerCorpse(obj, ch, ch.Room)
+		if err != nil {
+			log.Printf("Warning: failed to save the corpse of %s, leaving their belongings with them: %v\r\n", ch.Name, err)
+			game.restoreCorpseContents(obj, ch, gobj, wearLocations)
+		}
+	}
+
 	return obj
 }
 
@@ -329,25 +351,26 @@ func awardExperienceToRecipients(experience int, recipients []*Character) {
 	}
 }
 
-func (game *Game) Damage(ch *Character, target *Character, display bool, amount int, damageType int) bool {
+/* Apply damage (or healing, if amount is negative) after mitigation, returning what was dealt */
+func (game *Game) Damage(ch *Character, target *Character, display bool, amount int, damageType int) *DamageResult {
 	if target == nil {
-		return false
+		return nil
 	}
 
-	var damageTypeVerbTable map[int]string = make(map[int]string)
-	var damageTypeVerbOtherTable map[int]string = make(map[int]string)
-
-	damageTypeVerbOtherTable[DamageTypeBash] = "hits"
-	damageTypeVerbTable[DamageTypeBash] = "hit"
+	/* Scripted skills and spells may not hurt a player who is off limits to ch */
+	if amount > 0 && !ch.MayAttack(target) {
+		return &DamageResult{DamageType: damageType, Requested: amount}
+	}
 
-	damageTypeVerbOtherTable[DamageTypeSlash] = "slashes"
-	damageTypeVerbTable[DamageTypeSlash] = "slash"
+	result := game.MitigateDamage(ch, target, amount, damageType)
+	amount = result.Amount
 
-	damageTypeVerbOtherTable[DamageTypeStab] = "stabs"
-	damageTypeVerbTable[DamageTypeStab] = "stab"
+	verb, verbOther := "hit", "hits"
 
-	damageTypeVerbOtherTable[DamageTypeExotic] = "zaps"
-	damageTypeVerbTable[DamageTypeExotic] = "zap"
+	entry := FindDamageType(damageType)
+	if entry != nil {
+		verb, verbOther = entry.Verb, entry.VerbOther
+	}
 
 	if display && ch != nil {
 		if ch.Room != nil && target.Room != nil && target.Room == ch.Room {
@@ -355,28 +378,57 @@ func (game *Game) Damage(ch *Character, target *Character, display bool, amount
 				if character != ch && character != target {
 					character.Send(fmt.Sprintf("{G%s{G %s %s{G for %d damage.{x\r\n",
 						ch.GetShortDescriptionUpper(character),
-						damageTypeVerbOtherTable[damageType],
+						verbOther,
 						target.GetShortDescription(character),
 						amount))
 				}
 			}
 		}
 
-		ch.Send(fmt.Sprintf("{GYou %s %s{G for %d damage.{x\r\n", damageTypeVerbTable[damageType], target.GetShortDescription(ch), amount))
-		target.Send(fmt.Sprintf("{Y%s{Y %s you for %d damage.{x\r\n", ch.GetShortDescriptionUpper(target), damageTypeVerbOtherTable[damageType], amount))
+		ch.Send(fmt.Sprintf("{GYou %s %s{G for %d damage.{x\r\n", verb, target.GetShortDescription(ch), amount))
+		target.Send(fmt.Sprintf("{Y%s{Y %s you for %d damage.{x\r\n", ch.GetShortDescriptionUpper(target), verbOther, amount))
+
+		if result.Immune {
+			ch.Send(fmt.Sprintf("{D%s{D seems completely unaffected.{x\r\n", target.GetShortDescriptionUpper(ch)))
+		}
 	}
 
+	before := target.Health
 	target.Health -= amount
 
 	if target.Health > target.MaxHealth {
 		target.Health = target.MaxHealth
 	}
 
+	game.logCombatDamage(ch, target, result)
+
+	if amount > 0 {
+		game.notePvPAttack(ch, target)
+	}
+
+	if amount >= 0 {
+		target.AddThreat(ch, amount)
+	} else {
+		game.addHealingThreat(ch, target, target.Health-before)
+	}
+
 	if target.Level > LevelHero && target.Health < 1 {
 		target.Health = 1
 	}
 
+	if ch != nil && ch != target && ch.Combat != nil {
+		ch.Combat.recordDamage(ch, amount, target.Health <= 0)
+	}
+
 	if target.Health <= 0 {
+		result.Killed = true
+
+		game.logCombatDeath(ch, target)
+
+		if target.Room != nil {
+			target.fireMobileTrigger(MobileTriggerDeath, game.vm.ToValue(ch))
+		}
+
 		if target.Room != nil {
 			room := target.Room
 			var experienceRecipients []*Character
@@ -384,9 +436,24 @@ func (game *Game) Damage(ch *Character, target *Character, display bool, amount
 				experienceRecipients = game.experienceRecipientsForKill(ch, target, room)
 			}
 
+			if ch != nil && ch != target && ch.Flags&CHAR_IS_PLAYER != 0 && target.Flags&CHAR_IS_PLAYER != 0 {
+				game.recordPlayerKill(ch, target, room)
+			}
+
 			corpse := game.createCorpse(target)
 
-			room.removeCharacter(target)
+			/* Fallen players leave for limbo, and the room's leave trigger sees them go there */
+			var limbo *Room
+			if target.Flags&CHAR_IS_PLAYER != 0 {
+				limbo, _ = game.LoadRoomIndex(RoomLimbo)
+			}
+
+			if limbo != nil {
+				room.removeCharacterForMove(target, limbo)
+			} else {
+				room.removeCharacter(target)
+			}
+
 			room.AddObject(corpse)
 
 			game.Objects.Insert(corpse)
@@ -412,12 +479,14 @@ func (game *Game) Damage(ch *Character, target *Character, display bool, amount
 				target.Send(string(Config.death))
 				target.Send("{x\r\n")
 
-				limbo, err := game.LoadRoomIndex(RoomLimbo)
-				if err != nil {
-					return true
+				if limbo == nil {
+					return result
 				}
 
-				limbo.AddCharacter(target)
+				/* A leave trigger may have already put the character somewhere else */
+				if target.Room == nil {
+					limbo.AddCharacter(target)
+				}
 
 				target.Effects = NewLinkedList[*Effect]()
 				target.refreshAffected()
@@ -428,24 +497,32 @@ func (game *Game) Damage(ch *Character, target *Character, display bool, amount
 				target.Casting = nil
 
 				do_look(target, "")
+				limbo.characterEntered(target, room)
 			} else {
 				exp := int(target.Experience)
 				awardExperienceToRecipients(exp, experienceRecipients)
 
+				game.autoLootCorpse(ch, corpse)
+
 				game.Characters.Remove(target)
 				target = nil
 			}
 		}
 	}
 
-	return true
+	return result
 }
 
 func (game *Game) combatUpdate() {
+	game.combatRound()
 	game.InvokeNamedEventHandlersWithContextAndArguments("combatUpdate", game.vm.ToValue(game))
+	game.mobileFightTriggers()
 }
 
 func (game *Game) DisposeCombat(combat *Combat) {
+	game.sendCombatSummaries(combat)
+	game.recordCombatLog(combat)
+
 	for _, vch := range combat.Participants {
 		if vch == nil {
 			continue
@@ -457,6 +534,7 @@ func (game *Game) DisposeCombat(combat *Combat) {
 
 		vch.Combat = nil
 		vch.Fighting = nil
+		vch.ClearThreat()
 	}
 
 	game.Fights.Remove(combat)
@@ -485,7 +563,7 @@ func do_flee(ch *Character, arguments string) {
 		}
 	}
 
-	if len(exits) == 0 || rand.Intn(10) < 7 {
+	if len(exits) == 0 || ch.Game.combatIntn(10) < 7 {
 		ch.Send("{RYou panic and attempt to flee, but can't get away!{x\r\n")
 
 		/* Announce player's failed flee attempt to others in the room */
@@ -499,7 +577,7 @@ func do_flee(ch *Character, arguments string) {
 		return
 	}
 
-	var choice int = rand.Intn(len(exits))
+	var choice int = ch.Game.combatIntn(len(exits))
 	var chosenEscape *Exit = exits[choice]
 	destination := chosenEscape.To
 
@@ -531,7 +609,8 @@ func do_flee(ch *Character, arguments string) {
 	ch.Fighting = nil
 	ch.Combat = nil
 
-	ch.Room.moveCharacter(ch, destination)
+	from := ch.Room
+	from.moveCharacter(ch, destination)
 
 	/* Announce player's arrival to all other players in the new room */
 	for rch := range ch.Room.Characters.All() {
@@ -542,6 +621,7 @@ func do_flee(ch *Character, arguments string) {
 	}
 
 	do_look(ch, "")
+	destination.characterEntered(ch, from)
 }
 
 func do_kill(ch *Character, arguments string) {
@@ -571,7 +651,13 @@ func do_kill(ch *Character, arguments string) {
 		return
 	}
 
-	ch.Game.combatForAttack(ch, target)
+	if !ch.CanAttack(target) {
+		return
+	}
+
+	ch.Game.notePvPAttack(ch, target)
+
+	combat := ch.Game.combatForAttack(ch, target)
 
 	ch.Fighting = target
 
@@ -591,4 +677,6 @@ func do_kill(ch *Character, arguments string) {
 			}
 		}
 	}
+
+	ch.Game.autoAssist(combat)
 }
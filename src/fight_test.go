@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"testing"
+)
+
+func TestReconcileCombatParticipants(t *testing.T) {
+	game := &Game{Fights: NewLinkedList[*Combat]()}
+
+	fighter, deserter, stray := NewCharacter(), NewCharacter(), NewCharacter()
+
+	combat := &Combat{Participants: []*Character{fighter, deserter, stray}}
+	elsewhere := &Combat{Participants: []*Character{deserter}}
+	game.Fights.Insert(combat)
+	game.Fights.Insert(elsewhere)
+
+	fighter.Combat = combat
+	deserter.Combat = elsewhere
+
+	game.reconcileCombatParticipants(combat)
+
+	if len(combat.Participants) != 2 || combat.hasParticipant(deserter) {
+		t.Fatalf("participants after reconciling = %d, want the deserter dropped", len(combat.Participants))
+	}
+
+	if stray.Combat != combat || fighter.Combat != combat {
+		t.Error("remaining participants do not point back at their combat")
+	}
+
+	if deserter.Combat != elsewhere || !elsewhere.hasParticipant(deserter) {
+		t.Error("the deserter lost its place in the other combat")
+	}
+}
+
+func TestCombatForAttackJoinsTheTargetsFight(t *testing.T) {
+	game := &Game{Fights: NewLinkedList[*Combat]()}
+
+	room := game.NewRoom()
+	room.Characters = NewLinkedList[*Character]()
+
+	ch, target, latecomer := NewCharacter(), NewCharacter(), NewCharacter()
+	for _, vch := range []*Character{ch, target, latecomer} {
+		vch.Game = game
+		room.AddCharacter(vch)
+	}
+
+	combat := game.combatForAttack(ch, target)
+	if joined := game.combatForAttack(latecomer, target); joined != combat {
+		t.Fatal("attacking someone already fighting started a second combat")
+	}
+
+	if game.Fights.Count != 1 || len(combat.Participants) != 3 || latecomer.Combat != combat {
+		t.Errorf("%d fights with %d participants, want one with all three", game.Fights.Count, len(combat.Participants))
+	}
+}
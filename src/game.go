@@ -11,6 +11,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"math/rand"
 	"net"
 	"net/http"
 	"os"
@@ -33,6 +34,9 @@ const (
 type Game struct {
 	startedAt time.Time
 
+	/* Midnight on the first day of the game clock, see Hour */
+	clockEpoch time.Time
+
 	db       *sql.DB
 	vm       *goja.Runtime
 	listener net.Listener
@@ -52,12 +56,36 @@ type Game struct {
 	socials     map[string]*Social
 
 	eventHandlers   map[string]*LinkedList[*EventHandler]
+	combatHooks     map[string]*CombatHook
 	Scripts         map[uint]*Script `json:"scripts"`
 	objectScripts   map[uint]*Script
+	mobileScripts   map[uint]*Script
+	scriptSchedules map[string]*ScriptSchedule
 	districtScripts map[int]*Script
 	webhookScripts  map[int]*Script
 	webhooks        map[string]*Webhook
 
+	scriptRoot  string
+	modules     map[string]*ScriptModule
+	moduleStack []string
+
+	/* Scripts and modules currently running, innermost last; see Golem.store.script() */
+	storeOwners []string
+
+	/* Script files saved while in development mode, see watchScripts */
+	scriptFileChanges chan string
+
+	/* Source of combat rolls, see combatIntn */
+	combatRandom *rand.Rand
+
+	/* The most recently finished fights, oldest first, see recordCombatLog */
+	combatLogs        []*CombatLog
+	combatLogSequence uint
+
+	scriptDepth      int
+	scriptViolations map[string]int
+	disabledScripts  map[string]bool
+
 	register                 chan *Client
 	unregister               chan *Client
 	quitRequest              chan *Client
@@ -78,24 +106,7 @@ func NewGame() (*Game, error) {
 		}()
 	}
 
-	/* Create the game world instance and initialize variables & channels */
-	game := &Game{startedAt: time.Now()}
-
-	game.clients = make(map[*Client]bool)
-	game.register = make(chan *Client)
-	game.unregister = make(chan *Client)
-	game.quitRequest = make(chan *Client)
-	game.shutdownRequest = make(chan bool)
-	game.webhookMessage = make(chan string)
-	game.worldMapRequest = make(chan worldMapRequest)
-	game.clientMessage = make(chan ClientTextMessage)
-	game.planeGenerationCompleted = make(chan int)
-
-	game.Characters = NewLinkedList[*Character]()
-	game.Fights = NewLinkedList[*Combat]()
-	game.Objects = NewLinkedList[*ObjectInstance]()
-	game.ScriptTimers = NewLinkedList[*ScriptTimer]()
-	game.Planes = NewLinkedList[*Plane]()
+	game := newGame()
 
 	/* Initialize services we'll inject elsewhere through the game instance. */
 	game.db, err = openDatabase()
@@ -109,104 +120,152 @@ func NewGame() (*Game, error) {
 		return nil, err
 	}
 
-	err = game.LoadTerrain()
+	/* Report dangling world references before the loaders below trip over them */
+	game.checkWorldAtStartup()
+
+	err = game.loadWorld()
 	if err != nil {
 		return nil, err
 	}
 
-	err = game.LoadRaceTable()
+	err = game.InitScripting()
 	if err != nil {
 		return nil, err
 	}
 
-	err = game.LoadJobTable()
+	if Config.DevelopmentConfiguration.Enabled {
+		err = game.watchScripts()
+		if err != nil {
+			log.Printf("Warning: scripts will not reload on save: %v.\r\n", err)
+		}
+	}
+
+	/* Try to initialize each plane now that potential scripts have been attached */
+	for plane := range game.Planes.All() {
+		log.Printf("Generating %s...\r\n", plane.Name)
+
+		err = plane.generate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	/* Connect districts now that plane layers are initialized */
+	err = game.LoadDistricts()
 	if err != nil {
 		return nil, err
 	}
 
-	err = game.LoadSkills()
+	err = game.LoadShops()
 	if err != nil {
 		return nil, err
 	}
 
-	err = game.LoadJobSkills()
+	err = game.LoadResets()
 	if err != nil {
 		return nil, err
 	}
 
-	err = game.LoadSocials()
+	err = game.LoadPlayerCorpses()
 	if err != nil {
 		return nil, err
 	}
 
-	game.world = make(map[uint]*Room)
+	/* Run district scripts */
+	for districtId, script := range game.districtScripts {
+		district := game.FindDistrictByID(districtId)
+		if district == nil {
+			log.Printf("Couldn't run district-script for nonexistent district id %d.\r\n", districtId)
+			continue
+		}
 
-	err = game.LoadZones()
-	if err != nil {
-		return nil, err
+		_, err := script.tryEvaluate("onStart", game.vm.ToValue(district))
+		if err != nil {
+			log.Printf("Script evaluation of %d for district %d onStart failed: %v\r\n", script.Id, districtId, err)
+		}
 	}
 
-	err = game.FixExits()
+	return game, nil
+}
+
+/* Create the game world instance and initialize variables & channels */
+func newGame() *Game {
+	game := &Game{startedAt: time.Now(), clockEpoch: time.Unix(0, 0)}
+
+	game.clients = make(map[*Client]bool)
+	game.register = make(chan *Client)
+	game.unregister = make(chan *Client)
+	game.quitRequest = make(chan *Client)
+	game.shutdownRequest = make(chan bool)
+	game.webhookMessage = make(chan string)
+	game.worldMapRequest = make(chan worldMapRequest)
+	game.clientMessage = make(chan ClientTextMessage)
+	game.planeGenerationCompleted = make(chan int)
+
+	game.Characters = NewLinkedList[*Character]()
+	game.Fights = NewLinkedList[*Combat]()
+	game.Objects = NewLinkedList[*ObjectInstance]()
+	game.ScriptTimers = NewLinkedList[*ScriptTimer]()
+	game.Planes = NewLinkedList[*Plane]()
+
+	return game
+}
+
+/* Load the static world tables, zones and planes; scripts, districts and resets follow separately */
+func (game *Game) loadWorld() error {
+	err := game.LoadTerrain()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = game.LoadPlanes()
+	err = game.LoadRaceTable()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = game.LoadWebhooks()
+	err = game.LoadJobTable()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = game.InitScripting()
+	err = game.LoadSkills()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	/* Try to initialize each plane now that potential scripts have been attached */
-	for plane := range game.Planes.All() {
-		log.Printf("Generating %s...\r\n", plane.Name)
-
-		err = plane.generate()
-		if err != nil {
-			return nil, err
-		}
+	err = game.LoadJobSkills()
+	if err != nil {
+		return err
 	}
 
-	/* Connect districts now that plane layers are initialized */
-	err = game.LoadDistricts()
+	err = game.LoadSocials()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = game.LoadShops()
+	game.world = make(map[uint]*Room)
+
+	err = game.LoadZones()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	err = game.LoadResets()
+	err = game.FixExits()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	/* Run district scripts */
-	for districtId, script := range game.districtScripts {
-		district := game.FindDistrictByID(districtId)
-		if district == nil {
-			log.Printf("Couldn't run district-script for nonexistent district id %d.\r\n", districtId)
-			continue
-		}
+	err = game.LoadPlanes()
+	if err != nil {
+		return err
+	}
 
-		_, err := script.tryEvaluate("onStart", game.vm.ToValue(district))
-		if err != nil {
-			log.Printf("Script evaluation of %d for district %d onStart failed: %v\r\n", script.Id, districtId, err)
-		}
+	err = game.LoadWebhooks()
+	if err != nil {
+		return err
 	}
 
-	return game, nil
+	return nil
 }
 
 func openDatabase() (*sql.DB, error) {
@@ -299,30 +358,51 @@ func databaseMigrationDriver(db *sql.DB) (database.Driver, string, string, error
 	return driver, databaseDriverSQLite, "file://migrations", err
 }
 
+/* Game loop periods, shared with the simulated clock of the script test runner */
+const (
+	combatPulse          = 2 * time.Second
+	scriptTimerPulse     = 1 * time.Second
+	scriptSchedulePulse  = 15 * time.Second
+	characterUpdatePulse = 2 * time.Second
+	objectUpdatePulse    = 15 * time.Second
+	updatePulse          = 15 * time.Second
+	zoneUpdatePulse      = 1 * time.Minute
+)
+
 /* Game loop */
 func (game *Game) Run() {
 	/* Handle violence logic */
-	processCombatTicker := time.NewTicker(2 * time.Second)
+	processCombatTicker := time.NewTicker(combatPulse)
 
 	/* Handle effect updates */
-	processScriptTimersTicker := time.NewTicker(1 * time.Second)
+	processScriptTimersTicker := time.NewTicker(scriptTimerPulse)
+	processScriptSchedulesTicker := time.NewTicker(scriptSchedulePulse)
 
 	/* Handle frequent character update logic */
-	processCharacterUpdateTicker := time.NewTicker(2 * time.Second)
+	processCharacterUpdateTicker := time.NewTicker(characterUpdatePulse)
 
 	/* Handle object update logic */
-	processObjectUpdateTicker := time.NewTicker(15 * time.Second)
+	processObjectUpdateTicker := time.NewTicker(objectUpdatePulse)
 
 	/* Buffered/paged output for clients */
 	processOutputTicker := time.NewTicker(50 * time.Millisecond)
 
-	processUpdateTicker := time.NewTicker(15 * time.Second)
+	processUpdateTicker := time.NewTicker(updatePulse)
 	game.Update()
 
 	/* Handle resets and trigger one immediately */
-	processZoneUpdateTicker := time.NewTicker(1 * time.Minute)
+	processZoneUpdateTicker := time.NewTicker(zoneUpdatePulse)
 	game.ZoneUpdate()
 
+	/* Scheduled database backups, if configured */
+	var processBackupTicker <-chan time.Time
+	if Config.BackupConfiguration.IntervalMinutes > 0 {
+		backupTicker := time.NewTicker(time.Duration(Config.BackupConfiguration.IntervalMinutes) * time.Minute)
+		defer backupTicker.Stop()
+
+		processBackupTicker = backupTicker.C
+	}
+
 	for {
 		select {
 		case <-processUpdateTicker.C:
@@ -331,6 +411,9 @@ func (game *Game) Run() {
 		case <-processZoneUpdateTicker.C:
 			game.ZoneUpdate()
 
+		case <-processBackupTicker:
+			game.backupUpdate()
+
 		case <-processObjectUpdateTicker.C:
 			game.objectUpdate()
 
@@ -340,6 +423,9 @@ func (game *Game) Run() {
 		case <-processScriptTimersTicker.C:
 			game.scriptTimersUpdate()
 
+		case <-processScriptSchedulesTicker.C:
+			game.scriptSchedulesUpdate()
+
 		case <-processCombatTicker.C:
 			game.combatUpdate()
 
@@ -354,6 +440,9 @@ func (game *Game) Run() {
 				}
 			}
 
+		case filename := <-game.scriptFileChanges:
+			game.scriptFileChanged(filename)
+
 		case clientMessage := <-game.clientMessage:
 			game.nanny(clientMessage.client, clientMessage.message)
 
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	HoursPerDay = 24
+
+	/* Real time that passes for each hour of game time, so a game day lasts 24 minutes */
+	GameHourDuration = time.Minute
+)
+
+/* The hour of the game day, counted from a fixed epoch so that it survives reboots */
+func (game *Game) Hour() int {
+	return int(time.Since(game.clockEpoch)/GameHourDuration) % HoursPerDay
+}
+
+func formatGameHour(hour int) string {
+	switch {
+	case hour == 0:
+		return "midnight"
+
+	case hour == 12:
+		return "noon"
+
+	case hour < 12:
+		return fmt.Sprintf("%d in the morning", hour)
+
+	case hour < 18:
+		return fmt.Sprintf("%d in the afternoon", hour-12)
+	}
+
+	return fmt.Sprintf("%d in the evening", hour-12)
+}
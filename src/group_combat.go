@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (combat *Combat) recordDamage(ch *Character, amount int, killed bool) {
+	if combat.damage == nil {
+		combat.damage = make(map[*Character]int)
+		combat.kills = make(map[*Character]int)
+	}
+
+	if amount > 0 {
+		combat.damage[ch] += amount
+	}
+
+	if killed {
+		combat.kills[ch]++
+	}
+}
+
+/* Characters in the room who would come to ch's aid: its group, and mobiles charmed into following it */
+func combatAllies(ch *Character) []*Character {
+	allies := make([]*Character, 0)
+	if ch.Room == nil {
+		return allies
+	}
+
+	for rch := range ch.Room.Characters.All() {
+		if rch == ch {
+			continue
+		}
+
+		if ch.Group != nil && ch.Group.Contains(rch) {
+			allies = append(allies, rch)
+		} else if rch.Affected&AFFECT_CHARM != 0 && rch.Following == ch {
+			allies = append(allies, rch)
+		}
+	}
+
+	return allies
+}
+
+/*
+ * Bring idle allies of every participant into the fight against whoever that
+ * participant is fighting.  Players only join with autoassist on; grouped and
+ * charmed mobiles always do, and nobody turns on their own group.
+ */
+func (game *Game) autoAssist(combat *Combat) {
+	for _, vch := range append([]*Character(nil), combat.Participants...) {
+		if vch == nil || vch.Room == nil || vch.Fighting == nil || vch.Fighting.Room != vch.Room {
+			continue
+		}
+
+		target := vch.Fighting
+
+		for _, ally := range combatAllies(vch) {
+			if ally.Fighting != nil || ally.Position <= PositionSleeping || ally == target {
+				continue
+			}
+
+			if target.InSameGroup(ally) || ally.Following == target {
+				continue
+			}
+
+			if ally.Flags&CHAR_IS_PLAYER != 0 && !ally.HasPreference(PREF_AUTOASSIST) {
+				continue
+			}
+
+			if !ally.MayAttack(target) {
+				continue
+			}
+
+			ally.Send(fmt.Sprintf("{WYou start attacking %s{W in defense of %s{W!{x\r\n", target.GetShortDescription(ally), vch.GetShortDescription(ally)))
+			for rch := range ally.Room.Characters.All() {
+				if rch != ally {
+					rch.Send(fmt.Sprintf("{W%s{W joins the fight against %s{W!{x\r\n", ally.GetShortDescriptionUpper(rch), target.GetShortDescription(rch)))
+				}
+			}
+
+			ally.Fighting = target
+			game.AddCombatParticipant(combat, ally)
+		}
+	}
+}
+
+/* At the end of a fight, tell each grouped player what their group dealt and slew */
+func (game *Game) sendCombatSummaries(combat *Combat) {
+	if len(combat.damage) == 0 && len(combat.kills) == 0 {
+		return
+	}
+
+	for _, vch := range combat.Participants {
+		if vch == nil || vch.Flags&CHAR_IS_PLAYER == 0 || vch.Group == nil || vch.Room == nil {
+			continue
+		}
+
+		var output strings.Builder
+
+		for gch := range vch.Group.All() {
+			damage, dealt := combat.damage[gch]
+			kills := combat.kills[gch]
+			if !dealt && kills == 0 {
+				continue
+			}
+
+			noun := "kills"
+			if kills == 1 {
+				noun = "kill"
+			}
+
+			output.WriteString(fmt.Sprintf("{C  %s{c: %d damage, %d %s{x\r\n", gch.GetShortDescriptionUpper(vch), damage, kills, noun))
+		}
+
+		if output.Len() == 0 {
+			continue
+		}
+
+		vch.Send("{WYour group's fight is over:{x\r\n" + output.String())
+	}
+}
+
+func do_rescue(ch *Character, arguments string) {
+	if ch.Room == nil {
+		return
+	}
+
+	if len(arguments) < 1 {
+		ch.Send("Rescue whom?\r\n")
+		return
+	}
+
+	ally := ch.FindCharacterInRoom(arguments)
+	if ally == nil {
+		ch.Send("They aren't here.\r\n")
+		return
+	}
+
+	if ally == ch {
+		ch.Send("What about fleeing instead?\r\n")
+		return
+	}
+
+	if !ch.InSameGroup(ally) && ally.Following != ch {
+		ch.Send("You can only rescue members of your group.\r\n")
+		return
+	}
+
+	var attacker *Character
+	for rch := range ch.Room.Characters.All() {
+		if rch.Fighting == ally {
+			attacker = rch
+			break
+		}
+	}
+
+	if attacker == nil {
+		ch.Send(fmt.Sprintf("Nobody is fighting %s.\r\n", ally.GetShortDescription(ch)))
+		return
+	}
+
+	if attacker == ch {
+		ch.Send("You are the one attacking them!\r\n")
+		return
+	}
+
+	proficiency := ch.FindProficiencyByName("rescue")
+	if proficiency == nil {
+		ch.Send("You don't know how to rescue anyone.\r\n")
+		return
+	}
+
+	if ch.Game.combatIntn(100) >= proficiency.Proficiency {
+		ch.Send(fmt.Sprintf("{DYou fail to rescue %s{D.{x\r\n", ally.GetShortDescription(ch)))
+		return
+	}
+
+	ch.Send(fmt.Sprintf("{WYou leap in front of %s{W and rescue them!{x\r\n", ally.GetShortDescription(ch)))
+	ally.Send(fmt.Sprintf("{W%s{W rescues you!{x\r\n", ch.GetShortDescriptionUpper(ally)))
+	for rch := range ch.Room.Characters.All() {
+		if rch != ch && rch != ally {
+			rch.Send(fmt.Sprintf("{W%s{W rescues %s{W!{x\r\n", ch.GetShortDescriptionUpper(rch), ally.GetShortDescription(rch)))
+		}
+	}
+
+	combat := attacker.Combat
+	if combat == nil {
+		combat = ch.Game.combatForAttack(ch, attacker)
+	}
+
+	attacker.Fighting = ch
+	ch.Fighting = attacker
+	ch.Game.AddCombatParticipant(combat, ch)
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAutoAssistBringsAlliesIntoTheFight(t *testing.T) {
+	game := &Game{Fights: NewLinkedList[*Combat]()}
+
+	room := game.NewRoom()
+	room.Characters = NewLinkedList[*Character]()
+
+	newCharacter := func(player bool) *Character {
+		ch := NewCharacter()
+		ch.Game = game
+		if player {
+			ch.Flags |= CHAR_IS_PLAYER
+		}
+
+		room.AddCharacter(ch)
+		return ch
+	}
+
+	leader := newCharacter(true)
+	assisting := newCharacter(true)
+	declining := newCharacter(true)
+	sleeping := newCharacter(true)
+	hireling := newCharacter(false)
+	pet := newCharacter(false)
+	bystander := newCharacter(false)
+	attacker := newCharacter(false)
+
+	declining.Preferences = 0
+	sleeping.Position = PositionSleeping
+
+	pet.Following = leader
+	pet.Affected |= AFFECT_CHARM
+
+	group := NewLinkedList[*Character]()
+	for _, gch := range []*Character{leader, assisting, declining, sleeping, hireling} {
+		gch.Group = group
+		gch.Leader = leader
+		group.Insert(gch)
+	}
+
+	combat := game.combatForAttack(attacker, leader)
+	attacker.Fighting = leader
+	leader.Fighting = attacker
+
+	game.autoAssist(combat)
+
+	want := map[*Character]bool{assisting: true, hireling: true, pet: true}
+	for name, ch := range map[string]*Character{"assisting": assisting, "declining": declining, "sleeping": sleeping, "hireling": hireling, "pet": pet, "bystander": bystander} {
+		joined := ch.Fighting == attacker && combat.hasParticipant(ch)
+		if joined != want[ch] {
+			t.Errorf("%s joined the fight: %v, want %v", name, joined, want[ch])
+		}
+	}
+}
+
+func TestCombatSummaryTotalsTheGroup(t *testing.T) {
+	game := &Game{Fights: NewLinkedList[*Combat]()}
+
+	room := game.NewRoom()
+	room.Characters = NewLinkedList[*Character]()
+
+	leader, follower := NewCharacter(), NewCharacter()
+	leader.Flags |= CHAR_IS_PLAYER
+	follower.Flags |= CHAR_IS_PLAYER
+	leader.Name, follower.Name = "Leader", "Follower"
+	leader.transcript = &bytes.Buffer{}
+	room.AddCharacter(leader)
+
+	group := NewLinkedList[*Character]()
+	for _, gch := range []*Character{leader, follower} {
+		gch.Group = group
+		group.Insert(gch)
+	}
+
+	combat := &Combat{Participants: []*Character{leader, follower}}
+	combat.recordDamage(leader, 12, false)
+	combat.recordDamage(leader, 8, true)
+	combat.recordDamage(follower, 5, false)
+
+	if combat.damage[leader] != 20 || combat.kills[leader] != 1 || combat.damage[follower] != 5 || combat.kills[follower] != 0 {
+		t.Fatalf("recorded %v damage and %v kills", combat.damage, combat.kills)
+	}
+
+	game.Fights.Insert(combat)
+	game.DisposeCombat(combat)
+
+	if leader.Combat != nil || game.Fights.Count != 0 {
+		t.Error("the combat was not disposed of")
+	}
+
+	output := stripColourCodes(leader.transcript.String())
+	for _, line := range []string{"Leader: 20 damage, 1 kill", "Follower: 5 damage, 0 kills"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("summary %q is missing %q", output, line)
+		}
+	}
+}
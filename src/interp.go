@@ -22,6 +22,9 @@ type Command struct {
 	Scripted        bool
 	Callback        goja.Callable
 	Hidden          bool
+
+	/* Script module that registered a scripted command */
+	module string
 }
 
 var CommandTable map[string]Command
@@ -71,6 +74,10 @@ func init() {
 	CommandTable["sleep"] = Command{Name: "sleep", CmdFunc: do_sleep}
 	CommandTable["stand"] = Command{Name: "stand", CmdFunc: do_stand}
 
+	/* lock.go */
+	CommandTable["lock"] = Command{Name: "lock", CmdFunc: do_lock}
+	CommandTable["unlock"] = Command{Name: "unlock", CmdFunc: do_unlock}
+
 	/* act_obj.go */
 	CommandTable["equipment"] = Command{Name: "equipment", CmdFunc: do_equipment}
 	CommandTable["inventory"] = Command{Name: "inventory", CmdFunc: do_inventory}
@@ -85,6 +92,9 @@ func init() {
 	CommandTable["eat"] = Command{Name: "eat", CmdFunc: do_eat}
 	CommandTable["fill"] = Command{Name: "fill", CmdFunc: do_fill}
 
+	/* backup.go */
+	CommandTable["backup"] = Command{Name: "backup", CmdFunc: do_backup, MinimumLevel: LevelAdmin}
+
 	/* act_wiz.go */
 	CommandTable["copyover"] = Command{Name: "copyover", CmdFunc: do_copyover, MinimumLevel: LevelAdmin}
 	CommandTable["exec"] = Command{Name: "exec", CmdFunc: do_exec, MinimumLevel: LevelAdmin}
@@ -100,17 +110,44 @@ func init() {
 	CommandTable["webhook"] = Command{Name: "webhook", CmdFunc: do_webhook, MinimumLevel: LevelAdmin}
 	CommandTable["wiznet"] = Command{Name: "wiznet", CmdFunc: do_wiznet, MinimumLevel: LevelAdmin}
 
+	/* combat_log.go */
+	CommandTable["combatlog"] = Command{Name: "combatlog", CmdFunc: do_combatlog, MinimumLevel: LevelAdmin}
+
 	/* fight.go */
 	CommandTable["flee"] = Command{Name: "flee", CmdFunc: do_flee}
 	CommandTable["kill"] = Command{Name: "kill", CmdFunc: do_kill}
+	CommandTable["rescue"] = Command{Name: "rescue", CmdFunc: do_rescue}
+
+	/* preferences.go */
+	CommandTable["auto"] = Command{Name: "auto", CmdFunc: do_auto}
+	CommandTable["autoassist"] = Command{Name: "autoassist", CmdFunc: do_autoassist}
+	CommandTable["autogold"] = Command{Name: "autogold", CmdFunc: do_autogold}
+	CommandTable["autoloot"] = Command{Name: "autoloot", CmdFunc: do_autoloot}
+	CommandTable["autosplit"] = Command{Name: "autosplit", CmdFunc: do_autosplit}
+	CommandTable["wimpy"] = Command{Name: "wimpy", CmdFunc: do_wimpy}
+
+	/* corpse.go */
+	CommandTable["retrieve"] = Command{Name: "retrieve", CmdFunc: do_retrieve}
+
+	/* pvp.go */
+	CommandTable["pk"] = Command{Name: "pk", CmdFunc: do_pk}
+	CommandTable["pkills"] = Command{Name: "pkills", CmdFunc: do_pkills}
 
 	/* magic.go */
 	CommandTable["cast"] = Command{Name: "cast", CmdFunc: do_cast}
 	CommandTable["spells"] = Command{Name: "spells", CmdFunc: do_spells}
 
+	/* object_audit.go */
+	CommandTable["itemhistory"] = Command{Name: "itemhistory", CmdFunc: do_itemhistory, MinimumLevel: LevelAdmin}
+
 	/* shop.go */
 	CommandTable["buy"] = Command{Name: "buy", CmdFunc: do_buy}
 	CommandTable["shop"] = Command{Name: "shop", CmdFunc: do_shop}
+	CommandTable["sell"] = Command{Name: "sell", CmdFunc: do_sell}
+	CommandTable["value"] = Command{Name: "value", CmdFunc: do_value}
+
+	/* world_check.go */
+	CommandTable["checkworld"] = Command{Name: "checkworld", CmdFunc: do_checkworld, MinimumLevel: LevelBuilder}
 
 	/* scripting.go */
 	CommandTable["reload"] = Command{Name: "reload", CmdFunc: do_reload, MinimumLevel: LevelAdmin}
@@ -206,7 +243,7 @@ func (ch *Character) Interpret(input string) bool {
 	}
 
 	if ch.Client != nil && ch.Client.ConnectionHandler != nil {
-		_, err := (*ch.Client.ConnectionHandler)(ch.Game.vm.ToValue(ch.Client), ch.Game.vm.ToValue(input))
+		_, err := ch.Game.callScriptFunction(ScriptHookConnection, "", *ch.Client.ConnectionHandler, ch.Game.vm.ToValue(ch.Client), ch.Game.vm.ToValue(input))
 		if err != nil {
 			logScriptHandlerError("connection input handler", err)
 			ch.Client.ConnectionHandler = nil
@@ -225,6 +262,18 @@ func (ch *Character) Interpret(input string) bool {
 	command, words := strings.ToLower(words[0]), words[1:]
 	rest := strings.TrimSpace(strings.Join(words, " "))
 
+	/* Room scripts may veto or rewrite commands; a rewritten command is not intercepted again */
+	if ch.Room != nil && len(command) > 0 {
+		replacement, ok := ch.Room.interceptCommand(ch, command, rest)
+		if !ok {
+			return true
+		}
+
+		words = strings.Split(strings.TrimSpace(replacement), " ")
+		command, words = strings.ToLower(words[0]), words[1:]
+		rest = strings.TrimSpace(strings.Join(words, " "))
+	}
+
 	val, ok := CommandTable[command]
 	if !ok || (ok && ch.Level < val.MinimumLevel) {
 		/* Send a no such command if there was any command text */
@@ -249,7 +298,9 @@ func (ch *Character) Interpret(input string) bool {
 				return false
 			}
 
-			_, err := (*ch.Game.skills[prof.SkillId].Handler)(ch.Game.vm.ToValue(prof), ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(rest))
+			skill := ch.Game.skills[prof.SkillId]
+
+			_, err := ch.Game.callScriptFunction(ScriptHookSkill, "skill "+skill.Name, *skill.Handler, ch.Game.vm.ToValue(prof), ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(rest))
 			if err != nil {
 				logScriptHandlerError(fmt.Sprintf("skill %q", ch.Game.skills[prof.SkillId].Name), err)
 				ch.Send("{RAn unseen force prevents that action.{x\r\n")
@@ -268,7 +319,7 @@ func (ch *Character) Interpret(input string) bool {
 
 	/* Call the command func with the remaining command words joined. */
 	if val.Scripted {
-		_, err := val.Callback(ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(rest))
+		_, err := ch.Game.callScriptFunction(ScriptHookCommand, "command "+val.Name, val.Callback, ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(rest))
 		if err != nil {
 			logScriptHandlerError(fmt.Sprintf("command %q", val.Name), err)
 			ch.Send("{RAn unseen force prevents that action.{x\r\n")
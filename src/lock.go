@@ -0,0 +1,235 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* Locks of this difficulty or harder cannot be picked at all */
+const LockUnpickable = 100
+
+/*
+ * A door or closeable container that may be locked.  Containers keep the
+ * object index id of their key in Value2 and the difficulty of picking their
+ * lock in Value3; a key of 0 means there is no keyhole.
+ */
+type Lock struct {
+	Exit   *Exit           `json:"exit"`
+	Object *ObjectInstance `json:"object"`
+}
+
+/* The door in a direction, or a closeable container carried or in the room */
+func (ch *Character) FindLock(argument string) *Lock {
+	if ch.Room == nil || len(argument) < 1 {
+		return nil
+	}
+
+	argument = strings.ToLower(argument)
+
+	if direction, ok := DirectionFromString(argument); ok {
+		exit := ch.Room.getExit(direction)
+		if !exit.Visible(ch) || exit.Flags&EXIT_IS_DOOR == 0 {
+			return nil
+		}
+
+		return &Lock{Exit: exit}
+	}
+
+	obj := ch.FindObjectOnSelf(argument)
+	if obj == nil {
+		obj = ch.FindObjectInRoom(argument)
+	}
+
+	if obj == nil || obj.Flags&ITEM_CLOSEABLE == 0 {
+		return nil
+	}
+
+	return &Lock{Object: obj}
+}
+
+func (lock *Lock) Key() uint {
+	if lock.Exit != nil {
+		return lock.Exit.Key
+	}
+
+	return uint(max(0, lock.Object.Value2))
+}
+
+func (lock *Lock) Difficulty() int {
+	if lock.Exit != nil {
+		return lock.Exit.LockDifficulty
+	}
+
+	return lock.Object.Value3
+}
+
+func (lock *Lock) IsPickable() bool {
+	return lock.Difficulty() < LockUnpickable
+}
+
+func (lock *Lock) IsClosed() bool {
+	if lock.Exit != nil {
+		return lock.Exit.Flags&EXIT_CLOSED != 0
+	}
+
+	return lock.Object.Flags&ITEM_CLOSED != 0
+}
+
+func (lock *Lock) IsLocked() bool {
+	if lock.Exit != nil {
+		return lock.Exit.Flags&EXIT_LOCKED != 0
+	}
+
+	return lock.Object.Flags&ITEM_LOCKED != 0
+}
+
+/* The other side of a door, if it leads anywhere */
+func (lock *Lock) reverseExit() *Exit {
+	if lock.Exit == nil || lock.Exit.To == nil {
+		return nil
+	}
+
+	return lock.Exit.To.Exit[ReverseDirection[lock.Exit.Direction]]
+}
+
+/* Lock or unlock both sides of a door, or a container */
+func (lock *Lock) setLocked(locked bool) {
+	if lock.Exit == nil {
+		if locked {
+			lock.Object.Flags |= ITEM_LOCKED
+		} else {
+			lock.Object.Flags &= ^ITEM_LOCKED
+		}
+
+		return
+	}
+
+	for _, exit := range []*Exit{lock.Exit, lock.reverseExit()} {
+		if exit == nil {
+			continue
+		}
+
+		if locked {
+			exit.Flags |= EXIT_LOCKED
+		} else {
+			exit.Flags &= ^EXIT_LOCKED
+		}
+	}
+}
+
+func (lock *Lock) describe(viewer *Character) string {
+	if lock.Exit != nil {
+		return fmt.Sprintf("the door %s", ExitName[lock.Exit.Direction])
+	}
+
+	return lock.Object.GetShortDescription(viewer)
+}
+
+/* Whether ch carries or wears the object that opens a lock with this key */
+func (ch *Character) HasKey(key uint) bool {
+	if key == 0 || ch.Inventory == nil {
+		return false
+	}
+
+	for obj := range ch.Inventory.All() {
+		if obj.ParentId == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+/* Tell ch, the room, and anyone on the far side of a door that ch has changed a lock */
+func (lock *Lock) announce(ch *Character, verb string, verbOther string) {
+	ch.Send(fmt.Sprintf("{WYou %s %s{W.{x\r\n", verb, lock.describe(ch)))
+
+	for rch := range ch.Room.Characters.All() {
+		if !rch.IsEqual(ch) {
+			rch.Send(fmt.Sprintf("{W%s{W %s %s{W.{x\r\n", ch.GetShortDescriptionUpper(rch), verbOther, lock.describe(rch)))
+		}
+	}
+
+	reverse := lock.reverseExit()
+	if reverse == nil || lock.Exit.To == ch.Room {
+		return
+	}
+
+	for rch := range lock.Exit.To.Characters.All() {
+		rch.Send(fmt.Sprintf("{WYou hear a click from the %s door.{x\r\n", ExitName[reverse.Direction]))
+	}
+}
+
+/* Unlock a lock by picking it; the pick skill decides whether ch succeeds */
+func (ch *Character) PickLock(lock *Lock) {
+	if lock == nil || !lock.IsLocked() {
+		return
+	}
+
+	lock.setLocked(false)
+	lock.announce(ch, "pick the lock on", "picks the lock on")
+}
+
+/* Why ch cannot use a key on a lock, or the empty string if it can */
+func (ch *Character) lockRefusal(lock *Lock, locking bool) string {
+	switch {
+	case lock == nil:
+		return "You see no lock like that here."
+
+	case !lock.IsClosed():
+		return "It isn't closed."
+
+	case locking && lock.IsLocked():
+		return "It's already locked."
+
+	case !locking && !lock.IsLocked():
+		return "It isn't locked."
+
+	case lock.Key() == 0:
+		return "It has no keyhole."
+
+	case !ch.HasKey(lock.Key()) && ch.Level <= LevelHero:
+		return "You lack the key."
+	}
+
+	return ""
+}
+
+func do_lock(ch *Character, arguments string) {
+	if len(arguments) < 1 {
+		ch.Send("Lock what?\r\n")
+		return
+	}
+
+	lock := ch.FindLock(arguments)
+	if refusal := ch.lockRefusal(lock, true); refusal != "" {
+		ch.Send(refusal + "\r\n")
+		return
+	}
+
+	lock.setLocked(true)
+	lock.announce(ch, "lock", "locks")
+}
+
+func do_unlock(ch *Character, arguments string) {
+	if len(arguments) < 1 {
+		ch.Send("Unlock what?\r\n")
+		return
+	}
+
+	lock := ch.FindLock(arguments)
+	if refusal := ch.lockRefusal(lock, false); refusal != "" {
+		ch.Send(refusal + "\r\n")
+		return
+	}
+
+	lock.setLocked(false)
+	lock.announce(ch, "unlock", "unlocks")
+}
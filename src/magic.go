@@ -27,6 +27,7 @@ type CastingContext struct {
 func (ch *Character) onCastingUpdate() {
 	fizzleChance := rand.Intn(100)
 	if ch.Casting.Proficiency < fizzleChance {
+		ch.Game.logCombatSpell(ch, ch.Casting.Casting.Name, "fizzled")
 		ch.Send("\r\n{WYou lose your concentration and your magic spell fizzles out.{x\r\n")
 		if ch.Room != nil {
 			for rch := range ch.Room.Characters.All() {
@@ -51,10 +52,12 @@ func (ch *Character) onCastingUpdate() {
 			}
 		}
 
+		ch.Game.logCombatSpell(ch, ch.Casting.Casting.Name, "cast")
+
 		if ch.Casting.Casting.Handler != nil {
 			fn := *ch.Casting.Casting.Handler
 
-			_, err := fn(ch.Game.vm.ToValue(ch.Casting), ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(ch.Casting.Arguments))
+			_, err := ch.Game.callScriptFunction(ScriptHookSpell, "spell "+ch.Casting.Casting.Name, fn, ch.Game.vm.ToValue(ch.Casting), ch.Game.vm.ToValue(ch), ch.Game.vm.ToValue(ch.Casting.Arguments))
 			if err != nil {
 				logScriptHandlerError(fmt.Sprintf("spell %q", ch.Casting.Casting.Name), err)
 				ch.Send("{RThe magic twists strangely and fades away.{x\r\n")
@@ -72,6 +75,7 @@ func (game *Game) RegisterSpellHandler(name string, fn goja.Callable) goja.Value
 	}
 
 	spell.Handler = &fn
+	spell.handlerModule = game.currentScriptModule()
 	return game.vm.ToValue(spell)
 }
 
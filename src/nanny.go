@@ -9,8 +9,6 @@ package main
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"log"
 	"strings"
@@ -186,22 +184,17 @@ func (game *Game) nanny(client *Client, message string) {
 	case ConnectionStateNewPassword:
 		client.ConnectionState = ConnectionStateConfirmPassword
 
-		sha256Sum := sha256.Sum256([]byte(message + Config.HashSalt))
-		saltedHash := hex.EncodeToString(sha256Sum[:])
-		ciphertext, err := bcrypt.GenerateFromPassword([]byte(saltedHash), 10)
+		ciphertext, err := hashPassword(message)
 		if err != nil {
 			log.Println("Failed to bcrypt user password: ", err)
 			return
 		}
 
-		client.Character.temporaryHash = string(ciphertext)
+		client.Character.temporaryHash = ciphertext
 		output.WriteString("Please confirm your password: ")
 
 	case ConnectionStateConfirmPassword:
-		sha256Sum := sha256.Sum256([]byte(message + Config.HashSalt))
-		saltedHash := hex.EncodeToString(sha256Sum[:])
-
-		if bcrypt.CompareHashAndPassword([]byte(client.Character.temporaryHash), []byte(saltedHash)) != nil {
+		if bcrypt.CompareHashAndPassword([]byte(client.Character.temporaryHash), []byte(saltPassword(message))) != nil {
 			client.ConnectionState = ConnectionStateNewPassword
 			output.WriteString("Passwords didn't match.\r\nPlease choose a password: ")
 			break
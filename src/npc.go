@@ -36,7 +36,10 @@ func (game *Game) LoadMobileIndex(index uint) (*Character, error) {
 			stat_wis,
 			stat_con,
 			stat_cha,
-			stat_lck
+			stat_lck,
+			resist_flags,
+			immune_flags,
+			suscept_flags
 		FROM
 			mobiles
 		WHERE
@@ -74,7 +77,10 @@ func (game *Game) LoadMobileIndex(index uint) (*Character, error) {
 		&ch.Stats[STAT_WISDOM],
 		&ch.Stats[STAT_CONSTITUTION],
 		&ch.Stats[STAT_CHARISMA],
-		&ch.Stats[STAT_LUCK])
+		&ch.Stats[STAT_LUCK],
+		&ch.Resist,
+		&ch.Immune,
+		&ch.Suscept)
 	if err != nil {
 		return nil, err
 	}
@@ -18,21 +18,21 @@ import (
 )
 
 type Object struct {
-	Id       uint
-	ItemType string
+	Id       uint   `json:"id"`
+	ItemType string `json:"itemType"`
 
-	Name             string
-	ShortDescription string
-	LongDescription  string
-	Description      string
-	Flags            int
+	Name             string `json:"name"`
+	ShortDescription string `json:"shortDescription"`
+	LongDescription  string `json:"longDescription"`
+	Description      string `json:"description"`
+	Flags            int    `json:"flags"`
 
-	Value0 int
-	Value1 int
-	Value2 int
-	Value3 int
-	Weight float64
-	Ttl    int
+	Value0 int     `json:"value0"`
+	Value1 int     `json:"value1"`
+	Value2 int     `json:"value2"`
+	Value3 int     `json:"value3"`
+	Weight float64 `json:"weight"`
+	Ttl    int     `json:"ttl"`
 }
 
 type ObjectInstance struct {
@@ -44,6 +44,7 @@ type ObjectInstance struct {
 
 	Id       uint   `json:"id"`
 	ParentId uint   `json:"parentId"`
+	Serial   string `json:"serial"`
 	ItemType string `json:"itemType"`
 
 	Name             string `json:"name"`
@@ -62,6 +63,9 @@ type ObjectInstance struct {
 
 	CreatedAt time.Time `json:"createdAt"`
 	Ttl       int       `json:"ttl"`
+
+	/* Set on a fallen player's corpse */
+	Corpse *PlayerCorpse `json:"corpse"`
 }
 
 const (
@@ -81,6 +85,8 @@ const (
 	ItemTypeReagent        = "reagent"
 	ItemTypeArtifact       = "artifact"
 	ItemTypeCurrency       = "currency"
+	ItemTypeBoat           = "boat"
+	ItemTypeKey            = "key"
 )
 
 const (
@@ -256,6 +262,7 @@ func (game *Game) objectInstanceFromIndex(obj *Object) *ObjectInstance {
 	objectInstance := &ObjectInstance{
 		Game:             game,
 		ParentId:         obj.Id,
+		Serial:           newObjectSerial(),
 		Contents:         NewLinkedList[*ObjectInstance](),
 		Description:      obj.Description,
 		ShortDescription: obj.ShortDescription,
@@ -288,7 +295,10 @@ func (game *Game) NewObjectInstance(objectIndex uint) *ObjectInstance {
 		return nil
 	}
 
-	return game.objectInstanceFromIndex(obj)
+	objectInstance := game.objectInstanceFromIndex(obj)
+	game.AuditObject(objectInstance, ObjectAuditCreated, "script", "")
+
+	return objectInstance
 }
 
 func (game *Game) CreateGold(amount int) *ObjectInstance {
@@ -622,6 +632,7 @@ func (obj *ObjectInstance) reifyInContainerTx(ctx context.Context, tx *sql.Tx, c
 
 	if obj.Id == 0 {
 		obj.ensureDecayState(time.Now())
+		obj.ensureSerial()
 
 		var insideObjectInstanceId *uint = nil
 		if container != nil {
@@ -630,10 +641,10 @@ func (obj *ObjectInstance) reifyInContainerTx(ctx context.Context, tx *sql.Tx, c
 
 		result, err := tx.ExecContext(ctx, `
 			INSERT INTO
-				object_instances(parent_id, inside_object_instance_id, name, short_description, long_description, description, flags, item_type, value_1, value_2, value_3, value_4, weight, ttl, created_at)
+				object_instances(parent_id, serial, inside_object_instance_id, name, short_description, long_description, description, flags, item_type, value_1, value_2, value_3, value_4, weight, ttl, created_at)
 			VALUES
-				(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`, obj.ParentId, insideObjectInstanceId, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.Flags, obj.ItemType, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt)
+				(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, obj.ParentId, obj.Serial, insideObjectInstanceId, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.Flags, obj.ItemType, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt)
 		if err != nil {
 			log.Printf("Failed to finalize new object: %v.\r\n", err)
 			return reified, err
@@ -776,6 +787,7 @@ func (obj *ObjectInstance) Finalize(container *ObjectInstance) error {
 	}
 
 	obj.ensureDecayState(time.Now())
+	obj.ensureSerial()
 
 	var insideObjectInstanceId *uint = nil
 
@@ -785,10 +797,10 @@ func (obj *ObjectInstance) Finalize(container *ObjectInstance) error {
 
 	result, err := obj.Game.db.Exec(`
 		INSERT INTO
-			object_instances(parent_id, inside_object_instance_id, name, short_description, long_description, description, flags, item_type, value_1, value_2, value_3, value_4, weight, ttl, created_at)
+			object_instances(parent_id, serial, inside_object_instance_id, name, short_description, long_description, description, flags, item_type, value_1, value_2, value_3, value_4, weight, ttl, created_at)
 		VALUES
-			(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, obj.ParentId, insideObjectInstanceId, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.Flags, obj.ItemType, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt)
+			(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, obj.ParentId, obj.Serial, insideObjectInstanceId, obj.Name, obj.ShortDescription, obj.LongDescription, obj.Description, obj.Flags, obj.ItemType, obj.Value0, obj.Value1, obj.Value2, obj.Value3, obj.GetWeight(), obj.Ttl, obj.CreatedAt)
 	if err != nil {
 		log.Printf("Failed to finalize new object: %v.\r\n", err)
 		return err
@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	ObjectAuditCreated  = "created"
+	ObjectAuditGiven    = "given"
+	ObjectAuditDropped  = "dropped"
+	ObjectAuditTaken    = "taken"
+	ObjectAuditSold     = "sold"
+	ObjectAuditConsumed = "consumed"
+	ObjectAuditDecayed  = "decayed"
+	ObjectAuditPurged   = "purged"
+)
+
+const objectSerialLength = 8
+
+type ObjectAuditEntry struct {
+	Id        int
+	Serial    string
+	ObjectId  uint
+	Event     string
+	FromOwner string
+	ToOwner   string
+	CreatedAt time.Time
+}
+
+/* Serials follow an instance for its whole life, across any number of object_instances rows */
+func newObjectSerial() string {
+	serialBytes := make([]byte, objectSerialLength)
+
+	_, err := rand.Read(serialBytes)
+	if err != nil {
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(serialBytes)
+}
+
+func (obj *ObjectInstance) ensureSerial() {
+	if obj != nil && obj.Serial == "" {
+		obj.Serial = newObjectSerial()
+	}
+}
+
+func auditCharacter(ch *Character) string {
+	if ch == nil {
+		return ""
+	}
+
+	if ch.Flags&CHAR_IS_PLAYER != 0 {
+		return fmt.Sprintf("player %s", ch.Name)
+	}
+
+	return fmt.Sprintf("mobile %d", ch.Id)
+}
+
+func auditRoom(room *Room) string {
+	if room == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("room %d", room.Id)
+}
+
+func auditContainer(container *ObjectInstance) string {
+	if container == nil {
+		return ""
+	}
+
+	if container.Serial == "" {
+		return fmt.Sprintf("object %d", container.ParentId)
+	}
+
+	return fmt.Sprintf("object %s", container.Serial)
+}
+
+func (location objectLocation) auditOwner() string {
+	switch {
+	case location.carrier != nil:
+		return auditCharacter(location.carrier)
+	case location.container != nil:
+		return auditContainer(location.container)
+	default:
+		return auditRoom(location.room)
+	}
+}
+
+/* Record an ownership transition for an object and everything inside of it */
+func (game *Game) AuditObject(obj *ObjectInstance, event string, from string, to string) {
+	if game == nil || game.db == nil || obj == nil {
+		return
+	}
+
+	if obj.Serial != "" {
+		_, err := game.db.Exec(`
+			INSERT INTO
+				object_audit(serial, object_id, event, from_owner, to_owner)
+			VALUES
+				(?, ?, ?, ?, ?)
+		`, obj.Serial, obj.ParentId, event, from, to)
+		if err != nil {
+			log.Printf("Failed to audit object %s: %v.\r\n", obj.Serial, err)
+		}
+	}
+
+	if obj.Contents != nil {
+		for containedObj := range obj.Contents.All() {
+			game.AuditObject(containedObj, event, from, to)
+		}
+	}
+}
+
+func (game *Game) LoadObjectAudit(serial string) ([]*ObjectAuditEntry, error) {
+	rows, err := game.db.Query(`
+		SELECT
+			id,
+			serial,
+			object_id,
+			event,
+			from_owner,
+			to_owner,
+			CAST(strftime('%s', created_at) AS INTEGER)
+		FROM
+			object_audit
+		WHERE
+			serial = ?
+		ORDER BY
+			id
+	`, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]*ObjectAuditEntry, 0)
+
+	for rows.Next() {
+		entry := &ObjectAuditEntry{}
+
+		var objectId sql.NullInt64
+		var from, to sql.NullString
+		var createdAt sql.NullInt64
+
+		err := rows.Scan(&entry.Id, &entry.Serial, &objectId, &entry.Event, &from, &to, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.ObjectId = uint(objectId.Int64)
+		entry.FromOwner = from.String
+		entry.ToOwner = to.String
+		entry.CreatedAt = objectCreatedAtFromUnix(createdAt)
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+/* Report any serial persisted more than once; a legitimate object only ever has one row */
+func checkDuplicateObjectSerials(db *sql.DB) ([]WorldIssue, error) {
+	rows, err := db.Query(`
+		SELECT
+			object_instances.serial,
+			COUNT(DISTINCT object_instances.id),
+			GROUP_CONCAT(DISTINCT player_characters.username)
+		FROM
+			object_instances
+		INNER JOIN
+			player_character_object
+		ON
+			player_character_object.object_instance_id = object_instances.id
+		INNER JOIN
+			player_characters
+		ON
+			player_characters.id = player_character_object.player_character_id
+		WHERE
+			object_instances.serial IS NOT NULL
+		GROUP BY
+			object_instances.serial
+		HAVING
+			COUNT(DISTINCT object_instances.id) > 1
+		ORDER BY
+			object_instances.serial
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	issues := make([]WorldIssue, 0)
+
+	for rows.Next() {
+		var serial string
+		var count int
+		var owners string
+
+		err := rows.Scan(&serial, &count, &owners)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, WorldIssue{
+			Kind:    WorldIssueObject,
+			Message: fmt.Sprintf("object serial %s is held %d times by %s", serial, count, strings.ReplaceAll(owners, ",", ", ")),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+func do_itemhistory(ch *Character, arguments string) {
+	argument, _ := OneArgument(arguments)
+	if argument == "" {
+		ch.Send("Usage: itemhistory <serial or object>\r\n")
+		return
+	}
+
+	serial := strings.ToLower(argument)
+
+	obj := ch.FindObjectOnSelf(argument)
+	if obj == nil {
+		obj = ch.FindObjectInRoom(argument)
+	}
+
+	if obj != nil {
+		if obj.Serial == "" {
+			ch.Send(fmt.Sprintf("%s{x has no serial.\r\n", obj.GetShortDescriptionUpper(ch)))
+			return
+		}
+
+		serial = obj.Serial
+	}
+
+	entries, err := ch.Game.LoadObjectAudit(serial)
+	if err != nil {
+		ch.Send(fmt.Sprintf("{RUnable to load item history: %v{x\r\n", err))
+		return
+	}
+
+	if len(entries) == 0 {
+		ch.Send(fmt.Sprintf("No history recorded for serial %s.\r\n", serial))
+		return
+	}
+
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("{WHistory of serial {Y%s{W (object %d):{x\r\n", serial, entries[0].ObjectId))
+	for _, entry := range entries {
+		output.WriteString(fmt.Sprintf("{w%s {G%-8s {w%s -> %s{x\r\n",
+			entry.CreatedAt.Format("2006-01-02 15:04:05"),
+			entry.Event,
+			auditOwnerOrNothing(entry.FromOwner),
+			auditOwnerOrNothing(entry.ToOwner)))
+	}
+
+	ch.Send(output.String())
+}
+
+func auditOwnerOrNothing(owner string) string {
+	if owner == "" {
+		return "nowhere"
+	}
+
+	return owner
+}
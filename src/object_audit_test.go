@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDuplicateObjectSerials(t *testing.T) {
+	db, err := sql.Open(databaseDriverSQLite, filepath.Join(t.TempDir(), "serials.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE player_characters (id INTEGER PRIMARY KEY, username TEXT)`,
+		`CREATE TABLE object_instances (id INTEGER PRIMARY KEY, serial TEXT)`,
+		`CREATE TABLE player_character_object (id INTEGER PRIMARY KEY, player_character_id BIGINT, object_instance_id BIGINT)`,
+		`INSERT INTO player_characters (id, username) VALUES (1, 'alice'), (2, 'bob')`,
+		`INSERT INTO object_instances (id, serial) VALUES (1, 'aaaa'), (2, 'aaaa'), (3, 'bbbb'), (4, NULL), (5, NULL)`,
+		`INSERT INTO player_character_object (player_character_id, object_instance_id) VALUES (1, 1), (2, 2), (1, 3), (1, 4), (2, 5)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err := checkDuplicateObjectSerials(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("len(issues) = %d, want 1: %v", len(issues), issues)
+	}
+
+	if issues[0].Kind != WorldIssueObject || !strings.Contains(issues[0].Message, "aaaa") {
+		t.Fatalf("unexpected issue %+v", issues[0])
+	}
+
+	if !strings.Contains(issues[0].Message, "alice") || !strings.Contains(issues[0].Message, "bob") {
+		t.Fatalf("issue does not name both owners: %s", issues[0].Message)
+	}
+}
@@ -122,7 +122,10 @@ func (ch *Character) Sync() error {
 			stat_wis = ?,
 			stat_con = ?,
 			stat_cha = ?,
-			stat_lck = ?
+			stat_lck = ?,
+			resist_flags = ?,
+			immune_flags = ?,
+			suscept_flags = ?
 		WHERE
 			id = ?
 	`,
@@ -149,6 +152,9 @@ func (ch *Character) Sync() error {
 		ch.Stats[STAT_CONSTITUTION],
 		ch.Stats[STAT_CHARISMA],
 		ch.Stats[STAT_LUCK],
+		ch.Resist,
+		ch.Immune,
+		ch.Suscept,
 		ch.Id)
 	if err != nil {
 		return err
@@ -157,6 +163,25 @@ func (ch *Character) Sync() error {
 	return nil
 }
 
+func (game *Game) SaveRace(race *Race) error {
+	if race == nil {
+		return errors.New("no race to save")
+	}
+
+	_, err := game.db.Exec(`
+		UPDATE
+			races
+		SET
+			resist_flags = ?,
+			immune_flags = ?,
+			suscept_flags = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = ?
+	`, race.Resist, race.Immune, race.Suscept, race.Id)
+	return err
+}
+
 func (reset *Reset) Delete() error {
 	_, err := reset.Zone.Game.db.Exec(`
 		DELETE FROM
@@ -196,10 +221,10 @@ func (exit *Exit) Finalize() error {
 
 	result, err := exit.Room.Game.db.Exec(`
 		INSERT INTO
-			exits(room_id, to_room_id, direction, flags)
+			exits(room_id, to_room_id, direction, flags, key_id, lock_difficulty)
 		VALUES
-			(?, ?, ?, ?)
-	`, exit.Room.Id, exit.To.Id, exit.Direction, exit.Flags)
+			(?, ?, ?, ?, ?, ?)
+	`, exit.Room.Id, exit.To.Id, exit.Direction, exit.Flags, exit.Key, exit.LockDifficulty)
 	if err != nil {
 		log.Printf("Failed to finalize exit: %v.\r\n", err)
 		return err
@@ -224,10 +249,12 @@ func (exit *Exit) Save() error {
 		UPDATE
 			exits
 		SET
-			flags = ?
+			flags = ?,
+			key_id = ?,
+			lock_difficulty = ?
 		WHERE
 			id = ?
-	`, exit.Flags, exit.Id)
+	`, exit.Flags, exit.Key, exit.LockDifficulty, exit.Id)
 	if err != nil {
 		return err
 	}
@@ -42,9 +42,19 @@ type District struct {
 	Id                 int            `json:"id"`
 	Plane              *Plane         `json:"plane"`
 	Rect               *Rect          `json:"rect"`
+	Flags              int            `json:"flags"`
 	TerrainNameMapping map[int]string `json:"terrainNameMapping"`
 }
 
+/* District flag types */
+const (
+	DISTRICT_PK = 1
+)
+
+var DistrictFlagTable []Flag = []Flag{
+	{Name: "pk", Flag: DISTRICT_PK},
+}
+
 type PlaneObserver struct {
 	Plane *Plane `json:"plane"`
 	Rect  *Rect  `json:"rect"`
@@ -763,7 +773,8 @@ func (game *Game) LoadDistricts() error {
 			y,
 			z,
 			width,
-			height
+			height,
+			flags
 		FROM
 			districts
 	`)
@@ -785,7 +796,7 @@ func (game *Game) LoadDistricts() error {
 			TerrainNameMapping: make(map[int]string),
 		}
 
-		err := rows.Scan(&district.Id, &planeId, &district.Rect.X, &district.Rect.Y, &z, &district.Rect.W, &district.Rect.H)
+		err := rows.Scan(&district.Id, &planeId, &district.Rect.X, &district.Rect.Y, &z, &district.Rect.W, &district.Rect.H, &district.Flags)
 		if err != nil {
 			return err
 		}
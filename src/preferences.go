@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	PREF_AUTOLOOT   = 1
+	PREF_AUTOGOLD   = 1 << 1
+	PREF_AUTOSPLIT  = 1 << 2
+	PREF_AUTOASSIST = 1 << 3
+)
+
+/* Players assist their group unless they opt out; matches the column default */
+const DefaultPreferences = PREF_AUTOASSIST
+
+var PreferenceFlagTable []Flag = []Flag{
+	{Name: "autoloot", Flag: PREF_AUTOLOOT},
+	{Name: "autogold", Flag: PREF_AUTOGOLD},
+	{Name: "autosplit", Flag: PREF_AUTOSPLIT},
+	{Name: "autoassist", Flag: PREF_AUTOASSIST},
+}
+
+var preferenceDescriptions map[int]string = map[int]string{
+	PREF_AUTOLOOT:   "take everything from the corpses of your kills",
+	PREF_AUTOGOLD:   "take the gold from the corpses of your kills",
+	PREF_AUTOSPLIT:  "share gold you loot with your group",
+	PREF_AUTOASSIST: "join fights your group members are in",
+}
+
+func PreferenceFlagNames(flags int) string {
+	return FlagNames(flags, PreferenceFlagTable)
+}
+
+/* Whether ch, a player, has turned on pref; mobiles have no preferences */
+func (ch *Character) HasPreference(pref int) bool {
+	return ch != nil && ch.Flags&CHAR_IS_PLAYER != 0 && ch.Preferences&pref != 0
+}
+
+func (ch *Character) togglePreference(pref int) {
+	var name string
+
+	for _, flag := range PreferenceFlagTable {
+		if flag.Flag == pref {
+			name = flag.Name
+		}
+	}
+
+	ch.Preferences ^= pref
+
+	if ch.Preferences&pref != 0 {
+		ch.Send(fmt.Sprintf("{GYou will now %s.{x\r\n", preferenceDescriptions[pref]))
+		return
+	}
+
+	ch.Send(fmt.Sprintf("{G%s is now off.{x\r\n", strings.ToUpper(name[:1])+name[1:]))
+}
+
+func do_auto(ch *Character, arguments string) {
+	var output strings.Builder
+
+	output.WriteString("{WYour automatic actions:{x\r\n")
+
+	for _, flag := range PreferenceFlagTable {
+		state := "{Doff"
+		if ch.Preferences&flag.Flag != 0 {
+			state = "{Gon "
+		}
+
+		output.WriteString(fmt.Sprintf("{C%-12s %s{x  {c%s{x\r\n", flag.Name, state, preferenceDescriptions[flag.Flag]))
+	}
+
+	if ch.Wimpy > 0 {
+		output.WriteString(fmt.Sprintf("{CYou will flee when your health drops below %d.{x\r\n", ch.Wimpy))
+	} else {
+		output.WriteString("{CYou will fight to the death.{x\r\n")
+	}
+
+	ch.Send(output.String())
+}
+
+func do_autoloot(ch *Character, arguments string) {
+	ch.togglePreference(PREF_AUTOLOOT)
+}
+
+func do_autogold(ch *Character, arguments string) {
+	ch.togglePreference(PREF_AUTOGOLD)
+}
+
+func do_autosplit(ch *Character, arguments string) {
+	ch.togglePreference(PREF_AUTOSPLIT)
+}
+
+func do_autoassist(ch *Character, arguments string) {
+	ch.togglePreference(PREF_AUTOASSIST)
+}
+
+/* wimpy with no argument flees at a fifth of maximum health; wimpy 0 turns it off */
+func do_wimpy(ch *Character, arguments string) {
+	wimpy := ch.MaxHealth / 5
+
+	if arguments != "" {
+		value, err := strconv.Atoi(strings.TrimSpace(arguments))
+		if err != nil || value < 0 {
+			ch.Send("Your wimpy must be a number of health points.\r\n")
+			return
+		}
+
+		wimpy = value
+	}
+
+	if wimpy > ch.MaxHealth/2 {
+		ch.Send(fmt.Sprintf("Such cowardice ill becomes you; your wimpy can be at most %d.\r\n", ch.MaxHealth/2))
+		return
+	}
+
+	ch.Wimpy = wimpy
+
+	if wimpy == 0 {
+		ch.Send("{GYou will now fight to the death.{x\r\n")
+		return
+	}
+
+	ch.Send(fmt.Sprintf("{GYou will now flee when your health drops below %d.{x\r\n", wimpy))
+}
+
+/* A player hurt below their wimpy tries to flee, returning whether they got away */
+func (game *Game) checkWimpy(ch *Character) bool {
+	if ch.Flags&CHAR_IS_PLAYER == 0 || ch.Wimpy <= 0 || ch.Fighting == nil || ch.Room == nil {
+		return false
+	}
+
+	if ch.Health <= 0 || ch.Health >= ch.Wimpy {
+		return false
+	}
+
+	room := ch.Room
+	do_flee(ch, "")
+
+	return ch.Room != room
+}
+
+/* Honour the killer's autogold and autoloot on a fresh corpse, then autosplit what gold it gained */
+func (game *Game) autoLootCorpse(ch *Character, corpse *ObjectInstance) {
+	if ch == nil || corpse == nil || corpse.Contents == nil || ch.Room == nil || ch.Room != corpse.InRoom {
+		return
+	}
+
+	if !ch.HasPreference(PREF_AUTOLOOT) && !ch.HasPreference(PREF_AUTOGOLD) {
+		return
+	}
+
+	gold := ch.Gold
+
+	for _, obj := range corpse.Contents.Values() {
+		if obj.Flags&ITEM_TAKE == 0 {
+			continue
+		}
+
+		if obj.ItemType != ItemTypeCurrency && !ch.HasPreference(PREF_AUTOLOOT) {
+			continue
+		}
+
+		if !ch.takeFromContainer(obj, corpse) {
+			break
+		}
+	}
+
+	if ch.HasPreference(PREF_AUTOSPLIT) && ch.Gold > gold {
+		ch.splitGold(ch.Gold - gold)
+	}
+}
+
+/* Share amount of ch's gold evenly with group members in the room, ch keeping any remainder */
+func (ch *Character) splitGold(amount int) {
+	if ch.Group == nil || ch.Room == nil || amount <= 0 {
+		return
+	}
+
+	members := make([]*Character, 0, ch.Group.Count)
+	for gch := range ch.Group.All() {
+		if gch != ch && gch.Room == ch.Room {
+			members = append(members, gch)
+		}
+	}
+
+	share := amount / (len(members) + 1)
+	if len(members) == 0 || share == 0 {
+		return
+	}
+
+	for _, gch := range members {
+		ch.Gold -= share
+		gch.Gold += share
+		gch.Send(fmt.Sprintf("{Y%s{Y splits %d gold coins; your share is %d.{x\r\n", ch.GetShortDescriptionUpper(gch), amount, share))
+	}
+
+	ch.Send(fmt.Sprintf("{YYou split %d gold coins, keeping %d.{x\r\n", amount, amount-share*len(members)))
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitGoldSharesWithGroupInRoom(t *testing.T) {
+	room := &Room{Characters: NewLinkedList[*Character]()}
+	elsewhere := &Room{Characters: NewLinkedList[*Character]()}
+
+	leader, follower, straggler := NewCharacter(), NewCharacter(), NewCharacter()
+	group := NewLinkedList[*Character]()
+
+	for _, ch := range []*Character{leader, follower, straggler} {
+		ch.Group = group
+		group.Insert(ch)
+	}
+
+	room.AddCharacter(leader)
+	room.AddCharacter(follower)
+	elsewhere.AddCharacter(straggler)
+
+	leader.Gold = 25
+	leader.splitGold(25)
+
+	if leader.Gold != 13 || follower.Gold != 12 || straggler.Gold != 0 {
+		t.Errorf("split 25 gold as %d/%d/%d, want 13/12/0", leader.Gold, follower.Gold, straggler.Gold)
+	}
+}
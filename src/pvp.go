@@ -0,0 +1,356 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	/* Players must be this experienced to consent to player killing */
+	PkMinimumLevel = 10
+
+	/* Outside of PK rooms, starting a fight with a player more than this many levels below you makes you a killer */
+	PkLevelRange = 8
+
+	/* Seconds of play between toggling consent, and after any fight with another player */
+	PkToggleCooldown = 30 * 60
+	PkCombatCooldown = 10 * 60
+
+	/* Seconds of play the KILLER and THIEF flags last before they wear off */
+	PkKillerDuration = 30 * 60
+	PkThiefDuration  = 20 * 60
+)
+
+/* Rooms flagged pk, or lying in a pk district, are arenas: anyone may fight anyone without consequence */
+func (room *Room) IsPK() bool {
+	if room == nil {
+		return false
+	}
+
+	if room.Flags&ROOM_PK != 0 {
+		return true
+	}
+
+	layer, ok := room.planarLayer()
+	if !ok || layer.Districts == nil {
+		return false
+	}
+
+	district := layer.FindDistrict(room.X, room.Y)
+	return district != nil && district.Flags&DISTRICT_PK != 0
+}
+
+/* Why ch may not attack victim, another player, or the empty string if it may */
+func (ch *Character) pvpRefusal(victim *Character) string {
+	if ch == nil || victim == nil || ch == victim {
+		return ""
+	}
+
+	if ch.Flags&CHAR_IS_PLAYER == 0 || victim.Flags&CHAR_IS_PLAYER == 0 {
+		return ""
+	}
+
+	if ch.Room != nil && ch.Room.Flags&ROOM_SAFE != 0 {
+		return "You cannot do that here."
+	}
+
+	if ch.Room != nil && ch.Room.IsPK() {
+		return ""
+	}
+
+	if !ch.PkConsent {
+		return "You have not chosen to fight other players; see 'pk'."
+	}
+
+	/* Anyone who has chosen to fight other players may hunt down killers and thieves */
+	if victim.Killer > 0 || victim.Thief > 0 {
+		return ""
+	}
+
+	if !victim.PkConsent {
+		return fmt.Sprintf("%s has not chosen to fight other players.", victim.Name)
+	}
+
+	return ""
+}
+
+/* Whether ch may attack victim; mobiles are always fair game */
+func (ch *Character) MayAttack(victim *Character) bool {
+	return ch.pvpRefusal(victim) == ""
+}
+
+/* As MayAttack, telling ch why not */
+func (ch *Character) CanAttack(victim *Character) bool {
+	refusal := ch.pvpRefusal(victim)
+	if refusal != "" {
+		ch.Send(fmt.Sprintf("{W%s{x\r\n", refusal))
+		return false
+	}
+
+	return true
+}
+
+/*
+ * Account for ch attacking or hurting victim when both are players: neither
+ * may withdraw consent for a while, and outside an arena, starting a fight
+ * with a player more than PkLevelRange levels below ch, who is not a killer
+ * or thief, marks ch as a killer.  Hitting back at someone who is already
+ * fighting ch is self-defence, not the start of a fight.
+ */
+func (game *Game) notePvPAttack(ch *Character, victim *Character) {
+	if ch == nil || victim == nil || ch == victim || ch.Flags&CHAR_IS_PLAYER == 0 || victim.Flags&CHAR_IS_PLAYER == 0 {
+		return
+	}
+
+	ch.PkCooldown = max(ch.PkCooldown, PkCombatCooldown)
+	victim.PkCooldown = max(victim.PkCooldown, PkCombatCooldown)
+
+	if ch.Room != nil && ch.Room.IsPK() {
+		return
+	}
+
+	if victim.Killer > 0 || victim.Thief > 0 || victim.Fighting == ch {
+		return
+	}
+
+	if int(ch.Level)-int(victim.Level) <= PkLevelRange {
+		return
+	}
+
+	if ch.Killer <= 0 {
+		ch.Send("{R*** You are now a KILLER! ***{x\r\n")
+	}
+
+	ch.Killer = PkKillerDuration
+}
+
+/* Mark ch a thief for trying to rob another player */
+func (ch *Character) FlagThief() {
+	if ch.Flags&CHAR_IS_PLAYER == 0 || (ch.Room != nil && ch.Room.IsPK()) {
+		return
+	}
+
+	if ch.Thief <= 0 {
+		ch.Send("{R*** You are now a THIEF! ***{x\r\n")
+	}
+
+	ch.Thief = PkThiefDuration
+}
+
+/* Count down consent cooldowns and wear off KILLER and THIEF flags */
+func (game *Game) pvpUpdate(elapsed int) {
+	for ch := range game.Characters.All() {
+		if ch.Flags&CHAR_IS_PLAYER == 0 {
+			continue
+		}
+
+		ch.PkCooldown = max(0, ch.PkCooldown-elapsed)
+
+		if ch.Killer > 0 {
+			ch.Killer = max(0, ch.Killer-elapsed)
+			if ch.Killer == 0 {
+				ch.Send("{GYou are no longer a KILLER.{x\r\n")
+			}
+		}
+
+		if ch.Thief > 0 {
+			ch.Thief = max(0, ch.Thief-elapsed)
+			if ch.Thief == 0 {
+				ch.Send("{GYou are no longer a THIEF.{x\r\n")
+			}
+		}
+	}
+}
+
+/* Tell the immortals about a player's death at another's hands and keep it for the leaderboard */
+func (game *Game) recordPlayerKill(killer *Character, victim *Character, room *Room) {
+	arena := room != nil && room.IsPK()
+
+	var roomId uint
+	if room != nil {
+		roomId = room.Id
+	}
+
+	where := ""
+	if arena {
+		where = " in an arena"
+	}
+
+	out := fmt.Sprintf("{RPK: %s (level %d) has slain %s (level %d) in room %d%s.{x\r\n", killer.Name, killer.Level, victim.Name, victim.Level, roomId, where)
+	log.Print(stripColourCodes(out))
+	game.broadcast(out, WiznetBroadcastFilter)
+
+	if game.db == nil || killer.Id <= 0 || victim.Id <= 0 {
+		return
+	}
+
+	_, err := game.db.Exec(`
+		INSERT INTO
+			pkills(killer_id, victim_id, killer_level, victim_level, room_id, arena)
+		VALUES
+			(?, ?, ?, ?, ?, ?)
+	`, killer.Id, victim.Id, killer.Level, victim.Level, roomId, arena)
+	if err != nil {
+		log.Printf("Failed to record player kill: %v.\r\n", err)
+	}
+}
+
+func pkMinutes(seconds int) string {
+	minutes := (seconds + 59) / 60
+	if minutes == 1 {
+		return "1 minute"
+	}
+
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+/* pk on|off to consent to fighting other players; with no argument, show where ch stands */
+func do_pk(ch *Character, arguments string) {
+	if ch.Flags&CHAR_IS_PLAYER == 0 {
+		return
+	}
+
+	switch strings.ToLower(strings.TrimSpace(arguments)) {
+	case "":
+		var output strings.Builder
+
+		if ch.PkConsent {
+			output.WriteString("{RYou have chosen to fight other players.{x\r\n")
+		} else {
+			output.WriteString("{GYou have not chosen to fight other players.{x\r\n")
+		}
+
+		if ch.PkCooldown > 0 {
+			output.WriteString(fmt.Sprintf("{WYou can change your mind in %s.{x\r\n", pkMinutes(ch.PkCooldown)))
+		}
+
+		if ch.Killer > 0 {
+			output.WriteString(fmt.Sprintf("{RYou are a KILLER for another %s.{x\r\n", pkMinutes(ch.Killer)))
+		}
+
+		if ch.Thief > 0 {
+			output.WriteString(fmt.Sprintf("{RYou are a THIEF for another %s.{x\r\n", pkMinutes(ch.Thief)))
+		}
+
+		ch.Send(output.String())
+		return
+
+	case "on":
+		if ch.PkConsent {
+			ch.Send("You have already chosen to fight other players.\r\n")
+			return
+		}
+
+		if ch.Level < PkMinimumLevel {
+			ch.Send(fmt.Sprintf("You must be level %d to fight other players.\r\n", PkMinimumLevel))
+			return
+		}
+
+	case "off":
+		if !ch.PkConsent {
+			ch.Send("You have not chosen to fight other players.\r\n")
+			return
+		}
+
+		if ch.Fighting != nil {
+			ch.Send("Not while you are fighting!\r\n")
+			return
+		}
+
+	default:
+		ch.Send("Usage: pk [on|off]\r\n")
+		return
+	}
+
+	if ch.PkCooldown > 0 {
+		ch.Send(fmt.Sprintf("You must wait %s before changing your mind.\r\n", pkMinutes(ch.PkCooldown)))
+		return
+	}
+
+	ch.PkConsent = !ch.PkConsent
+	ch.PkCooldown = PkToggleCooldown
+
+	if ch.PkConsent {
+		ch.Send(fmt.Sprintf("{RYou may now attack, and be attacked by, other players; picking on one more than %d levels below you makes you a KILLER.{x\r\n", PkLevelRange))
+	} else {
+		ch.Send("{GYou no longer fight other players.{x\r\n")
+	}
+
+	ch.Game.broadcast(fmt.Sprintf("{WPK: %s has turned player killing %s.{x\r\n", ch.Name, strings.ToLower(strings.TrimSpace(arguments))), WiznetBroadcastFilter)
+}
+
+/* The players with the most kills of other players outside arenas, and ch's own record */
+func do_pkills(ch *Character, arguments string) {
+	rows, err := ch.Game.db.Query(`
+		SELECT
+			player_characters.username,
+			COUNT(*) AS kills
+		FROM
+			pkills
+		INNER JOIN
+			player_characters ON player_characters.id = pkills.killer_id
+		WHERE
+			pkills.arena = 0
+		GROUP BY
+			pkills.killer_id
+		ORDER BY
+			kills DESC,
+			MIN(pkills.created_at) ASC
+		LIMIT 10
+	`)
+	if err != nil {
+		log.Printf("Failed to load the player kill leaderboard: %v.\r\n", err)
+		ch.Send("{RThe leaderboard is unavailable right now.{x\r\n")
+		return
+	}
+
+	defer rows.Close()
+
+	var output strings.Builder
+	output.WriteString("{WMost feared players:{x\r\n")
+
+	rank := 0
+	for rows.Next() {
+		var name string
+		var kills int
+
+		if err := rows.Scan(&name, &kills); err != nil {
+			log.Printf("Failed to read the player kill leaderboard: %v.\r\n", err)
+			break
+		}
+
+		rank++
+		output.WriteString(fmt.Sprintf("{C%2d. {c%-16s {W%d{x\r\n", rank, name, kills))
+	}
+
+	if rank == 0 {
+		output.WriteString("{DNo player has slain another yet.{x\r\n")
+	}
+
+	if ch.Id > 0 {
+		var kills, deaths int
+
+		err = ch.Game.db.QueryRow(`
+			SELECT
+				COALESCE(SUM(killer_id = ?), 0),
+				COALESCE(SUM(victim_id = ?), 0)
+			FROM
+				pkills
+			WHERE
+				arena = 0
+		`, ch.Id, ch.Id).Scan(&kills, &deaths)
+		if err == nil {
+			output.WriteString(fmt.Sprintf("{WYou have slain %d players and been slain by players %d times.{x\r\n", kills, deaths))
+		}
+	}
+
+	ch.Send(output.String())
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import "testing"
+
+func TestPvPRequiresConsent(t *testing.T) {
+	game := &Game{}
+	room := game.NewRoom()
+
+	newPlayer := func(level uint, consent bool) *Character {
+		ch := newCombatTestCharacter(game, level)
+		ch.Flags |= CHAR_IS_PLAYER
+		ch.PkConsent = consent
+		ch.Room = room
+		return ch
+	}
+
+	tests := []struct {
+		name     string
+		attacker *Character
+		victim   *Character
+		setup    func(attacker *Character, victim *Character)
+		want     bool
+	}{
+		{"both consent", newPlayer(20, true), newPlayer(25, true), nil, true},
+		{"attacker has not consented", newPlayer(20, false), newPlayer(20, true), nil, false},
+		{"victim has not consented", newPlayer(20, true), newPlayer(20, false), nil, false},
+		{"out of range", newPlayer(20, true), newPlayer(29, true), nil, true},
+		{"killers are fair game", newPlayer(20, true), newPlayer(40, false), func(a *Character, v *Character) { v.Killer = 60 }, true},
+		{"killers still need consent to hunt", newPlayer(20, false), newPlayer(20, false), func(a *Character, v *Character) { v.Killer = 60 }, false},
+		{"thieves are fair game", newPlayer(20, true), newPlayer(40, false), func(a *Character, v *Character) { v.Thief = 60 }, true},
+		{"mobiles are always fair game", newPlayer(20, false), newCombatTestCharacter(game, 50), nil, true},
+		{"arena", newPlayer(1, false), newPlayer(40, false), func(a *Character, v *Character) { room.Flags = ROOM_PK }, true},
+		{"safe room", newPlayer(20, true), newPlayer(20, true), func(a *Character, v *Character) { room.Flags = ROOM_SAFE }, false},
+	}
+
+	for _, test := range tests {
+		room.Flags = 0
+		if test.setup != nil {
+			test.setup(test.attacker, test.victim)
+		}
+
+		if got := test.attacker.MayAttack(test.victim); got != test.want {
+			t.Errorf("%s: may attack is %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPvPAttacksFlagTheAggressor(t *testing.T) {
+	game := &Game{Characters: NewLinkedList[*Character](), Fights: NewLinkedList[*Combat]()}
+	room := game.NewRoom()
+	room.Characters = NewLinkedList[*Character]()
+	room.Objects = NewLinkedList[*ObjectInstance]()
+
+	newPlayer := func(name string, level uint) *Character {
+		ch := newCombatTestCharacter(game, level)
+		ch.Name = name
+		ch.Flags |= CHAR_IS_PLAYER
+		ch.PkConsent = true
+		room.AddCharacter(ch)
+		game.Characters.Insert(ch)
+		return ch
+	}
+
+	bully := newPlayer("bully", 40)
+	peer := newPlayer("peer", 36)
+	victim := newPlayer("victim", 20)
+	upstart := newPlayer("upstart", 20)
+
+	do_kill(peer, "bully")
+	game.Damage(peer, bully, false, 10, DamageTypeTrue)
+	game.Damage(bully, peer, false, 10, DamageTypeTrue)
+	if peer.Killer != 0 || bully.Killer != 0 {
+		t.Fatalf("killer flags are %d and %d after a fight within range", peer.Killer, bully.Killer)
+	}
+
+	if peer.PkCooldown != PkCombatCooldown || bully.PkCooldown != PkCombatCooldown {
+		t.Errorf("consent cooldowns are %d and %d, want both at %d", peer.PkCooldown, bully.PkCooldown, PkCombatCooldown)
+	}
+
+	/* Hitting back at a much weaker player who started the fight is self-defence */
+	do_kill(upstart, "peer")
+	game.Damage(peer, upstart, false, 10, DamageTypeTrue)
+	if upstart.Killer != 0 || peer.Killer != 0 {
+		t.Fatalf("killer flags are %d and %d after self-defence", upstart.Killer, peer.Killer)
+	}
+
+	bully.Fighting = nil
+	do_kill(bully, "victim")
+	if bully.Killer != PkKillerDuration || victim.Killer != 0 {
+		t.Fatalf("killer flags are %d and %d, want only the bully flagged", bully.Killer, victim.Killer)
+	}
+
+	game.pvpUpdate(PkKillerDuration - 1)
+	if bully.Killer != 1 || bully.PkCooldown != 0 {
+		t.Errorf("after most of the flag's duration, killer is %d and cooldown %d", bully.Killer, bully.PkCooldown)
+	}
+
+	game.pvpUpdate(15)
+	if bully.Killer != 0 {
+		t.Errorf("the killer flag has %d seconds left after expiring", bully.Killer)
+	}
+
+	/* A spell or skill opening on a bystander starts a fight just as kill does */
+	peer.Fighting = nil
+	game.Damage(peer, victim, false, 10, DamageTypeTrue)
+	if peer.Killer != PkKillerDuration {
+		t.Errorf("a damaging opener left the killer flag at %d", peer.Killer)
+	}
+
+	victim.PkConsent = false
+	health := victim.Health
+	if result := game.Damage(upstart, victim, false, 10, DamageTypeTrue); result.Amount != 0 || victim.Health != health {
+		t.Errorf("a player who withdrew consent took %d damage and has %d health", result.Amount, victim.Health)
+	}
+}
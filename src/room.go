@@ -31,6 +31,7 @@ const (
 	ROOM_EVIL_AURA  = 1 << 4
 	ROOM_PLANAR     = 1 << 5
 	ROOM_DARK       = 1 << 6
+	ROOM_PK         = 1 << 7
 )
 
 var RoomFlagTable []Flag = []Flag{
@@ -41,6 +42,7 @@ var RoomFlagTable []Flag = []Flag{
 	{Name: "evil_aura", Flag: ROOM_EVIL_AURA},
 	{Name: "planar", Flag: ROOM_PLANAR},
 	{Name: "dark", Flag: ROOM_DARK},
+	{Name: "pk", Flag: ROOM_PK},
 }
 
 type Room struct {
@@ -182,7 +184,8 @@ func (room *Room) notifyPlaneEnter(ch *Character, previousRoom *Room) {
 			continue
 		}
 
-		obs.OnEnterCallback(room.Game.vm.ToValue(ch))
+		_, err := room.Game.callScriptFunction(ScriptHookObserver, "", obs.OnEnterCallback, room.Game.vm.ToValue(ch))
+		logScriptHandlerError("plane observer enter", err)
 	}
 }
 
@@ -205,7 +208,8 @@ func (room *Room) notifyPlaneLeave(ch *Character, destination *Room) {
 			continue
 		}
 
-		obs.OnLeaveCallback(room.Game.vm.ToValue(ch))
+		_, err := room.Game.callScriptFunction(ScriptHookObserver, "", obs.OnLeaveCallback, room.Game.vm.ToValue(ch))
+		logScriptHandlerError("plane observer leave", err)
 	}
 }
 
@@ -239,6 +243,19 @@ func (room *Room) AddCharacter(ch *Character) {
 	ch.Trail = trail
 }
 
+/*
+ * Fire the room's enter trigger for a character who has moved in from another
+ * room, once the arrival has been announced and they have looked around.
+ * Characters appearing from nowhere (resets, logins) have not moved into the room.
+ */
+func (room *Room) characterEntered(ch *Character, from *Room) {
+	if from == nil || from == room || ch.Room != room {
+		return
+	}
+
+	room.fireRoomTrigger(RoomTriggerEnter, ch)
+}
+
 func (room *Room) moveCharacter(ch *Character, destination *Room) {
 	if destination == nil {
 		room.removeCharacter(ch)
@@ -246,6 +263,12 @@ func (room *Room) moveCharacter(ch *Character, destination *Room) {
 	}
 
 	room.removeCharacterForMove(ch, destination)
+
+	/* A leave trigger may have already put the character somewhere else */
+	if ch.Room != nil {
+		return
+	}
+
 	destination.AddCharacter(ch)
 }
 
@@ -269,6 +292,11 @@ func (room *Room) removeCharacterForMove(ch *Character, destination *Room) {
 	}
 
 	ch.Room = nil
+
+	/* Extraction and quitting are not moves, so only a real destination fires the leave trigger */
+	if destination != nil && destination != room {
+		room.fireRoomTrigger(RoomTriggerLeave, ch, destination)
+	}
 }
 
 func (room *Room) listObjectsToCharacter(ch *Character) {
@@ -366,7 +394,9 @@ func (game *Game) FixExits() error {
 			room_id,
 			to_room_id,
 			direction,
-			flags
+			flags,
+			key_id,
+			lock_difficulty
 		FROM
 			exits
 		WHERE
@@ -386,7 +416,7 @@ func (game *Game) FixExits() error {
 		var roomId int
 		var toRoomId int
 
-		err = rows.Scan(&exit.Id, &roomId, &toRoomId, &exit.Direction, &exit.Flags)
+		err = rows.Scan(&exit.Id, &roomId, &toRoomId, &exit.Direction, &exit.Flags, &exit.Key, &exit.LockDifficulty)
 		if err != nil {
 			return err
 		}
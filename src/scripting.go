@@ -8,6 +8,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -23,6 +24,7 @@ import (
 type EventHandler struct {
 	name     string
 	callback goja.Callable
+	module   string
 }
 
 type ScriptTimer struct {
@@ -47,6 +49,16 @@ func logScriptHandlerError(context string, err error) {
 	log.Printf("Script handler %s failed: %v\r\n", context, err)
 }
 
+/* Prefer the script's stack trace over goja's one-line summary of the throwing native frame */
+func scriptErrorDescription(err error) string {
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return exception.String()
+	}
+
+	return err.Error()
+}
+
 func (game *Game) DefaultSourceLoader(filename string) ([]byte, error) {
 	for _, script := range game.Scripts {
 		if strings.Compare(strings.ToLower(filename), strings.ToLower(script.Name)) == 0 {
@@ -57,23 +69,28 @@ func (game *Game) DefaultSourceLoader(filename string) ([]byte, error) {
 	return nil, errors.New("unable to find a script of that name")
 }
 
-func (script *Script) GetExports() (*goja.Object, error) {
-	game := script.Game
-
-	source := "(function(exports, require, module) {" + script.Script + "\n})"
-	parsed, err := goja.Parse(script.Name, source, parser.WithSourceMapLoader(game.DefaultSourceLoader))
+/* Compile a database script's source inside its module wrapper without running it */
+func (game *Game) compileScript(name string, source string) (*goja.Program, error) {
+	parsed, err := goja.Parse(name, "(function(exports, require, module) {"+source+"\n})", parser.WithSourceMapLoader(game.DefaultSourceLoader))
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
 
-	compiled, err := goja.CompileAST(parsed, false)
+	return goja.CompileAST(parsed, false)
+}
+
+func (script *Script) GetExports() (*goja.Object, error) {
+	game := script.Game
+
+	compiled, err := game.compileScript(script.Name, script.Script)
 	if err != nil {
 		log.Println(err)
 		return nil, err
 	}
 
-	res, err := game.vm.RunProgram(compiled)
+	res, err := game.runScriptLimited(ScriptHookLoad, script.limitKey(), func() (goja.Value, error) {
+		return game.vm.RunProgram(compiled)
+	})
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -89,7 +106,9 @@ func (script *Script) GetExports() (*goja.Object, error) {
 	exports := game.vm.NewObject()
 	module.Set("exports", exports)
 
-	_, err = fn(exports, exports, nil, module)
+	_, err = game.withStoreOwner(script.Name, func() (goja.Value, error) {
+		return game.callScriptFunction(ScriptHookLoad, script.limitKey(), fn, exports, exports, game.requireFunction(""), module)
+	})
 	if err != nil {
 		log.Printf("Failed to evaluate script (%s/%d): %v\r\n", script.Name, script.Id, err)
 		return nil, err
@@ -176,6 +195,42 @@ func (game *Game) LoadScriptsFromDatabase() error {
 		return err
 	}
 
+	log.Println("Loading mobile-script relations from database...")
+	rows, err = game.db.Query(`
+		SELECT
+			mobile_script.mobile_id,
+			mobile_script.script_id
+		FROM
+			mobile_script
+	`)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var mobileId uint
+		var scriptId uint
+
+		err := rows.Scan(&mobileId, &scriptId)
+		if err != nil {
+			return err
+		}
+
+		_, ok := game.Scripts[scriptId]
+		if !ok {
+			log.Printf("Trying to relate mobile with script")
+			continue
+		}
+
+		game.mobileScripts[mobileId] = game.Scripts[scriptId]
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
 	log.Println("Loading room-script relations from database...")
 	rows, err = game.db.Query(`
 		SELECT
@@ -328,6 +383,7 @@ func (game *Game) LoadScriptsFromDatabase() error {
 
 func (game *Game) clearScriptBindings() {
 	game.objectScripts = make(map[uint]*Script)
+	game.mobileScripts = make(map[uint]*Script)
 	game.webhookScripts = make(map[int]*Script)
 	game.districtScripts = make(map[int]*Script)
 
@@ -355,6 +411,12 @@ func (game *Game) detachScriptBindings(script *Script) {
 		}
 	}
 
+	for mobileId, boundScript := range game.mobileScripts {
+		if sameScriptBinding(boundScript, script) {
+			delete(game.mobileScripts, mobileId)
+		}
+	}
+
 	for webhookId, boundScript := range game.webhookScripts {
 		if sameScriptBinding(boundScript, script) {
 			delete(game.webhookScripts, webhookId)
@@ -430,31 +492,18 @@ func (game *Game) DeleteScript(script *Script) error {
 	return nil
 }
 
+/* Save the in-memory source as a new revision; see Commit */
 func (script *Script) Save() bool {
-	result, err := script.Game.db.Exec(`
-		UPDATE
-			scripts
-		SET
-			name = ?,
-			script = ?
-		WHERE
-			id = ?
-	`, script.Name, script.Script, script.Id)
+	_, err := script.Commit(script.Script, "")
 	if err != nil {
 		log.Printf("Failed to save script: %v.\r\n", err)
 		return false
 	}
 
-	_, err = result.RowsAffected()
-	if err != nil {
-		log.Printf("Failed to retrieve number of rows affected: %v.\r\n", err)
-		return false
-	}
-
 	return true
 }
 
-func (game *Game) CreateScript(name string, initialBody string) (*Script, error) {
+func (game *Game) CreateScript(name string, initialBody string, author string) (*Script, error) {
 	script := &Script{Name: name, Script: initialBody, Game: game}
 
 	var err error
@@ -466,22 +515,43 @@ func (game *Game) CreateScript(name string, initialBody string) (*Script, error)
 		return nil, fmt.Errorf("failed to initialize exports for script %s", script.Name)
 	}
 
-	res, err := game.db.Exec(`
+	ctx := context.Background()
+
+	tx, err := game.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(`
 	INSERT INTO
 		scripts(name, script)
 	VALUES
 		(?, ?)
 	`, name, initialBody)
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
 	insertId64, err := res.LastInsertId()
 	if err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
 	script.Id = uint(insertId64)
+
+	_, err = insertScriptRevision(tx, script.Id, initialBody, author)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
 	if game.Scripts == nil {
 		game.Scripts = make(map[uint]*Script)
 	}
@@ -494,7 +564,7 @@ func (game *Game) scriptTimersUpdate() {
 		effect := iter.Value
 
 		if time.Since(effect.createdAt).Milliseconds() > effect.delay {
-			_, err := effect.callback(game.vm.ToValue(effect))
+			_, err := game.callScriptFunction(ScriptHookTimer, "", effect.callback, game.vm.ToValue(effect))
 			if err != nil {
 				logScriptHandlerError("setTimeout callback", err)
 			}
@@ -516,7 +586,9 @@ func (script *Script) tryEvaluate(methodName string, this goja.Value, arguments
 		return nil, fmt.Errorf("%s not a function exported by script %s", methodName, script.Name)
 	}
 
-	result, err := fn(this, arguments...)
+	result, err := script.Game.withStoreOwner(script.Name, func() (goja.Value, error) {
+		return script.Game.callScriptFunction(scriptMethodHook(methodName), script.limitKey(), fn, this, arguments...)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -524,6 +596,16 @@ func (script *Script) tryEvaluate(methodName string, this goja.Value, arguments
 	return result, nil
 }
 
+/* Trigger-style scripts need not export every handler, so check before evaluating */
+func (script *Script) exportsFunction(methodName string) bool {
+	if script == nil || script.Exports == nil {
+		return false
+	}
+
+	_, ok := goja.AssertFunction(script.Exports.Get(methodName))
+	return ok
+}
+
 func (game *Game) InvokeNamedEventHandlersWithContextAndArguments(name string, this goja.Value, arguments ...goja.Value) ([]goja.Value, []error) {
 	if game.eventHandlers[name] != nil {
 		values := make([]goja.Value, game.eventHandlers[name].Count)
@@ -531,7 +613,7 @@ func (game *Game) InvokeNamedEventHandlersWithContextAndArguments(name string, t
 		i := 0
 
 		for eventHandler := range game.eventHandlers[name].All() {
-			result, err := eventHandler.callback(this, arguments...)
+			result, err := game.callScriptFunction(ScriptHookEvent, "event "+name, eventHandler.callback, this, arguments...)
 			if err != nil {
 				logScriptHandlerError(fmt.Sprintf("event %q", name), err)
 				errors[i] = err
@@ -553,12 +635,24 @@ func (game *Game) InvokeNamedEventHandlersWithContextAndArguments(name string, t
 }
 
 func (game *Game) LoadScripts() error {
-	const ScriptDirectory = "scripts"
+	if game.scriptRoot == "" {
+		return game.LoadScriptsFromDirectory(ScriptDirectory)
+	}
 
-	return game.LoadScriptsFromDirectory(ScriptDirectory)
+	return game.LoadScriptsFromDirectory(game.scriptRoot)
 }
 
+/* Every file is evaluated as a module named by its path under directory, e.g. "core/combat" */
 func (game *Game) LoadScriptsFromDirectory(directory string) error {
+	game.scriptRoot = directory
+	if game.modules == nil {
+		game.clearScriptModules()
+	}
+
+	return game.loadScriptModulesFromDirectory(directory)
+}
+
+func (game *Game) loadScriptModulesFromDirectory(directory string) error {
 	log.Printf("Loading scripts from directory %s:\r\n", directory)
 	scripts, err := os.ReadDir(directory)
 	if err != nil {
@@ -571,27 +665,38 @@ func (game *Game) LoadScriptsFromDirectory(directory string) error {
 			continue
 		}
 
-		path := filepath.Join(directory, name)
+		filename := filepath.Join(directory, name)
 
 		if script.IsDir() {
-			err := game.LoadScriptsFromDirectory(path)
+			err := game.loadScriptModulesFromDirectory(filename)
 			if err != nil {
 				return err
 			}
 			continue
 		}
 
-		if filepath.Ext(name) != ".js" {
+		/* Test suites are only evaluated by the script test runner */
+		if filepath.Ext(name) != ".js" || strings.HasSuffix(name, ScriptTestSuffix) {
+			continue
+		}
+
+		id, err := scriptModuleIdFromPath(game.scriptRoot, filename)
+		if err != nil {
+			return err
+		}
+
+		/* Already evaluated because an earlier script required it */
+		if module, ok := game.modules[id]; ok && module.loaded {
 			continue
 		}
 
-		log.Printf("Loading script: %s\r\n", path)
-		bytes, err := os.ReadFile(path)
+		log.Printf("Loading script: %s\r\n", filename)
+		bytes, err := os.ReadFile(filename)
 		if err != nil {
 			return err
 		}
 
-		_, err = game.vm.RunString(string(bytes))
+		_, err = game.evaluateScriptModule(id, filename, string(bytes))
 		if err != nil {
 			log.Println(err)
 			return err
@@ -603,8 +708,16 @@ func (game *Game) LoadScriptsFromDirectory(directory string) error {
 
 func do_reload(ch *Character, arguments string) {
 	ch.Game.InvokeNamedEventHandlersWithContextAndArguments("reload", ch.Game.vm.ToValue(ch.Game))
+	ch.Game.resetScriptViolations()
+	ch.Game.clearScriptModules()
+
+	err := ch.Game.LoadScriptSchedules()
+	if err != nil {
+		ch.Send(fmt.Sprintf("{RFailed to reload scheduled jobs: %s{x\r\n", err.Error()))
+		return
+	}
 
-	err := ch.Game.LoadScripts()
+	err = ch.Game.LoadScripts()
 	if err != nil {
 		ch.Send(fmt.Sprintf("{RFailed reload: %s{x\r\n", err.Error()))
 		return
@@ -621,9 +734,41 @@ func do_reload(ch *Character, arguments string) {
 func (game *Game) InitScripting() error {
 	game.vm = goja.New()
 	game.eventHandlers = make(map[string]*LinkedList[*EventHandler])
+	game.resetScriptViolations()
+	game.clearScriptModules()
 
 	game.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	game.vm.SetMaxCallStackSize(Config.ScriptingConfiguration.MaxCallStackSize)
+
+	game.installScriptGlobals()
+
+	err := game.LoadScriptSchedules()
+	if err != nil {
+		return err
+	}
+
+	err = game.LoadScripts()
+	if err != nil {
+		return err
+	}
+
+	err = game.LoadScriptsFromDatabase()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/* Globals every script can see; also reflected over to generate scripts/golem.d.ts */
+func (game *Game) installScriptGlobals() {
+	game.vm.Set("Golem", game.newGolemObject())
+	game.vm.Set("println", game.vm.ToValue(log.Println))
+	game.vm.Set("setTimeout", game.vm.ToValue(game.setTimeout))
+	game.vm.Set("require", game.requireFunction(""))
+}
 
+func (game *Game) newGolemObject() *goja.Object {
 	obj := game.vm.NewObject()
 
 	obj.Set("game", game.vm.ToValue(game))
@@ -644,6 +789,12 @@ func (game *Game) InitScripting() error {
 		return game.vm.ToValue(true)
 	}))
 
+	obj.Set("clearCombatHooks", game.vm.ToValue(func() goja.Value {
+		game.combatHooks = make(map[string]*CombatHook)
+
+		return game.vm.ToValue(true)
+	}))
+
 	obj.Set("clearScriptedSkillHandlers", game.vm.ToValue(func() goja.Value {
 		for _, skill := range game.skills {
 			skill.Handler = nil
@@ -658,12 +809,14 @@ func (game *Game) InitScripting() error {
 			game.eventHandlers[eventName] = NewLinkedList[*EventHandler]()
 		}
 
-		handler := &EventHandler{name: eventName, callback: fn}
+		handler := &EventHandler{name: eventName, callback: fn, module: game.currentScriptModule()}
 		game.eventHandlers[eventName].Insert(handler)
 
 		return game.vm.ToValue(handler)
 	}))
 
+	obj.Set("registerCombatHook", game.vm.ToValue(game.RegisterCombatHook))
+
 	obj.Set("registerSkillHandler", game.vm.ToValue(func(name goja.Value, fn goja.Callable) goja.Value {
 		skillName := name.String()
 
@@ -684,6 +837,7 @@ func (game *Game) InitScripting() error {
 			CmdFunc:      nil,
 			MinimumLevel: uint(minimumLevel.ToInteger()),
 			Callback:     fn,
+			module:       game.currentScriptModule(),
 		}
 
 		CommandTable[command] = scriptedCommand
@@ -745,6 +899,7 @@ func (game *Game) InitScripting() error {
 	roomFlagsConstantsObj.Set("ROOM_DUNGEON", ROOM_DUNGEON)
 	roomFlagsConstantsObj.Set("ROOM_EVIL_AURA", ROOM_EVIL_AURA)
 	roomFlagsConstantsObj.Set("ROOM_DARK", ROOM_DARK)
+	roomFlagsConstantsObj.Set("ROOM_PK", ROOM_PK)
 
 	exitFlagsConstantsObj := game.vm.NewObject()
 	exitFlagsConstantsObj.Set("EXIT_IS_DOOR", EXIT_IS_DOOR)
@@ -784,6 +939,8 @@ func (game *Game) InitScripting() error {
 	effectTypes.Set("EffectTypeAffected", game.vm.ToValue(EffectTypeAffected))
 	effectTypes.Set("EffectTypeStat", game.vm.ToValue(EffectTypeStat))
 	effectTypes.Set("EffectTypeImmunity", game.vm.ToValue(EffectTypeImmunity))
+	effectTypes.Set("EffectTypeResist", game.vm.ToValue(EffectTypeResist))
+	effectTypes.Set("EffectTypeSuscept", game.vm.ToValue(EffectTypeSuscept))
 
 	affectedTypes := game.vm.NewObject()
 	affectedTypes.Set("AFFECT_SANCTUARY", game.vm.ToValue(AFFECT_SANCTUARY))
@@ -795,6 +952,8 @@ func (game *Game) InitScripting() error {
 	affectedTypes.Set("AFFECT_FIRESHIELD", game.vm.ToValue(AFFECT_FIRESHIELD))
 	affectedTypes.Set("AFFECT_PARALYSIS", game.vm.ToValue(AFFECT_PARALYSIS))
 	affectedTypes.Set("AFFECT_BLINDNESS", game.vm.ToValue(AFFECT_BLINDNESS))
+	affectedTypes.Set("AFFECT_CHARM", game.vm.ToValue(AFFECT_CHARM))
+	affectedTypes.Set("AFFECT_FLYING", game.vm.ToValue(AFFECT_FLYING))
 
 	statTypes := game.vm.NewObject()
 	statTypes.Set("STAT_NONE", game.vm.ToValue(STAT_NONE))
@@ -812,6 +971,11 @@ func (game *Game) InitScripting() error {
 	combatObj.Set("DamageTypeSlash", game.vm.ToValue(DamageTypeSlash))
 	combatObj.Set("DamageTypeStab", game.vm.ToValue(DamageTypeStab))
 	combatObj.Set("DamageTypeExotic", game.vm.ToValue(DamageTypeExotic))
+	combatObj.Set("DamageTypeFire", game.vm.ToValue(DamageTypeFire))
+	combatObj.Set("DamageTypeCold", game.vm.ToValue(DamageTypeCold))
+	combatObj.Set("DamageTypeShock", game.vm.ToValue(DamageTypeShock))
+	combatObj.Set("DamageTypePoison", game.vm.ToValue(DamageTypePoison))
+	combatObj.Set("DamageTypeTrue", game.vm.ToValue(DamageTypeTrue))
 
 	httpUtilityObj := game.vm.NewObject()
 	httpUtilityObj.Set("Get", game.vm.ToValue(SimpleGET))
@@ -834,6 +998,12 @@ func (game *Game) InitScripting() error {
 	terrainTypes.Set("TerrainTypeMountains", game.vm.ToValue(TerrainTypeMountains))
 	terrainTypes.Set("TerrainTypeSnowcappedMountains", game.vm.ToValue(TerrainTypeSnowcappedMountains))
 
+	terrainFlags := game.vm.NewObject()
+	terrainFlags.Set("TERRAIN_IMPASSABLE", game.vm.ToValue(TERRAIN_IMPASSABLE))
+	terrainFlags.Set("TERRAIN_SHALLOW_WATER", game.vm.ToValue(TERRAIN_SHALLOW_WATER))
+	terrainFlags.Set("TERRAIN_DEEP_WATER", game.vm.ToValue(TERRAIN_DEEP_WATER))
+	terrainFlags.Set("TERRAIN_RUGGED", game.vm.ToValue(TERRAIN_RUGGED))
+
 	utilObj := game.vm.NewObject()
 	utilObj.Set("createLinkedList", game.vm.ToValue(NewAnyLinkedList))
 	utilObj.Set("createQuadTree", game.vm.ToValue(NewAnyQuadTree))
@@ -856,6 +1026,8 @@ func (game *Game) InitScripting() error {
 	utilObj.Set("findRaceByName", game.vm.ToValue(FindRaceByName))
 	utilObj.Set("characterFlagNames", game.vm.ToValue(CharacterFlagNames))
 	utilObj.Set("affectedFlagNames", game.vm.ToValue(AffectedFlagNames))
+	utilObj.Set("findDamageType", game.vm.ToValue(FindDamageTypeByName))
+	utilObj.Set("damageFlagNames", game.vm.ToValue(DamageFlagNames))
 	utilObj.Set("positionName", game.vm.ToValue(PositionName))
 	utilObj.Set("statName", game.vm.ToValue(StatName))
 	utilObj.Set("characterStat", game.vm.ToValue(CharacterStat))
@@ -879,6 +1051,7 @@ func (game *Game) InitScripting() error {
 	obj.Set("AffectedTypes", affectedTypes)
 	obj.Set("RoomFlags", roomFlagsConstantsObj)
 	obj.Set("TerrainTypes", terrainTypes)
+	obj.Set("TerrainFlags", terrainFlags)
 	obj.Set("StatTypes", statTypes)
 	obj.Set("CharacterFlags", charFlagsConstantsObj)
 	obj.Set("ObjectFlags", objectFlagsConstantsObj)
@@ -890,22 +1063,22 @@ func (game *Game) InitScripting() error {
 	obj.Set("HTTP", httpUtilityObj)
 	obj.Set("NewExit", game.vm.ToValue(game.NewExit))
 	obj.Set("Levels", levelConstantsObj)
+	obj.Set("store", game.newScriptStoreObject())
 
-	obj.Set("util", utilObj)
+	obj.Set("schedule", game.vm.ToValue(func(expression string, name string, fn goja.Callable) goja.Value {
+		job, err := game.ScheduleScript(expression, name, fn)
+		if err != nil {
+			panic(game.vm.NewGoError(err))
+		}
 
-	game.vm.Set("Golem", obj)
-	game.vm.Set("println", game.vm.ToValue(log.Println))
-	game.vm.Set("setTimeout", game.vm.ToValue(game.setTimeout))
+		return game.vm.ToValue(job)
+	}))
 
-	err := game.LoadScripts()
-	if err != nil {
-		return err
-	}
+	obj.Set("unschedule", game.vm.ToValue(func(name string) bool {
+		return game.UnscheduleScript(name) == nil
+	}))
 
-	err = game.LoadScriptsFromDatabase()
-	if err != nil {
-		return err
-	}
+	obj.Set("util", utilObj)
 
-	return nil
+	return obj
 }
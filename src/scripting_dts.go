@@ -0,0 +1,363 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+//go:generate go run . dts ../scripts/golem.d.ts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+const scriptDeclarationsHeader = `/*
+ * Type declarations for the Golem scripting API.
+ *
+ * Generated by "golem dts" from the Go types exposed to scripts; do not edit.
+ */
+
+declare const module: { exports: any; id: string; filename: string };
+declare const exports: any;
+declare const __filename: string;
+declare const __dirname: string;
+`
+
+var (
+	scriptPackagePath       = reflect.TypeOf(Game{}).PkgPath()
+	scriptValueType         = reflect.TypeOf((*goja.Value)(nil)).Elem()
+	scriptCallableType      = reflect.TypeOf(goja.Callable(nil))
+	scriptNativeFunction    = reflect.TypeOf(func(goja.FunctionCall) goja.Value { return nil })
+	scriptErrorType         = reflect.TypeOf((*error)(nil)).Elem()
+	scriptQualifiedTypeName = regexp.MustCompile(`[\w./-]*\.`)
+	scriptIdentifier        = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+)
+
+type scriptDeclarations struct {
+	names      map[reflect.Type]string
+	interfaces map[string]string
+	pending    []reflect.Type
+
+	/* Prefix for interface references made from outside of the Golem namespace */
+	qualifier string
+}
+
+func (declarations *scriptDeclarations) interfaceName(t reflect.Type) string {
+	name, ok := declarations.names[t]
+	if !ok {
+		/* LinkedList[*main.Character] becomes LinkedListOfCharacter */
+		name = scriptQualifiedTypeName.ReplaceAllString(t.Name(), "")
+		name = strings.NewReplacer("interface {}", "Any", "*", "", "[", "Of", "]", "", ",", "And", " ", "").Replace(name)
+
+		declarations.names[t] = name
+		declarations.pending = append(declarations.pending, t)
+	}
+
+	return declarations.qualifier + name
+}
+
+func (declarations *scriptDeclarations) typeOf(t reflect.Type) string {
+	switch t {
+	case scriptValueType:
+		return "any"
+	case scriptCallableType, scriptNativeFunction:
+		return "(...args: any[]) => any"
+	case scriptErrorType:
+		return "Error"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+
+	case reflect.String:
+		return "string"
+
+	case reflect.Slice, reflect.Array:
+		element := declarations.typeOf(t.Elem())
+		if strings.Contains(element, "=>") {
+			element = "(" + element + ")"
+		}
+
+		return element + "[]"
+
+	case reflect.Map:
+		key := "string"
+		if declarations.typeOf(t.Key()) == "number" {
+			key = "number"
+		}
+
+		return fmt.Sprintf("{ [key: %s]: %s }", key, declarations.typeOf(t.Elem()))
+
+	case reflect.Pointer:
+		return declarations.typeOf(t.Elem())
+
+	case reflect.Func:
+		parameters, result := declarations.signature(t, 0)
+		return fmt.Sprintf("(%s) => %s", parameters, result)
+
+	case reflect.Struct:
+		/* Only the game's own types are described; library types stay opaque */
+		if t.PkgPath() != scriptPackagePath || t.Name() == "" {
+			return "any"
+		}
+
+		return declarations.interfaceName(t)
+	}
+
+	return "any"
+}
+
+/* Describe a Go function as goja calls it: a trailing error throws, several results become an array */
+func (declarations *scriptDeclarations) signature(t reflect.Type, skip int) (string, string) {
+	if t == scriptNativeFunction {
+		return "...args: any[]", "any"
+	}
+
+	parameters := make([]string, 0, t.NumIn())
+	for i := skip; i < t.NumIn(); i++ {
+		name := fmt.Sprintf("arg%d", i-skip)
+
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			parameters = append(parameters, fmt.Sprintf("...%s: %s", name, declarations.typeOf(t.In(i))))
+			continue
+		}
+
+		parameters = append(parameters, fmt.Sprintf("%s: %s", name, declarations.typeOf(t.In(i))))
+	}
+
+	results := make([]string, 0, t.NumOut())
+	for i := 0; i < t.NumOut(); i++ {
+		if i == t.NumOut()-1 && t.Out(i) == scriptErrorType {
+			break
+		}
+
+		results = append(results, declarations.typeOf(t.Out(i)))
+	}
+
+	switch len(results) {
+	case 0:
+		return strings.Join(parameters, ", "), "void"
+	case 1:
+		return strings.Join(parameters, ", "), results[0]
+	default:
+		return strings.Join(parameters, ", "), "[" + strings.Join(results, ", ") + "]"
+	}
+}
+
+/* Mirror goja.TagFieldNameMapper("json", true): tagged fields and lower-camel methods */
+func (declarations *scriptDeclarations) describeInterface(t reflect.Type) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("    interface %s {\n", declarations.names[t]))
+
+	members := make(map[string]bool)
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !scriptIdentifier.MatchString(name) || members[name] {
+			continue
+		}
+
+		members[name] = true
+		output.WriteString(fmt.Sprintf("        %s: %s;\n", name, declarations.typeOf(field.Type)))
+	}
+
+	methods := reflect.PointerTo(t)
+	for i := 0; i < methods.NumMethod(); i++ {
+		method := methods.Method(i)
+
+		name := strings.ToLower(method.Name[:1]) + method.Name[1:]
+		if members[name] {
+			continue
+		}
+
+		members[name] = true
+		parameters, result := declarations.signature(method.Type, 1)
+
+		output.WriteString(fmt.Sprintf("        %s(%s): %s;\n", name, parameters, result))
+	}
+
+	output.WriteString("    }\n")
+	return output.String()
+}
+
+func sortedScriptKeys(obj *goja.Object) []string {
+	keys := obj.Keys()
+	sort.Strings(keys)
+
+	return keys
+}
+
+func scriptPlainObject(value goja.Value) (*goja.Object, bool) {
+	obj, ok := value.(*goja.Object)
+	if !ok {
+		return nil, false
+	}
+
+	_, plain := obj.Export().(map[string]interface{})
+	return obj, plain
+}
+
+/* Nested objects are described as object types, whose members may use reserved words like delete */
+func (declarations *scriptDeclarations) objectType(obj *goja.Object, indent string) string {
+	var output strings.Builder
+
+	output.WriteString("{\n")
+
+	for _, key := range sortedScriptKeys(obj) {
+		value := obj.Get(key)
+
+		if nested, ok := scriptPlainObject(value); ok {
+			output.WriteString(fmt.Sprintf("%s    %s: %s;\n", indent, key, declarations.objectType(nested, indent+"    ")))
+			continue
+		}
+
+		exported := value.Export()
+		if exported != nil && reflect.TypeOf(exported).Kind() == reflect.Func {
+			parameters, result := declarations.signature(reflect.TypeOf(exported), 0)
+			output.WriteString(fmt.Sprintf("%s    %s(%s): %s;\n", indent, key, parameters, result))
+			continue
+		}
+
+		output.WriteString(fmt.Sprintf("%s    readonly %s: %s;\n", indent, key, declarations.valueType(exported)))
+	}
+
+	output.WriteString(indent + "}")
+	return output.String()
+}
+
+func (declarations *scriptDeclarations) valueType(exported interface{}) string {
+	if exported == nil {
+		return "any"
+	}
+
+	return declarations.typeOf(reflect.TypeOf(exported))
+}
+
+func (declarations *scriptDeclarations) describeMember(output *strings.Builder, indent string, prefix string, name string, value goja.Value) {
+	if obj, ok := scriptPlainObject(value); ok {
+		output.WriteString(fmt.Sprintf("%s%sconst %s: %s;\n", indent, prefix, name, declarations.objectType(obj, indent)))
+		return
+	}
+
+	exported := value.Export()
+	if exported != nil && reflect.TypeOf(exported).Kind() == reflect.Func {
+		parameters, result := declarations.signature(reflect.TypeOf(exported), 0)
+		output.WriteString(fmt.Sprintf("%s%sfunction %s(%s): %s;\n", indent, prefix, name, parameters, result))
+		return
+	}
+
+	output.WriteString(fmt.Sprintf("%s%sconst %s: %s;\n", indent, prefix, name, declarations.valueType(exported)))
+}
+
+/*
+ * Build the script globals against a bare game and describe everything
+ * reachable from them.  Go types become interfaces inside the Golem namespace
+ * so that names like Map and Object do not merge with the standard library's.
+ */
+func generateScriptDeclarations(w io.Writer) error {
+	game := &Game{vm: goja.New()}
+	game.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	game.installScriptGlobals()
+
+	declarations := &scriptDeclarations{
+		names:      make(map[reflect.Type]string),
+		interfaces: make(map[string]string),
+	}
+
+	var output strings.Builder
+
+	output.WriteString(scriptDeclarationsHeader)
+
+	global := game.vm.GlobalObject()
+	golem, ok := scriptPlainObject(global.Get("Golem"))
+	if !ok {
+		return fmt.Errorf("the Golem global is not an object")
+	}
+
+	output.WriteString("\ndeclare namespace Golem {\n")
+	for _, key := range sortedScriptKeys(golem) {
+		declarations.describeMember(&output, "    ", "", key, golem.Get(key))
+	}
+
+	var globals strings.Builder
+
+	declarations.qualifier = "Golem."
+	for _, key := range sortedScriptKeys(global) {
+		if key == "Golem" {
+			continue
+		}
+
+		globals.WriteString("\n")
+		declarations.describeMember(&globals, "", "declare ", key, global.Get(key))
+	}
+
+	declarations.qualifier = ""
+	for len(declarations.pending) > 0 {
+		t := declarations.pending[0]
+		declarations.pending = declarations.pending[1:]
+
+		declarations.interfaces[declarations.names[t]] = declarations.describeInterface(t)
+	}
+
+	names := make([]string, 0, len(declarations.interfaces))
+	for name := range declarations.interfaces {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		output.WriteString("\n")
+		output.WriteString(declarations.interfaces[name])
+	}
+
+	output.WriteString("}\n")
+	output.WriteString(globals.String())
+
+	_, err := io.WriteString(w, output.String())
+	return err
+}
+
+func runDeclarationsCommand(arguments []string) error {
+	if len(arguments) > 1 {
+		return errCommandUsage
+	}
+
+	if len(arguments) == 0 {
+		return generateScriptDeclarations(os.Stdout)
+	}
+
+	f, err := os.Create(arguments[0])
+	if err != nil {
+		return err
+	}
+
+	err = generateScriptDeclarations(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
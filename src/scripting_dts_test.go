@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScriptDeclarationsAreUpToDate(t *testing.T) {
+	var generated strings.Builder
+
+	if err := generateScriptDeclarations(&generated); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"declare namespace Golem {",
+		"    interface Character {",
+		"        findCharacterInRoom(arg0: string): Character;",
+		"        delete(arg0: string): boolean;",
+		"    function registerPlayerCommand(",
+		"declare function setTimeout(",
+	} {
+		if !strings.Contains(generated.String(), want) {
+			t.Fatalf("generated declarations are missing %q", want)
+		}
+	}
+
+	checkedIn, err := os.ReadFile(filepath.Join("..", "scripts", "golem.d.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(checkedIn) != generated.String() {
+		t.Fatal("scripts/golem.d.ts is stale; run go generate ./src")
+	}
+}
@@ -0,0 +1,644 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+)
+
+/* Script files ending in this suffix are test suites, skipped when scripts are loaded */
+const ScriptTestSuffix = ".test.js"
+
+/* Math.random is seeded identically for every suite so that runs are reproducible */
+const scriptTestDefaultSeed = 1
+
+type ScriptTestOptions struct {
+	ScriptRoot    string
+	MigrationRoot string
+	Paths         []string
+	Run           *regexp.Regexp
+	Verbose       bool
+	Output        io.Writer
+}
+
+type ScriptTestResult struct {
+	Passed int
+	Failed int
+}
+
+type scriptTestCase struct {
+	name string
+	fn   goja.Callable
+}
+
+/* One test file, evaluated against its own freshly migrated fixture world */
+type scriptTestSuite struct {
+	game     *Game
+	filename string
+	tests    []scriptTestCase
+	elapsed  time.Duration
+}
+
+/* The game loop's periodic updates, replayed by fixture.tick() on a simulated clock */
+var scriptTestPulses = []struct {
+	period time.Duration
+	update func(game *Game)
+}{
+	{scriptTimerPulse, (*Game).scriptTimersUpdate},
+	{scriptSchedulePulse, (*Game).scriptSchedulesUpdate},
+	{combatPulse, (*Game).combatUpdate},
+	{characterUpdatePulse, (*Game).characterUpdate},
+	{objectUpdatePulse, (*Game).objectUpdate},
+	{updatePulse, (*Game).Update},
+	{zoneUpdatePulse, (*Game).ZoneUpdate},
+}
+
+/* Boot a game against an in-memory database carrying every migration and its seed world */
+func newScriptTestGame(options ScriptTestOptions) (*Game, error) {
+	db, err := sql.Open(databaseDriverSQLite, ":memory:")
+	if err != nil {
+		return nil, err
+	}
+
+	/* A single pooled connection keeps the in-memory database alive for the whole suite */
+	configureDatabasePool(db)
+
+	err = configureDatabaseConnection(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	migrations, err := filepath.Abs(options.MigrationRoot)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://"+filepath.ToSlash(migrations), databaseDriverSQLite, driver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		db.Close()
+		return nil, err
+	}
+
+	game := newGame()
+	game.db = db
+	game.scriptRoot = options.ScriptRoot
+
+	/* Planes and districts are left ungenerated; fixtures live in the seeded zones */
+	err = game.loadWorld()
+	if err == nil {
+		err = game.InitScripting()
+	}
+
+	if err == nil {
+		err = game.LoadShops()
+	}
+
+	if err == nil {
+		err = game.LoadResets()
+	}
+
+	if err == nil {
+		err = game.LoadPlayerCorpses()
+	}
+
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	game.vm.SetRandSource(rand.New(rand.NewSource(scriptTestDefaultSeed)).Float64)
+	game.SetCombatSeed(scriptTestDefaultSeed)
+	game.ZoneUpdate()
+
+	return game, nil
+}
+
+func (suite *scriptTestSuite) throw(format string, arguments ...interface{}) {
+	panic(suite.game.vm.NewGoError(fmt.Errorf(format, arguments...)))
+}
+
+/* Age everything the game loop measures with the wall clock, as if d had passed */
+func (suite *scriptTestSuite) age(d time.Duration) {
+	game := suite.game
+
+	for ch := range game.Characters.All() {
+		if ch.Casting != nil {
+			ch.Casting.StartedAt = ch.Casting.StartedAt.Add(-d)
+		}
+
+		for fx := range ch.Effects.All() {
+			fx.CreatedAt = fx.CreatedAt.Add(-d)
+		}
+	}
+
+	for timer := range game.ScriptTimers.All() {
+		timer.createdAt = timer.createdAt.Add(-d)
+	}
+
+	for zone := range game.Zones.All() {
+		zone.LastReset = zone.LastReset.Add(-d)
+	}
+
+	game.clockEpoch = game.clockEpoch.Add(-d)
+}
+
+func (suite *scriptTestSuite) tick(seconds int) {
+	for i := 0; i < seconds; i++ {
+		suite.age(time.Second)
+		suite.elapsed += time.Second
+
+		for _, pulse := range scriptTestPulses {
+			if suite.elapsed%pulse.period == 0 {
+				pulse.update(suite.game)
+			}
+		}
+	}
+}
+
+/* Drain everything sent to a fixture character since it was last read, without colour codes */
+func (suite *scriptTestSuite) output(ch *Character) string {
+	if ch == nil || ch.transcript == nil {
+		suite.throw("output is only captured for characters spawned by the fixture")
+	}
+
+	output := stripColourCodes(ch.transcript.String())
+	ch.transcript.Reset()
+
+	return output
+}
+
+type scriptTestPlayerOptions struct {
+	Room   *Room          `json:"room"`
+	Level  uint           `json:"level"`
+	Race   string         `json:"race"`
+	Job    string         `json:"job"`
+	Skills map[string]int `json:"skills"`
+}
+
+func (suite *scriptTestSuite) player(name string, value goja.Value) *Character {
+	game := suite.game
+
+	options := scriptTestPlayerOptions{Level: 1, Race: "human", Job: "warrior"}
+	if value != nil && !goja.IsUndefined(value) && !goja.IsNull(value) {
+		err := game.vm.ExportTo(value, &options)
+		if err != nil {
+			suite.throw("bad player options: %v", err)
+		}
+	}
+
+	ch := NewCharacter()
+	ch.Game = game
+	ch.Name = name
+	ch.Level = options.Level
+	ch.Flags |= CHAR_IS_PLAYER
+	ch.Practices = 100
+	ch.Health, ch.MaxHealth = 20, 20
+	ch.Mana, ch.MaxMana = 100, 100
+	ch.Stamina, ch.MaxStamina = 100, 100
+	ch.transcript = &bytes.Buffer{}
+
+	ch.Race = FindRaceByName(options.Race)
+	if ch.Race == nil {
+		suite.throw("no race named %s", options.Race)
+	}
+
+	ch.Job = FindJobByName(options.Job)
+	if ch.Job == nil {
+		suite.throw("no job named %s", options.Job)
+	}
+
+	err := ch.insertPlayerCharacter()
+	if err != nil {
+		suite.throw("could not create player %s: %v", name, err)
+	}
+
+	err = ch.syncJobSkills()
+	if err != nil {
+		suite.throw("could not grant %s's skills: %v", name, err)
+	}
+
+	for skillName, proficiency := range options.Skills {
+		skill := game.FindSkillByName(skillName)
+		if skill == nil {
+			suite.throw("no skill named %s", skillName)
+		}
+
+		prof, ok := ch.Skills[skill.Id]
+		if !ok {
+			suite.throw("a level %d %s does not know %s", ch.Level, ch.Job.Name, skillName)
+		}
+
+		prof.Proficiency = proficiency
+	}
+
+	ch.Room = options.Room
+	if ch.Room == nil {
+		ch.Room = suite.room(RoomLimbo)
+	}
+
+	game.addPlayerCharacterToWorld(ch)
+	return ch
+}
+
+func (suite *scriptTestSuite) mobile(id uint, room *Room) *Character {
+	game := suite.game
+
+	if room == nil {
+		suite.throw("mobile %d needs a room", id)
+	}
+
+	mobile, err := game.LoadMobileIndex(id)
+	if err != nil || mobile == nil {
+		suite.throw("could not load mobile %d: %v", id, err)
+	}
+
+	mobile.transcript = &bytes.Buffer{}
+
+	room.AddCharacter(mobile)
+	game.Characters.Insert(mobile)
+	return mobile
+}
+
+func (suite *scriptTestSuite) object(id uint, holder goja.Value) *ObjectInstance {
+	game := suite.game
+
+	obj := game.NewObjectInstance(id)
+	if obj == nil {
+		suite.throw("could not load object %d", id)
+	}
+
+	switch target := holder.Export().(type) {
+	case *Character:
+		target.AddObject(obj)
+
+	case *Room:
+		target.AddObject(obj)
+
+	default:
+		suite.throw("object %d must be given to a character or a room", id)
+	}
+
+	game.Objects.Insert(obj)
+	return obj
+}
+
+func (suite *scriptTestSuite) room(id uint) *Room {
+	room, err := suite.game.LoadRoomIndex(id)
+	if err != nil || room == nil {
+		suite.throw("could not load room %d: %v", id, err)
+	}
+
+	return room
+}
+
+/* Rooms made by a test exist only in memory and belong to the first zone */
+func (suite *scriptTestSuite) createRoom(name string, description string) *Room {
+	room := suite.game.NewRoom()
+	room.Name = name
+	room.Description = description
+	room.Resets = NewLinkedList[*Reset]()
+	room.Objects = NewLinkedList[*ObjectInstance]()
+	room.Characters = NewLinkedList[*Character]()
+	room.Exit = make(map[uint]*Exit)
+
+	if suite.game.Zones.Head != nil {
+		room.Zone = suite.game.Zones.Head.Value
+	}
+
+	return room
+}
+
+func (suite *scriptTestSuite) link(from *Room, direction string, to *Room) {
+	for index, name := range ExitName {
+		if name != strings.ToLower(direction) {
+			continue
+		}
+
+		from.Exit[index] = suite.game.NewExit(from, index, to, 0)
+		to.Exit[ReverseDirection[index]] = suite.game.NewExit(to, ReverseDirection[index], from, 0)
+		return
+	}
+
+	suite.throw("no direction named %s", direction)
+}
+
+func (suite *scriptTestSuite) fixtureObject() *goja.Object {
+	vm := suite.game.vm
+	obj := vm.NewObject()
+
+	obj.Set("room", suite.room)
+	obj.Set("createRoom", suite.createRoom)
+	obj.Set("link", suite.link)
+	obj.Set("player", suite.player)
+	obj.Set("mobile", suite.mobile)
+	obj.Set("object", suite.object)
+	obj.Set("output", suite.output)
+
+	/* Run input through the interpreter and return what it sent back to ch */
+	obj.Set("command", func(ch *Character, input string) string {
+		suite.output(ch)
+		ch.Interpret(input)
+
+		return suite.output(ch)
+	})
+
+	obj.Set("tick", func(seconds goja.Value) {
+		if seconds == nil || goja.IsUndefined(seconds) {
+			suite.tick(1)
+			return
+		}
+
+		suite.tick(int(seconds.ToInteger()))
+	})
+
+	obj.Set("seed", func(seed int64) {
+		vm.SetRandSource(rand.New(rand.NewSource(seed)).Float64)
+		suite.game.SetCombatSeed(seed)
+	})
+
+	return obj
+}
+
+func (suite *scriptTestSuite) assertObject() goja.Value {
+	vm := suite.game.vm
+
+	message := func(value goja.Value, fallback string) string {
+		if value == nil || goja.IsUndefined(value) {
+			return fallback
+		}
+
+		return value.String()
+	}
+
+	assert := vm.ToValue(func(value goja.Value, text goja.Value) {
+		if !value.ToBoolean() {
+			suite.throw("%s", message(text, "assertion failed"))
+		}
+	}).ToObject(vm)
+
+	assert.Set("equal", func(actual goja.Value, expected goja.Value, text goja.Value) {
+		if !actual.StrictEquals(expected) {
+			suite.throw("%s: got %v, want %v", message(text, "values differ"), actual, expected)
+		}
+	})
+
+	assert.Set("contains", func(haystack string, needle string, text goja.Value) {
+		if !strings.Contains(haystack, needle) {
+			suite.throw("%s: %q does not contain %q", message(text, "missing text"), haystack, needle)
+		}
+	})
+
+	assert.Set("notContains", func(haystack string, needle string, text goja.Value) {
+		if strings.Contains(haystack, needle) {
+			suite.throw("%s: %q contains %q", message(text, "unexpected text"), haystack, needle)
+		}
+	})
+
+	assert.Set("fail", func(text goja.Value) {
+		suite.throw("%s", message(text, "failed"))
+	})
+
+	return assert
+}
+
+func (suite *scriptTestSuite) load() error {
+	vm := suite.game.vm
+
+	vm.Set("fixture", suite.fixtureObject())
+	vm.Set("assert", suite.assertObject())
+	vm.Set("test", func(name string, fn goja.Callable) {
+		suite.tests = append(suite.tests, scriptTestCase{name: name, fn: fn})
+	})
+
+	source, err := os.ReadFile(suite.filename)
+	if err != nil {
+		return err
+	}
+
+	id := strings.TrimSuffix(filepath.Base(suite.filename), ".js")
+	if relative, err := filepath.Rel(suite.game.scriptRoot, suite.filename); err == nil && !strings.HasPrefix(relative, "..") {
+		id = strings.TrimSuffix(filepath.ToSlash(relative), ".js")
+	}
+
+	_, err = suite.game.evaluateScriptModule(id, suite.filename, string(source))
+	return err
+}
+
+/* Find the test suites under each path, which may also name suite files directly */
+func findScriptTestFiles(paths []string) ([]string, error) {
+	files := make([]string, 0)
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(filename string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if filename != root && strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ScriptTestSuffix) {
+				files = append(files, filename)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+/* Indent every line of text under a test result, dropping carriage returns and trailing newlines */
+func scriptTestIndent(text string) string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r", ""), "\n")
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}
+
+/* Run one suite, printing go test style results; game logs are shown for failures or with -v */
+func runScriptTestFile(filename string, options ScriptTestOptions, result *ScriptTestResult) {
+	out := options.Output
+
+	var logs bytes.Buffer
+	if options.Verbose {
+		log.SetOutput(out)
+	} else {
+		log.SetOutput(&logs)
+	}
+
+	started := time.Now()
+
+	game, err := newScriptTestGame(options)
+	if err != nil {
+		result.Failed++
+		fmt.Fprintf(out, "FAIL\t%s\tcould not boot the fixture world: %v\n", filename, err)
+		return
+	}
+	defer game.db.Close()
+
+	suite := &scriptTestSuite{game: game, filename: filename}
+
+	err = suite.load()
+	if err != nil {
+		result.Failed++
+		fmt.Fprintf(out, "FAIL\t%s\n%s\n", filename, scriptTestIndent(scriptErrorDescription(err)))
+		return
+	}
+
+	failed := 0
+
+	for _, test := range suite.tests {
+		if options.Run != nil && !options.Run.MatchString(test.name) {
+			continue
+		}
+
+		if options.Verbose {
+			fmt.Fprintf(out, "=== RUN   %s\n", test.name)
+		}
+
+		logs.Reset()
+		testStarted := time.Now()
+
+		_, err := game.callScriptFunction(ScriptHookTest, "", test.fn, goja.Undefined())
+		elapsed := time.Since(testStarted).Seconds()
+
+		if err != nil {
+			failed++
+			fmt.Fprintf(out, "--- FAIL: %s (%.2fs)\n%s\n", test.name, elapsed, scriptTestIndent(scriptErrorDescription(err)))
+
+			if logs.Len() > 0 {
+				fmt.Fprintf(out, "%s\n", scriptTestIndent(logs.String()))
+			}
+
+			continue
+		}
+
+		result.Passed++
+		if options.Verbose {
+			fmt.Fprintf(out, "--- PASS: %s (%.2fs)\n", test.name, elapsed)
+		}
+	}
+
+	result.Failed += failed
+
+	if failed > 0 {
+		fmt.Fprintf(out, "FAIL\t%s\t%.3fs\n", filename, time.Since(started).Seconds())
+		return
+	}
+
+	fmt.Fprintf(out, "ok  \t%s\t%.3fs\n", filename, time.Since(started).Seconds())
+}
+
+func RunScriptTests(options ScriptTestOptions) (ScriptTestResult, error) {
+	var result ScriptTestResult
+
+	if options.Output == nil {
+		options.Output = os.Stdout
+	}
+
+	paths := options.Paths
+	if len(paths) == 0 {
+		paths = []string{options.ScriptRoot}
+	}
+
+	files, err := findScriptTestFiles(paths)
+	if err != nil {
+		return result, err
+	}
+
+	if len(files) == 0 {
+		return result, errors.New("no script test files found")
+	}
+
+	previousLogOutput := log.Writer()
+	defer log.SetOutput(previousLogOutput)
+
+	for _, filename := range files {
+		runScriptTestFile(filename, options, &result)
+	}
+
+	return result, nil
+}
+
+func runScriptTestsCommand(arguments []string) error {
+	flags := flag.NewFlagSet("test-scripts", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	verbose := flags.Bool("v", false, "")
+	run := flags.String("run", "", "")
+
+	if err := flags.Parse(arguments); err != nil {
+		return errCommandUsage
+	}
+
+	options := ScriptTestOptions{
+		ScriptRoot:    ScriptDirectory,
+		MigrationRoot: "migrations",
+		Paths:         flags.Args(),
+		Verbose:       *verbose,
+	}
+
+	if *run != "" {
+		pattern, err := regexp.Compile(*run)
+		if err != nil {
+			return err
+		}
+
+		options.Run = pattern
+	}
+
+	result, err := RunScriptTests(options)
+	if err != nil {
+		return err
+	}
+
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d script tests failed", result.Failed, result.Failed+result.Passed)
+	}
+
+	return nil
+}
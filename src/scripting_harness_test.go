@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScriptSuitesPass(t *testing.T) {
+	var output strings.Builder
+
+	result, err := RunScriptTests(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+		Output:        &output,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Failed > 0 || result.Passed == 0 {
+		t.Fatalf("%d passed, %d failed:\n%s", result.Passed, result.Failed, output.String())
+	}
+}
+
+func TestScriptTestRunnerReportsFailures(t *testing.T) {
+	suite := filepath.Join(t.TempDir(), "broken.test.js")
+
+	err := os.WriteFile(suite, []byte(`
+		test('passes', () => assert.equal(fixture.room(1).name, 'Limbo'));
+		test('fails', () => assert.contains(fixture.command(fixture.player('Tester'), 'look'), 'Nowhere', 'wrong room'));
+	`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output strings.Builder
+
+	result, err := RunScriptTests(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+		Paths:         []string{suite},
+		Output:        &output,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Passed != 1 || result.Failed != 1 {
+		t.Fatalf("%d passed, %d failed, want 1 and 1:\n%s", result.Passed, result.Failed, output.String())
+	}
+
+	for _, want := range []string{"--- FAIL: fails", "wrong room", "broken.test.js:3"} {
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("output is missing %q:\n%s", want, output.String())
+		}
+	}
+}
@@ -0,0 +1,282 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/metrics"
+	"sort"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+/* Hook types, each of which may be given its own execution budget */
+const (
+	ScriptHookLoad       = "load"
+	ScriptHookExec       = "exec"
+	ScriptHookEvent      = "event"
+	ScriptHookTimer      = "timer"
+	ScriptHookSchedule   = "schedule"
+	ScriptHookSkill      = "skill"
+	ScriptHookSpell      = "spell"
+	ScriptHookCommand    = "command"
+	ScriptHookConnection = "connection"
+	ScriptHookEffect     = "effect"
+	ScriptHookObserver   = "observer"
+	ScriptHookRoom       = "room"
+	ScriptHookObject     = "object"
+	ScriptHookMobile     = "mobile"
+	ScriptHookDistrict   = "district"
+	ScriptHookPlane      = "plane"
+	ScriptHookWebhook    = "webhook"
+	ScriptHookCombat     = "combat"
+	ScriptHookTest       = "test"
+)
+
+const (
+	defaultScriptBudgetMilliseconds    = 250
+	defaultScriptMaxCallStackSize      = 1024
+	defaultScriptMaxAllocationMegabyte = 64
+	defaultScriptMaxViolations         = 3
+
+	scriptAllocationSampleInterval = 10 * time.Millisecond
+	scriptHeapAllocsMetric         = "/gc/heap/allocs:bytes"
+)
+
+/* Budgets used when the configuration does not override a hook type */
+var defaultScriptBudgets = map[string]int{
+	ScriptHookLoad:     5000,
+	ScriptHookExec:     5000,
+	ScriptHookPlane:    5000,
+	ScriptHookWebhook:  1000,
+	ScriptHookDistrict: 500,
+	ScriptHookTest:     10000,
+}
+
+/* Exported script methods are budgeted according to the kind of entity that invokes them */
+var scriptMethodHooks = map[string]string{
+	"onRoomEnter":          ScriptHookRoom,
+	"onRoomLeave":          ScriptHookRoom,
+	"onUse":                ScriptHookObject,
+	"onGreet":              ScriptHookMobile,
+	"onSpeech":             ScriptHookMobile,
+	"onGive":               ScriptHookMobile,
+	"onFight":              ScriptHookMobile,
+	"onDeath":              ScriptHookMobile,
+	"onTick":               ScriptHookMobile,
+	"onRandom":             ScriptHookMobile,
+	"onStart":              ScriptHookDistrict,
+	"onUpdate":             ScriptHookDistrict,
+	"onGET":                ScriptHookWebhook,
+	"onGenerate":           ScriptHookPlane,
+	"onGenerationComplete": ScriptHookPlane,
+}
+
+var errScriptDisabled = errors.New("disabled after repeatedly exceeding its limits")
+
+type scriptLimitExceeded struct {
+	hook   string
+	reason string
+}
+
+func (limit *scriptLimitExceeded) Error() string {
+	return fmt.Sprintf("%s handler %s", limit.hook, limit.reason)
+}
+
+func scriptBudget(hook string) time.Duration {
+	milliseconds, ok := Config.ScriptingConfiguration.BudgetMilliseconds[hook]
+	if !ok {
+		milliseconds, ok = defaultScriptBudgets[hook]
+	}
+
+	if !ok {
+		milliseconds = Config.ScriptingConfiguration.DefaultBudgetMilliseconds
+	}
+
+	return time.Duration(milliseconds) * time.Millisecond
+}
+
+func scriptMethodHook(methodName string) string {
+	hook, ok := scriptMethodHooks[methodName]
+	if !ok {
+		return ScriptHookEvent
+	}
+
+	return hook
+}
+
+func (script *Script) limitKey() string {
+	return fmt.Sprintf("script %d (%s)", script.Id, script.Name)
+}
+
+func heapAllocatedBytes() uint64 {
+	sample := []metrics.Sample{{Name: scriptHeapAllocsMetric}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
+
+/*
+ * Interrupt the VM if the invocation overruns its budget or allocates past the
+ * configured ceiling.  goja cannot attribute allocations to a script, so the
+ * ceiling is measured against the whole process' heap allocations while the
+ * script runs.  The default sits well above what the rest of the server
+ * allocates in a handler's budget but below what a runaway loop building
+ * arrays or strings reaches in a fraction of a second; 0 disables it.
+ */
+func (game *Game) watchScript(hook string, budget time.Duration, done <-chan struct{}, finished chan<- struct{}) {
+	defer close(finished)
+
+	var deadline <-chan time.Time
+	if budget > 0 {
+		timer := time.NewTimer(budget)
+		defer timer.Stop()
+
+		deadline = timer.C
+	}
+
+	var sample <-chan time.Time
+	allocationLimit := uint64(Config.ScriptingConfiguration.MaxAllocationMegabytes) << 20
+	allocatedAtStart := heapAllocatedBytes()
+	if allocationLimit > 0 {
+		ticker := time.NewTicker(scriptAllocationSampleInterval)
+		defer ticker.Stop()
+
+		sample = ticker.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case <-deadline:
+			game.vm.Interrupt(&scriptLimitExceeded{hook: hook, reason: fmt.Sprintf("exceeded its %v time budget", budget)})
+			return
+
+		case <-sample:
+			allocated := heapAllocatedBytes() - allocatedAtStart
+			if allocated > allocationLimit {
+				game.vm.Interrupt(&scriptLimitExceeded{hook: hook, reason: fmt.Sprintf("allocated more than %d MB", Config.ScriptingConfiguration.MaxAllocationMegabytes)})
+				return
+			}
+		}
+	}
+}
+
+/*
+ * Run a piece of script under the budget for its hook type.  Handlers reached
+ * from inside another script run under the outermost caller's watchdog.  A key
+ * identifies the handler for violation tracking; handlers with an empty key are
+ * interrupted but never disabled.
+ */
+func (game *Game) runScriptLimited(hook string, key string, run func() (goja.Value, error)) (goja.Value, error) {
+	if key != "" && game.disabledScripts[key] {
+		return nil, fmt.Errorf("%s: %w", key, errScriptDisabled)
+	}
+
+	if game.scriptDepth > 0 {
+		game.scriptDepth++
+		defer func() {
+			game.scriptDepth--
+		}()
+
+		return run()
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	game.scriptDepth++
+	go game.watchScript(hook, scriptBudget(hook), done, finished)
+
+	result, err := run()
+
+	close(done)
+	<-finished
+
+	game.vm.ClearInterrupt()
+	game.scriptDepth--
+
+	if err != nil {
+		game.checkScriptViolation(hook, key, err)
+	}
+
+	return result, err
+}
+
+func (game *Game) callScriptFunction(hook string, key string, fn goja.Callable, this goja.Value, arguments ...goja.Value) (goja.Value, error) {
+	return game.runScriptLimited(hook, key, func() (goja.Value, error) {
+		return fn(this, arguments...)
+	})
+}
+
+func (game *Game) checkScriptViolation(hook string, key string, err error) {
+	var reason string
+
+	var interrupted *goja.InterruptedError
+	var overflow *goja.StackOverflowError
+
+	switch {
+	case errors.As(err, &interrupted):
+		limit, ok := interrupted.Value().(*scriptLimitExceeded)
+		if !ok {
+			return
+		}
+
+		reason = limit.reason
+
+	case errors.As(err, &overflow):
+		reason = fmt.Sprintf("exceeded the maximum call stack size of %d", Config.ScriptingConfiguration.MaxCallStackSize)
+
+	default:
+		return
+	}
+
+	if key == "" {
+		log.Printf("Script %s handler %s.\r\n", hook, reason)
+		return
+	}
+
+	game.scriptViolations[key]++
+	count := game.scriptViolations[key]
+
+	log.Printf("Script %s %s (%d of %d violations).\r\n", key, reason, count, Config.ScriptingConfiguration.MaxViolations)
+
+	if count < Config.ScriptingConfiguration.MaxViolations {
+		return
+	}
+
+	game.disabledScripts[key] = true
+
+	out := fmt.Sprintf("Script %s has been disabled after %d violations; it last %s.\r\n", key, count, reason)
+	log.Print(out)
+	game.broadcast(out, WiznetBroadcastFilter)
+}
+
+func (game *Game) resetScriptViolations() {
+	game.scriptViolations = make(map[string]int)
+	game.disabledScripts = make(map[string]bool)
+}
+
+func (game *Game) disabledScriptKeys() []string {
+	keys := make([]string, 0, len(game.disabledScripts))
+	for key := range game.disabledScripts {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
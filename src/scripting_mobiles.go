@@ -0,0 +1,208 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/dop251/goja"
+)
+
+/* Exported functions a mobile script may define, invoked with the mobile as this */
+const (
+	MobileTriggerGreet  = "onGreet"
+	MobileTriggerSpeech = "onSpeech"
+	MobileTriggerGive   = "onGive"
+	MobileTriggerFight  = "onFight"
+	MobileTriggerDeath  = "onDeath"
+	MobileTriggerTick   = "onTick"
+	MobileTriggerRandom = "onRandom"
+)
+
+/* Percent chance per update that onRandom fires, unless the script exports randomChance */
+const defaultMobileRandomChance = 10
+
+func (game *Game) mobileScript(ch *Character) *Script {
+	if ch == nil || ch.Flags&CHAR_IS_PLAYER != 0 {
+		return nil
+	}
+
+	return game.mobileScripts[uint(ch.Id)]
+}
+
+func (ch *Character) hasMobileTrigger(trigger string) bool {
+	return ch.Game != nil && ch.Game.mobileScript(ch).exportsFunction(trigger)
+}
+
+func (ch *Character) fireMobileTrigger(trigger string, arguments ...goja.Value) goja.Value {
+	if !ch.hasMobileTrigger(trigger) {
+		return nil
+	}
+
+	script := ch.Game.mobileScript(ch)
+	result, err := script.tryEvaluate(trigger, ch.Game.vm.ToValue(ch), arguments...)
+	if err != nil {
+		log.Printf("Mobile %d script %d %s failed: %v\r\n", ch.Id, script.Id, trigger, err)
+		return nil
+	}
+
+	return result
+}
+
+/* Fired for scripted mobiles who notice a player arriving in their room */
+func (game *Game) mobileGreetTriggers(ch *Character, room *Room) {
+	if ch == nil || room == nil || ch.Flags&CHAR_IS_PLAYER == 0 {
+		return
+	}
+
+	for _, mob := range room.Characters.Values() {
+		if mob == ch || mob.Room != room || mob.Fighting != nil || mob.Position <= PositionSleeping {
+			continue
+		}
+
+		if !mob.hasMobileTrigger(MobileTriggerGreet) || !ch.Visible(mob) {
+			continue
+		}
+
+		mob.fireMobileTrigger(MobileTriggerGreet, game.vm.ToValue(ch))
+	}
+}
+
+/* Only player speech is heard, so that mobiles speaking in response cannot loop */
+func (game *Game) mobileSpeechTriggers(ch *Character, text string) {
+	if ch == nil || ch.Room == nil || ch.Flags&CHAR_IS_PLAYER == 0 {
+		return
+	}
+
+	room := ch.Room
+	for _, mob := range room.Characters.Values() {
+		if mob == ch || mob.Room != room || mob.Position <= PositionSleeping {
+			continue
+		}
+
+		script := game.mobileScript(mob)
+		if !script.exportsFunction(MobileTriggerSpeech) {
+			continue
+		}
+
+		keyword, ok := matchSpeechKeywords(mobileSpeechKeywords(script), text)
+		if !ok {
+			continue
+		}
+
+		mob.fireMobileTrigger(MobileTriggerSpeech, game.vm.ToValue(ch), game.vm.ToValue(text), game.vm.ToValue(keyword))
+	}
+}
+
+/* A script may export speechKeywords as a string or array of strings to filter what it reacts to */
+func mobileSpeechKeywords(script *Script) []string {
+	value := script.Exports.Get("speechKeywords")
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil
+	}
+
+	var keywords []string
+	switch exported := value.Export().(type) {
+	case string:
+		keywords = append(keywords, exported)
+
+	case []interface{}:
+		for _, keyword := range exported {
+			if s, ok := keyword.(string); ok {
+				keywords = append(keywords, s)
+			}
+		}
+	}
+
+	return keywords
+}
+
+func speechWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+/*
+ * Match speech against a list of keywords or phrases, ignoring case and
+ * punctuation.  Keywords match whole words; a phrase matches a run of words.
+ * No keywords at all matches any speech.
+ */
+func matchSpeechKeywords(keywords []string, text string) (string, bool) {
+	if len(keywords) == 0 {
+		return "", true
+	}
+
+	words := speechWords(text)
+
+	for _, keyword := range keywords {
+		phrase := speechWords(keyword)
+		if len(phrase) == 0 {
+			continue
+		}
+
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			matched := true
+
+			for j, word := range phrase {
+				if words[i+j] != word {
+					matched = false
+					break
+				}
+			}
+
+			if matched {
+				return keyword, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (game *Game) mobileFightTriggers() {
+	for _, combat := range game.Fights.Values() {
+		for _, mob := range append([]*Character(nil), combat.Participants...) {
+			if mob == nil || mob.Fighting == nil || mob.Room == nil || mob.Combat != combat {
+				continue
+			}
+
+			mob.fireMobileTrigger(MobileTriggerFight, game.vm.ToValue(mob.Fighting))
+		}
+	}
+}
+
+func (game *Game) mobileTickTriggers() {
+	for _, mob := range game.Characters.Values() {
+		if mob.Room == nil || game.mobileScript(mob) == nil {
+			continue
+		}
+
+		mob.fireMobileTrigger(MobileTriggerTick)
+
+		if mob.Room == nil || !mob.hasMobileTrigger(MobileTriggerRandom) {
+			continue
+		}
+
+		if rand.Intn(100) < mobileRandomChance(game.mobileScript(mob)) {
+			mob.fireMobileTrigger(MobileTriggerRandom)
+		}
+	}
+}
+
+func mobileRandomChance(script *Script) int {
+	value := script.Exports.Get("randomChance")
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return defaultMobileRandomChance
+	}
+
+	return int(value.ToInteger())
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+const ScriptDirectory = "scripts"
+
+/* Prefix distinguishing modules sourced from the scripts table from files on disk */
+const scriptModuleDatabasePrefix = "db:"
+
+type ScriptModule struct {
+	Id       string       `json:"id"`
+	Filename string       `json:"filename"`
+	Module   *goja.Object `json:"module"`
+
+	loaded bool
+}
+
+/*
+ * Resolve a require() request to a module id relative to the script root.
+ * Relative requests ("./x", "../x") resolve against the requiring module.
+ */
+func resolveScriptModuleId(from string, request string) (string, error) {
+	request = strings.TrimSuffix(strings.TrimSpace(request), ".js")
+	if request == "" {
+		return "", errors.New("require() needs a module name")
+	}
+
+	if strings.HasPrefix(request, "./") || strings.HasPrefix(request, "../") {
+		request = path.Join(path.Dir(from), request)
+	}
+
+	id := path.Clean(request)
+	if id == "." || id == ".." || strings.HasPrefix(id, "../") || path.IsAbs(id) {
+		return "", fmt.Errorf("module %q resolves outside of the script directory", request)
+	}
+
+	return id, nil
+}
+
+func (game *Game) clearScriptModules() {
+	game.modules = make(map[string]*ScriptModule)
+	game.moduleStack = nil
+}
+
+/* Forget cached modules evaluated from a database script so the next require() sees new source */
+func (game *Game) invalidateDatabaseScriptModule(name string) {
+	for id, module := range game.modules {
+		if strings.EqualFold(module.Filename, scriptModuleDatabasePrefix+name) {
+			delete(game.modules, id)
+		}
+	}
+}
+
+/* Files under the script root take precedence over scripts table entries of the same name */
+func (game *Game) findScriptModuleSource(id string) (string, string, error) {
+	root := game.scriptRoot
+	if root == "" {
+		root = ScriptDirectory
+	}
+
+	base := filepath.Join(root, filepath.FromSlash(id))
+	for _, filename := range []string{base + ".js", filepath.Join(base, "index.js")} {
+		source, err := os.ReadFile(filename)
+		if err == nil {
+			return string(source), filename, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", "", err
+		}
+	}
+
+	if game.db != nil {
+		var name, source string
+
+		err := game.db.QueryRow(`
+			SELECT
+				name,
+				script
+			FROM
+				scripts
+			WHERE
+				name = ? COLLATE NOCASE
+		`, id).Scan(&name, &source)
+		if err == nil {
+			return source, scriptModuleDatabasePrefix + name, nil
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return "", "", err
+		}
+	}
+
+	return "", "", fmt.Errorf("cannot find module '%s'", id)
+}
+
+func (game *Game) requireScriptModule(from string, request string) (goja.Value, error) {
+	id, err := resolveScriptModuleId(from, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.modules == nil {
+		game.clearScriptModules()
+	}
+
+	module, ok := game.modules[id]
+	if ok {
+		if !module.loaded {
+			return nil, fmt.Errorf("require cycle: %s -> %s", strings.Join(game.moduleStack, " -> "), id)
+		}
+
+		return module.Module.Get("exports"), nil
+	}
+
+	source, filename, err := game.findScriptModuleSource(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return game.evaluateScriptModule(id, filename, source)
+}
+
+func compileScriptModule(filename string, source string) (*goja.Program, error) {
+	return goja.Compile(filename, "(function(exports, require, module, __filename, __dirname) {"+source+"\n})", false)
+}
+
+/* The module whose top-level code is running, which owns any handlers it registers */
+func (game *Game) currentScriptModule() string {
+	if len(game.moduleStack) == 0 {
+		return ""
+	}
+
+	return game.moduleStack[len(game.moduleStack)-1]
+}
+
+/* Module id for a file under root, e.g. "magic/fireball" or "core" for core/index.js */
+func scriptModuleIdFromPath(root string, filename string) (string, error) {
+	relative, err := filepath.Rel(root, filename)
+	if err != nil {
+		return "", err
+	}
+
+	id := strings.TrimSuffix(filepath.ToSlash(relative), ".js")
+	if path.Base(id) == "index" && path.Dir(id) != "." {
+		id = path.Dir(id)
+	}
+
+	return id, nil
+}
+
+/* Evaluate source with a CommonJS wrapper and cache its module object under id */
+func (game *Game) evaluateScriptModule(id string, filename string, source string) (goja.Value, error) {
+	vm := game.vm
+
+	moduleObj := vm.NewObject()
+	exports := vm.NewObject()
+	moduleObj.Set("id", id)
+	moduleObj.Set("filename", filename)
+	moduleObj.Set("exports", exports)
+
+	/* Relative requires from a directory's index.js resolve inside that directory */
+	from := id
+	if filepath.Base(filename) == "index.js" && path.Base(id) != "index" {
+		from = path.Join(id, "index")
+	}
+
+	module := &ScriptModule{Id: id, Filename: filename, Module: moduleObj}
+	game.modules[id] = module
+
+	game.moduleStack = append(game.moduleStack, id)
+	defer func() {
+		game.moduleStack = game.moduleStack[:len(game.moduleStack)-1]
+	}()
+
+	program, err := compileScriptModule(filename, source)
+	if err != nil {
+		delete(game.modules, id)
+		return nil, err
+	}
+
+	wrapper, err := game.runScriptLimited(ScriptHookLoad, "", func() (goja.Value, error) {
+		return vm.RunProgram(program)
+	})
+	if err != nil {
+		delete(game.modules, id)
+		return nil, err
+	}
+
+	fn, ok := goja.AssertFunction(wrapper)
+	if !ok {
+		delete(game.modules, id)
+		return nil, fmt.Errorf("module %s did not compile to a function", id)
+	}
+
+	_, err = game.withStoreOwner(id, func() (goja.Value, error) {
+		return game.callScriptFunction(ScriptHookLoad, "", fn, exports, exports, game.requireFunction(from), moduleObj, vm.ToValue(filename), vm.ToValue(path.Dir(from)))
+	})
+	if err != nil {
+		delete(game.modules, id)
+		return nil, err
+	}
+
+	module.loaded = true
+	return moduleObj.Get("exports"), nil
+}
+
+/* Build the require() bound to a module, throwing script errors rather than returning them */
+func (game *Game) requireFunction(from string) goja.Value {
+	return game.vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		exports, err := game.requireScriptModule(from, call.Argument(0).String())
+		if err != nil {
+			var exception *goja.Exception
+			if errors.As(err, &exception) {
+				panic(exception.Value())
+			}
+
+			panic(game.vm.NewGoError(err))
+		}
+
+		return exports
+	})
+}
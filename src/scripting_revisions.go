@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/* One saved version of a database script's source */
+type ScriptRevision struct {
+	Id        int       `json:"id"`
+	ScriptId  uint      `json:"scriptId"`
+	Revision  int       `json:"revision"`
+	Author    string    `json:"author"`
+	Script    string    `json:"script"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func scriptRevisionAuthor(author string) interface{} {
+	if author == "" {
+		return nil
+	}
+
+	return author
+}
+
+func insertScriptRevision(tx *sql.Tx, scriptId uint, source string, author string) (*ScriptRevision, error) {
+	revision := &ScriptRevision{ScriptId: scriptId, Author: author, Script: source}
+
+	var createdAt int64
+
+	err := tx.QueryRow(`
+		INSERT INTO
+			script_revisions(script_id, revision, author, script)
+		VALUES
+			(?, (SELECT COALESCE(MAX(revision), 0) + 1 FROM script_revisions WHERE script_id = ?), ?, ?)
+		RETURNING
+			id,
+			revision,
+			CAST(strftime('%s', created_at) AS INTEGER)
+	`, scriptId, scriptId, scriptRevisionAuthor(author), source).Scan(&revision.Id, &revision.Revision, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	revision.CreatedAt = time.Unix(createdAt, 0)
+	return revision, nil
+}
+
+/*
+ * Persist new source for a script, recording it as the next revision.  Source
+ * that does not compile is rejected before anything is written, and saving
+ * unchanged source keeps the latest revision rather than adding a duplicate.
+ */
+func (script *Script) Commit(source string, author string) (*ScriptRevision, error) {
+	_, err := script.Game.compileScript(script.Name, source)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	tx, err := script.Game.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE
+			scripts
+		SET
+			name = ?,
+			script = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = ?
+	`, script.Name, source, script.Id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	latest, err := script.latestRevision(tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	revision := latest
+	if latest == nil || latest.Script != source {
+		revision, err = insertScriptRevision(tx, script.Id, source, author)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	script.Script = source
+
+	/* New source gets a clean slate against the execution limits */
+	delete(script.Game.disabledScripts, script.limitKey())
+	delete(script.Game.scriptViolations, script.limitKey())
+	script.Game.invalidateDatabaseScriptModule(script.Name)
+
+	return revision, nil
+}
+
+func (script *Script) latestRevision(tx *sql.Tx) (*ScriptRevision, error) {
+	revision := &ScriptRevision{ScriptId: script.Id}
+
+	var author sql.NullString
+	var createdAt int64
+
+	err := tx.QueryRow(`
+		SELECT
+			id,
+			revision,
+			author,
+			script,
+			CAST(strftime('%s', created_at) AS INTEGER)
+		FROM
+			script_revisions
+		WHERE
+			script_id = ?
+		ORDER BY
+			revision DESC
+		LIMIT 1
+	`, script.Id).Scan(&revision.Id, &revision.Revision, &author, &revision.Script, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	revision.Author = author.String
+	revision.CreatedAt = time.Unix(createdAt, 0)
+	return revision, nil
+}
+
+/* Every revision of the script, newest first */
+func (script *Script) Revisions() ([]*ScriptRevision, error) {
+	rows, err := script.Game.db.Query(`
+		SELECT
+			id,
+			revision,
+			author,
+			script,
+			CAST(strftime('%s', created_at) AS INTEGER)
+		FROM
+			script_revisions
+		WHERE
+			script_id = ?
+		ORDER BY
+			revision DESC
+	`, script.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := make([]*ScriptRevision, 0)
+
+	for rows.Next() {
+		revision := &ScriptRevision{ScriptId: script.Id}
+
+		var author sql.NullString
+		var createdAt int64
+
+		err := rows.Scan(&revision.Id, &revision.Revision, &author, &revision.Script, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revision.Author = author.String
+		revision.CreatedAt = time.Unix(createdAt, 0)
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (script *Script) Revision(number int) (*ScriptRevision, error) {
+	revision := &ScriptRevision{ScriptId: script.Id, Revision: number}
+
+	var author sql.NullString
+	var createdAt int64
+
+	err := script.Game.db.QueryRow(`
+		SELECT
+			id,
+			author,
+			script,
+			CAST(strftime('%s', created_at) AS INTEGER)
+		FROM
+			script_revisions
+		WHERE
+			script_id = ?
+			AND revision = ?
+	`, script.Id, number).Scan(&revision.Id, &author, &revision.Script, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("script %d has no revision %d", script.Id, number)
+	} else if err != nil {
+		return nil, err
+	}
+
+	revision.Author = author.String
+	revision.CreatedAt = time.Unix(createdAt, 0)
+	return revision, nil
+}
+
+/* Restore an earlier revision's source as a new revision, so the rollback itself shows in history */
+func (script *Script) Rollback(number int, author string) (*ScriptRevision, error) {
+	target, err := script.Revision(number)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := script.Commit(target.Script, author)
+	if err != nil {
+		return nil, err
+	}
+
+	exports, err := script.GetExports()
+	if err != nil {
+		return revision, err
+	}
+
+	script.Exports = exports
+	return revision, nil
+}
+
+func scriptRevisionAuthorDescription(author string) string {
+	if author == "" {
+		return "unknown"
+	}
+
+	return author
+}
+
+func (script *Script) historyDescription() (string, error) {
+	revisions, err := script.Revisions()
+	if err != nil {
+		return "", err
+	}
+
+	if len(revisions) == 0 {
+		return fmt.Sprintf("Script %d has no recorded revisions.\r\n", script.Id), nil
+	}
+
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("{WHistory of script %s (%d):\r\n", script.Name, script.Id))
+	output.WriteString("{Y  Rev | Author       | Saved            | Lines\r\n")
+	output.WriteString("------+--------------+------------------+------\r\n")
+
+	/* A rollback leaves older revisions with the same source; only the newest is current */
+	current := false
+
+	for _, revision := range revisions {
+		marker := ""
+		if !current && revision.Script == script.Script {
+			marker = " {G(current){y"
+			current = true
+		}
+
+		output.WriteString(fmt.Sprintf("{y%5d | %-12s | %-16s | %5d%s\r\n",
+			revision.Revision,
+			scriptRevisionAuthorDescription(revision.Author),
+			revision.CreatedAt.Format("2006-01-02 15:04"),
+			strings.Count(revision.Script, "\n")+1,
+			marker))
+	}
+
+	output.WriteString("{x")
+	return output.String(), nil
+}
+
+func scriptDiffDescription(fromName string, from string, toName string, to string) string {
+	hunks := DiffHunks(DiffLines(strings.Split(from, "\n"), strings.Split(to, "\n")), 3)
+	if len(hunks) == 0 {
+		return fmt.Sprintf("No differences between %s and %s.\r\n", fromName, toName)
+	}
+
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("{R--- %s\r\n{G+++ %s\r\n", fromName, toName))
+
+	for _, hunk := range hunks {
+		output.WriteString(fmt.Sprintf("{C@@ -%d,%d +%d,%d @@\r\n", hunk.FromLine, hunk.FromCount, hunk.ToLine, hunk.ToCount))
+
+		for _, line := range hunk.Lines {
+			text := escapeColourCodes(line.Text)
+
+			switch line.Operation {
+			case DiffDelete:
+				output.WriteString("{R-" + text + "\r\n")
+			case DiffInsert:
+				output.WriteString("{G+" + text + "\r\n")
+			default:
+				output.WriteString("{x " + text + "\r\n")
+			}
+		}
+	}
+
+	output.WriteString("{x")
+	return output.String()
+}
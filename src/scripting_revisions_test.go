@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+func TestScriptRevisionsCommitAndRollback(t *testing.T) {
+	db, err := sql.Open(databaseDriverSQLite, filepath.Join(t.TempDir(), "revisions.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	scriptsMigration, err := os.ReadFile(filepath.Join("..", "migrations", "6_create_scripts_table.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revisionsMigration, err := os.ReadFile(filepath.Join("..", "migrations", "23_create_script_revisions.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* Existing scripts are backfilled as their first revision */
+	statements := []string{
+		string(scriptsMigration),
+		`INSERT INTO scripts (id, name, script) VALUES (10, 'greeter', 'module.exports = { greeting: "hi" };')`,
+		string(revisionsMigration),
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	game := &Game{vm: goja.New(), db: db}
+	script := &Script{Id: 10, Name: "greeter", Script: `module.exports = { greeting: "hi" };`, Game: game}
+
+	if _, err := script.Commit("module.exports = {", "alice"); err == nil {
+		t.Fatal("expected source that does not compile to be rejected")
+	}
+
+	var stored string
+	if err := db.QueryRow(`SELECT script FROM scripts WHERE id = 10`).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored != script.Script {
+		t.Fatalf("rejected source was persisted: %q", stored)
+	}
+
+	revision, err := script.Commit(`module.exports = { greeting: "hello" };`, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if revision.Revision != 2 || revision.Author != "alice" {
+		t.Fatalf("commit = revision %d by %q, want revision 2 by alice", revision.Revision, revision.Author)
+	}
+
+	unchanged, err := script.Commit(script.Script, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if unchanged.Revision != 2 {
+		t.Fatalf("saving unchanged source recorded revision %d", unchanged.Revision)
+	}
+
+	rolledBack, err := script.Rollback(1, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rolledBack.Revision != 3 || script.Script != `module.exports = { greeting: "hi" };` {
+		t.Fatalf("rollback = revision %d with %q", rolledBack.Revision, script.Script)
+	}
+
+	if got := script.Exports.Get("greeting").String(); got != "hi" {
+		t.Fatalf("exports after rollback = %q, want hi", got)
+	}
+
+	revisions, err := script.Revisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authors := ""
+	for _, revision := range revisions {
+		authors += scriptRevisionAuthorDescription(revision.Author) + ","
+	}
+
+	if authors != "bob,alice,unknown," {
+		t.Fatalf("revision authors newest first = %q", authors)
+	}
+}
+
+func TestScriptDiffsShowColourCodesAsSource(t *testing.T) {
+	diff := scriptDiffDescription("revision 1", `ch.send("hi");`, "current", `ch.send("{Rhi{x");`)
+
+	for _, want := range []string{`-ch.send("hi");`, `+ch.send("{Rhi{x");`} {
+		if got := stripColourCodes(diff); !strings.Contains(got, want) {
+			t.Errorf("diff %q does not show %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"log"
+
+	"github.com/dop251/goja"
+)
+
+/* Exported functions a room script may define, invoked with the room as this */
+const (
+	RoomTriggerEnter   = "onRoomEnter"
+	RoomTriggerLeave   = "onRoomLeave"
+	RoomTriggerSay     = "onSay"
+	RoomTriggerLook    = "onLook"
+	RoomTriggerDrop    = "onDrop"
+	RoomTriggerTake    = "onTake"
+	RoomTriggerCommand = "onCommand"
+)
+
+func (room *Room) fireRoomTrigger(trigger string, arguments ...interface{}) goja.Value {
+	if room == nil || room.Game == nil || !room.script.exportsFunction(trigger) {
+		return nil
+	}
+
+	values := make([]goja.Value, len(arguments))
+	for i, argument := range arguments {
+		values[i] = room.Game.vm.ToValue(argument)
+	}
+
+	script := room.script
+	result, err := script.tryEvaluate(trigger, room.Game.vm.ToValue(room), values...)
+	if err != nil {
+		log.Printf("Room %d script %d %s failed: %v\r\n", room.Id, script.Id, trigger, err)
+		return nil
+	}
+
+	return result
+}
+
+/*
+ * Give the room a chance to intercept a command before it is interpreted.
+ * onCommand may return false to swallow the command, or a string to run in
+ * its place; any other result lets the command through unchanged.
+ */
+func (room *Room) interceptCommand(ch *Character, command string, arguments string) (string, bool) {
+	input := command
+	if arguments != "" {
+		input = command + " " + arguments
+	}
+
+	result := room.fireRoomTrigger(RoomTriggerCommand, ch, command, arguments)
+	if result == nil || goja.IsUndefined(result) || goja.IsNull(result) {
+		return input, true
+	}
+
+	switch replacement := result.Export().(type) {
+	case bool:
+		return input, replacement
+
+	case string:
+		return replacement, true
+	}
+
+	return input, true
+}
+
+/* onLook may return true to replace the default look output */
+func (room *Room) lookHandled(ch *Character, arguments string) bool {
+	result := room.fireRoomTrigger(RoomTriggerLook, ch, arguments)
+	if result == nil {
+		return false
+	}
+
+	return result.ToBoolean()
+}
@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+const scriptScheduleTimestampFormat = "2006-01-02 15:04:05"
+
+/*
+ * A named cron job registered by a script.  The row survives reloads and
+ * restarts; the callback is rebound whenever a script registers the name again.
+ */
+type ScriptSchedule struct {
+	Id         int       `json:"id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Paused     bool      `json:"paused"`
+	NextRunAt  time.Time `json:"nextRunAt"`
+	LastRunAt  time.Time `json:"lastRunAt"`
+
+	schedule *CronSchedule
+	callback goja.Callable
+}
+
+func scriptScheduleTimestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.UTC().Format(scriptScheduleTimestampFormat)
+}
+
+func scriptScheduleTimeFromUnix(unix sql.NullInt64) time.Time {
+	if !unix.Valid {
+		return time.Time{}
+	}
+
+	return time.Unix(unix.Int64, 0)
+}
+
+func (job *ScriptSchedule) state() string {
+	switch {
+	case job.callback == nil:
+		return "unbound"
+	case job.Paused:
+		return "paused"
+	default:
+		return "active"
+	}
+}
+
+/* Forget every callback and reload schedules from the database, ready for scripts to register again */
+func (game *Game) LoadScriptSchedules() error {
+	game.scriptSchedules = make(map[string]*ScriptSchedule)
+
+	rows, err := game.db.Query(`
+		SELECT
+			id,
+			name,
+			cron_expression,
+			paused,
+			CAST(strftime('%s', next_run_at) AS INTEGER),
+			CAST(strftime('%s', last_run_at) AS INTEGER)
+		FROM
+			script_schedules
+	`)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		job := &ScriptSchedule{}
+
+		var nextRunAt, lastRunAt sql.NullInt64
+
+		err := rows.Scan(&job.Id, &job.Name, &job.Expression, &job.Paused, &nextRunAt, &lastRunAt)
+		if err != nil {
+			return err
+		}
+
+		job.NextRunAt = scriptScheduleTimeFromUnix(nextRunAt)
+		job.LastRunAt = scriptScheduleTimeFromUnix(lastRunAt)
+
+		job.schedule, err = ParseCronSchedule(job.Expression)
+		if err != nil {
+			log.Printf("Ignoring script schedule %s: %v.\r\n", job.Name, err)
+			continue
+		}
+
+		game.scriptSchedules[job.Name] = job
+	}
+
+	return rows.Err()
+}
+
+/* Register fn under name; an unchanged expression keeps its persisted next run time */
+func (game *Game) ScheduleScript(expression string, name string, fn goja.Callable) (*ScriptSchedule, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("scheduled jobs need a name")
+	}
+
+	schedule, err := ParseCronSchedule(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if game.scriptSchedules == nil {
+		game.scriptSchedules = make(map[string]*ScriptSchedule)
+	}
+
+	job, ok := game.scriptSchedules[name]
+	if ok && job.Expression == schedule.Expression && !job.NextRunAt.IsZero() {
+		job.callback = fn
+		return job, nil
+	}
+
+	if !ok {
+		job = &ScriptSchedule{Name: name}
+	}
+
+	job.Expression = schedule.Expression
+	job.NextRunAt = schedule.Next(time.Now())
+	job.schedule = schedule
+	job.callback = fn
+
+	err = game.db.QueryRow(`
+		INSERT INTO
+			script_schedules(name, cron_expression, next_run_at)
+		VALUES
+			(?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			cron_expression = excluded.cron_expression,
+			next_run_at = excluded.next_run_at,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING
+			id,
+			paused
+	`, job.Name, job.Expression, scriptScheduleTimestamp(job.NextRunAt)).Scan(&job.Id, &job.Paused)
+	if err != nil {
+		return nil, err
+	}
+
+	game.scriptSchedules[name] = job
+	return job, nil
+}
+
+func (game *Game) UnscheduleScript(name string) error {
+	job, ok := game.scriptSchedules[name]
+	if !ok {
+		return fmt.Errorf("no scheduled job named %s", name)
+	}
+
+	_, err := game.db.Exec(`
+		DELETE FROM
+			script_schedules
+		WHERE
+			id = ?
+	`, job.Id)
+	if err != nil {
+		return err
+	}
+
+	delete(game.scriptSchedules, name)
+	return nil
+}
+
+/* Resuming starts counting from now rather than firing for every run missed while paused */
+func (game *Game) SetScriptSchedulePaused(name string, paused bool) error {
+	job, ok := game.scriptSchedules[name]
+	if !ok {
+		return fmt.Errorf("no scheduled job named %s", name)
+	}
+
+	nextRunAt := job.NextRunAt
+	if !paused {
+		nextRunAt = job.schedule.Next(time.Now())
+	}
+
+	_, err := game.db.Exec(`
+		UPDATE
+			script_schedules
+		SET
+			paused = ?,
+			next_run_at = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE
+			id = ?
+	`, paused, scriptScheduleTimestamp(nextRunAt), job.Id)
+	if err != nil {
+		return err
+	}
+
+	job.Paused = paused
+	job.NextRunAt = nextRunAt
+	return nil
+}
+
+func (game *Game) sortedScriptSchedules() []*ScriptSchedule {
+	jobs := make([]*ScriptSchedule, 0, len(game.scriptSchedules))
+	for _, job := range game.scriptSchedules {
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+
+	return jobs
+}
+
+/* Run every bound, unpaused job that has come due; runs missed while down are caught up once */
+func (game *Game) scriptSchedulesUpdate() {
+	now := time.Now()
+
+	for _, job := range game.sortedScriptSchedules() {
+		if job.callback == nil || job.Paused || job.NextRunAt.IsZero() || job.NextRunAt.After(now) {
+			continue
+		}
+
+		_, err := game.callScriptFunction(ScriptHookSchedule, "schedule "+job.Name, job.callback, game.vm.ToValue(job))
+		if err != nil {
+			logScriptHandlerError(fmt.Sprintf("scheduled job %q", job.Name), err)
+		}
+
+		job.LastRunAt = now
+		job.NextRunAt = job.schedule.Next(now)
+
+		_, err = game.db.Exec(`
+			UPDATE
+				script_schedules
+			SET
+				last_run_at = ?,
+				next_run_at = ?,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE
+				id = ?
+		`, scriptScheduleTimestamp(job.LastRunAt), scriptScheduleTimestamp(job.NextRunAt), job.Id)
+		if err != nil {
+			log.Printf("Failed to persist script schedule %s: %v.\r\n", job.Name, err)
+		}
+	}
+}
+
+func scriptScheduleTimeDescription(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	return t.Format("2006-01-02 15:04")
+}
+
+func (game *Game) scriptSchedulesDescription() string {
+	jobs := game.sortedScriptSchedules()
+	if len(jobs) == 0 {
+		return "No jobs are scheduled.\r\n"
+	}
+
+	var output strings.Builder
+
+	output.WriteString("{YName                 | Schedule        | State   | Next run         | Last run\r\n")
+	output.WriteString("---------------------+-----------------+---------+------------------+-----------------\r\n")
+
+	for _, job := range jobs {
+		output.WriteString(fmt.Sprintf("{y%-20s | %-15s | %-7s | %-16s | %s\r\n",
+			job.Name,
+			job.Expression,
+			job.state(),
+			scriptScheduleTimeDescription(job.NextRunAt),
+			scriptScheduleTimeDescription(job.LastRunAt)))
+	}
+
+	output.WriteString("{x")
+	return output.String()
+}
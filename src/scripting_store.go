@@ -0,0 +1,303 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+const (
+	ScriptStoreGlobal    = "global"
+	ScriptStoreScript    = "script"
+	ScriptStoreCharacter = "character"
+)
+
+const (
+	scriptStoreMaxKeyLength   = 255
+	scriptStoreMaxValueLength = 64 * 1024
+)
+
+/* A namespace of persistent JSON values belonging to the world, a script or a player */
+type ScriptStore struct {
+	Game  *Game  `json:"-"`
+	Scope string `json:"scope"`
+	Owner string `json:"owner"`
+}
+
+func (game *Game) GlobalStore() *ScriptStore {
+	return &ScriptStore{Game: game, Scope: ScriptStoreGlobal}
+}
+
+func (game *Game) ScriptStoreFor(name string) (*ScriptStore, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("a script store needs a script name")
+	}
+
+	return &ScriptStore{Game: game, Scope: ScriptStoreScript, Owner: name}, nil
+}
+
+/* Run fn with owner as the script whose namespace Golem.store.script() returns */
+func (game *Game) withStoreOwner(owner string, fn func() (goja.Value, error)) (goja.Value, error) {
+	game.storeOwners = append(game.storeOwners, owner)
+	defer func() {
+		game.storeOwners = game.storeOwners[:len(game.storeOwners)-1]
+	}()
+
+	return fn()
+}
+
+func (game *Game) currentStoreOwner() string {
+	if len(game.storeOwners) == 0 {
+		return ""
+	}
+
+	return game.storeOwners[len(game.storeOwners)-1]
+}
+
+func (game *Game) CharacterStore(ch *Character) (*ScriptStore, error) {
+	if ch == nil || ch.Flags&CHAR_IS_PLAYER == 0 || ch.Id <= 0 {
+		return nil, errors.New("only saved player characters have a persistent store")
+	}
+
+	return &ScriptStore{Game: game, Scope: ScriptStoreCharacter, Owner: strconv.Itoa(ch.Id)}, nil
+}
+
+func validateScriptStoreKey(key string) error {
+	if key == "" {
+		return errors.New("store keys may not be empty")
+	}
+
+	if len(key) > scriptStoreMaxKeyLength {
+		return fmt.Errorf("store keys may be at most %d bytes", scriptStoreMaxKeyLength)
+	}
+
+	return nil
+}
+
+/* Get returns the raw JSON stored under key */
+func (store *ScriptStore) Get(key string) (string, bool, error) {
+	var value string
+
+	err := store.Game.db.QueryRow(`
+		SELECT
+			value
+		FROM
+			script_store
+		WHERE
+			scope = ?
+		AND
+			owner = ?
+		AND
+			key = ?
+	`, store.Scope, store.Owner, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+func (store *ScriptStore) Set(key string, value string) error {
+	if err := validateScriptStoreKey(key); err != nil {
+		return err
+	}
+
+	if len(value) > scriptStoreMaxValueLength {
+		return fmt.Errorf("store values may be at most %d bytes of JSON", scriptStoreMaxValueLength)
+	}
+
+	_, err := store.Game.db.Exec(`
+		INSERT INTO
+			script_store(scope, owner, key, value)
+		VALUES
+			(?, ?, ?, ?)
+		ON CONFLICT(scope, owner, key) DO UPDATE SET
+			value = excluded.value,
+			updated_at = CURRENT_TIMESTAMP
+	`, store.Scope, store.Owner, key, value)
+	return err
+}
+
+func (store *ScriptStore) Delete(key string) (bool, error) {
+	result, err := store.Game.db.Exec(`
+		DELETE FROM
+			script_store
+		WHERE
+			scope = ?
+		AND
+			owner = ?
+		AND
+			key = ?
+	`, store.Scope, store.Owner, key)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+/* List the keys in this namespace starting with prefix, in order */
+func (store *ScriptStore) List(prefix string) ([]string, error) {
+	rows, err := store.Game.db.Query(`
+		SELECT
+			key
+		FROM
+			script_store
+		WHERE
+			scope = ?
+		AND
+			owner = ?
+		AND
+			substr(key, 1, ?) = ?
+		ORDER BY
+			key
+	`, store.Scope, store.Owner, len(prefix), prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := make([]string, 0)
+
+	for rows.Next() {
+		var key string
+
+		err := rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+/* Values cross the script boundary through the VM's own JSON so they round-trip as plain objects */
+func (game *Game) scriptJSON(method string, argument goja.Value) goja.Value {
+	fn, ok := goja.AssertFunction(game.vm.Get("JSON").ToObject(game.vm).Get(method))
+	if !ok {
+		panic(game.vm.NewGoError(fmt.Errorf("JSON.%s is unavailable", method)))
+	}
+
+	result, err := fn(goja.Undefined(), argument)
+	if err != nil {
+		panic(err)
+	}
+
+	return result
+}
+
+func (game *Game) scriptStoreError(err error) {
+	if err != nil {
+		panic(game.vm.NewGoError(err))
+	}
+}
+
+func (game *Game) scriptStoreObject(store *ScriptStore) *goja.Object {
+	obj := game.vm.NewObject()
+
+	obj.Set("scope", store.Scope)
+	obj.Set("owner", store.Owner)
+
+	obj.Set("get", func(key string, fallback goja.Value) goja.Value {
+		value, ok, err := store.Get(key)
+		game.scriptStoreError(err)
+
+		if !ok {
+			if fallback == nil {
+				return goja.Undefined()
+			}
+
+			return fallback
+		}
+
+		return game.scriptJSON("parse", game.vm.ToValue(value))
+	})
+
+	obj.Set("set", func(key string, value goja.Value) bool {
+		serialized := game.scriptJSON("stringify", value)
+		if goja.IsUndefined(serialized) {
+			_, err := store.Delete(key)
+			game.scriptStoreError(err)
+			return true
+		}
+
+		game.scriptStoreError(store.Set(key, serialized.String()))
+		return true
+	})
+
+	obj.Set("delete", func(key string) bool {
+		deleted, err := store.Delete(key)
+		game.scriptStoreError(err)
+
+		return deleted
+	})
+
+	obj.Set("list", func(prefix string) []string {
+		keys, err := store.List(prefix)
+		game.scriptStoreError(err)
+
+		return keys
+	})
+
+	return obj
+}
+
+/*
+ * Golem.store exposes the global namespace directly and scoped namespaces
+ * through script() and character().  script() takes no name: it is always the
+ * namespace of the calling script, so one script cannot read or overwrite
+ * another's state.
+ */
+func (game *Game) newScriptStoreObject() *goja.Object {
+	obj := game.scriptStoreObject(game.GlobalStore())
+
+	obj.Set("global", game.scriptStoreObject(game.GlobalStore()))
+
+	obj.Set("script", func() *goja.Object {
+		store, err := game.ScriptStoreFor(game.currentStoreOwner())
+		if err != nil {
+			err = errors.New("Golem.store.script() is only available while a script or module runs")
+		}
+
+		game.scriptStoreError(err)
+
+		return game.scriptStoreObject(store)
+	})
+
+	obj.Set("character", func(ch *Character) *goja.Object {
+		store, err := game.CharacterStore(ch)
+		game.scriptStoreError(err)
+
+		return game.scriptStoreObject(store)
+	})
+
+	return obj
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+func TestScriptStoreRoundTripsAndCleansUp(t *testing.T) {
+	db, err := sql.Open(databaseDriverSQLite, filepath.Join(t.TempDir(), "store.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migration, err := os.ReadFile(filepath.Join("..", "migrations", "21_create_script_store.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []string{
+		`CREATE TABLE scripts (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE player_characters (id INTEGER PRIMARY KEY, username TEXT, deleted_at DATETIME)`,
+		`INSERT INTO scripts (id, name) VALUES (1, 'quests')`,
+		`INSERT INTO player_characters (id, username) VALUES (7, 'alice')`,
+		string(migration),
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	game := &Game{vm: goja.New(), db: db}
+	game.vm.Set("store", game.newScriptStoreObject())
+
+	ch := NewCharacter()
+	ch.Id = 7
+	ch.Flags |= CHAR_IS_PLAYER
+	game.vm.Set("ch", ch)
+
+	if _, err := game.vm.RunString(`store.script()`); err == nil {
+		t.Fatal("expected the script namespace to be refused outside a script")
+	}
+
+	result, err := game.withStoreOwner("quests", func() (goja.Value, error) {
+		return game.vm.RunString(`
+			store.set('counter', 3);
+			store.script().set('stage', { name: 'dragon', steps: [1, 2] });
+			store.character(ch).set('quest.dragon', true);
+			store.character(ch).set('quest.rat', false);
+
+			[
+				store.get('counter') + store.global.get('counter'),
+				store.script().get('stage').steps[1],
+				store.character(ch).list('quest.').join(','),
+				store.get('missing', 'fallback'),
+				store.delete('counter'),
+				store.get('counter') === undefined,
+			].join('|')
+	`)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := result.String(), "6|2|quest.dragon,quest.rat|fallback|true|true"; got != want {
+		t.Fatalf("store results = %q, want %q", got, want)
+	}
+
+	if _, err := game.vm.RunString(`store.set('big', 'x'.repeat(70000))`); err == nil {
+		t.Fatal("expected an oversized value to be refused")
+	}
+
+	if _, err := db.Exec(`DELETE FROM scripts WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`UPDATE player_characters SET deleted_at = CURRENT_TIMESTAMP WHERE id = 7`); err != nil {
+		t.Fatal(err)
+	}
+
+	var remaining int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM script_store`).Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+
+	if remaining != 0 {
+		t.Fatalf("%d store entries survived their owners' deletion", remaining)
+	}
+}
@@ -8,9 +8,13 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dop251/goja"
 )
@@ -37,6 +41,7 @@ func TestLoadScriptsFromDirectoryOnlyExecutesJavascriptFiles(t *testing.T) {
 		filepath.Join(hidden, "ignored.js"):     "this is not valid javascript",
 		filepath.Join(nested, ".ignored.swp"):   "this is not valid javascript",
 		filepath.Join(nested, "generated.json"): "this is not valid javascript",
+		filepath.Join(nested, "nested.test.js"): "this is not valid javascript",
 	}
 
 	for path, contents := range files {
@@ -55,3 +60,382 @@ func TestLoadScriptsFromDirectoryOnlyExecutesJavascriptFiles(t *testing.T) {
 		t.Fatalf("loadedScripts = %d, want 2", got)
 	}
 }
+
+func TestRunScriptLimitedDisablesRepeatOffenders(t *testing.T) {
+	previous := Config.ScriptingConfiguration
+	t.Cleanup(func() {
+		Config.ScriptingConfiguration = previous
+	})
+
+	Config.ScriptingConfiguration = defaultScriptingConfiguration()
+	Config.ScriptingConfiguration.BudgetMilliseconds = map[string]int{ScriptHookSkill: 20}
+
+	game := &Game{vm: goja.New(), Characters: NewLinkedList[*Character]()}
+	game.resetScriptViolations()
+
+	value, err := game.vm.RunString("(function() { while(true) {} })")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn, ok := goja.AssertFunction(value)
+	if !ok {
+		t.Fatal("expected a function")
+	}
+
+	for i := 0; i < Config.ScriptingConfiguration.MaxViolations; i++ {
+		_, err := game.callScriptFunction(ScriptHookSkill, "skill spin", fn, goja.Undefined())
+
+		var interrupted *goja.InterruptedError
+		if !errors.As(err, &interrupted) {
+			t.Fatalf("call %d: err = %v, want an interrupt", i, err)
+		}
+	}
+
+	_, err = game.callScriptFunction(ScriptHookSkill, "skill spin", fn, goja.Undefined())
+	if !errors.Is(err, errScriptDisabled) {
+		t.Fatalf("err = %v, want errScriptDisabled", err)
+	}
+
+	if got, err := game.vm.RunString("1 + 1"); err != nil || got.ToInteger() != 2 {
+		t.Fatalf("vm unusable after interrupts: %v, %v", got, err)
+	}
+}
+
+func TestRunScriptLimitedStopsRunawayAllocations(t *testing.T) {
+	previous := Config.ScriptingConfiguration
+	t.Cleanup(func() {
+		Config.ScriptingConfiguration = previous
+	})
+
+	Config.ScriptingConfiguration = defaultScriptingConfiguration()
+	Config.ScriptingConfiguration.BudgetMilliseconds = map[string]int{ScriptHookLoad: 5000, ScriptHookSkill: 200}
+
+	game := &Game{vm: goja.New(), Characters: NewLinkedList[*Character]()}
+	game.resetScriptViolations()
+
+	hoard := func() (goja.Value, error) {
+		return game.vm.RunString(`(function() { const hoard = []; for (;;) { hoard.push({ coin: 'gold'.repeat(16) }); } })()`)
+	}
+
+	started := time.Now()
+	_, err := game.runScriptLimited(ScriptHookLoad, "", hoard)
+	if err == nil || !strings.Contains(err.Error(), "allocated more than") {
+		t.Fatalf("err = %v, want the allocation ceiling to interrupt the script", err)
+	}
+
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("the allocation ceiling took %v to stop the script", elapsed)
+	}
+
+	Config.ScriptingConfiguration.MaxAllocationMegabytes = 0
+	_, err = game.runScriptLimited(ScriptHookSkill, "", hoard)
+	if err == nil || !strings.Contains(err.Error(), "time budget") {
+		t.Fatalf("err = %v, want only the time budget to interrupt the script", err)
+	}
+}
+
+func TestRequireResolvesCachesAndDetectsCycles(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"lib/counter.js": "globalThis.counterLoads = (globalThis.counterLoads || 0) + 1; module.exports = { value: 42 };",
+		"lib/index.js":   "exports.counter = require('./counter');",
+		"main.js":        "globalThis.answer = require('lib').counter.value + require('lib/counter.js').value;",
+		"cycle/a.js":     "require('./b');",
+		"cycle/b.js":     "require('./a');",
+	}
+
+	for name, contents := range files {
+		filename := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	game := &Game{vm: goja.New(), scriptRoot: root}
+	game.clearScriptModules()
+
+	if _, err := game.requireScriptModule("", "main"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := game.vm.Get("answer").ToInteger(); got != 84 {
+		t.Fatalf("answer = %d, want 84", got)
+	}
+
+	if got := game.vm.Get("counterLoads").ToInteger(); got != 1 {
+		t.Fatalf("counter module evaluated %d times, want 1", got)
+	}
+
+	if _, err := game.requireScriptModule("", "cycle/a"); err == nil || !strings.Contains(err.Error(), "require cycle") {
+		t.Fatalf("err = %v, want a require cycle", err)
+	}
+
+	if _, err := game.requireScriptModule("", "../outside"); err == nil {
+		t.Fatal("expected a request outside of the script root to be refused")
+	}
+}
+
+func TestMatchSpeechKeywords(t *testing.T) {
+	tests := []struct {
+		keywords []string
+		text     string
+		keyword  string
+		matched  bool
+	}{
+		{nil, "hello there", "", true},
+		{[]string{"quest"}, "Do you have a QUEST for me?", "quest", true},
+		{[]string{"quest"}, "I am questing", "", false},
+		{[]string{"hello", "hi"}, "Oh, hi!", "hi", true},
+		{[]string{"open sesame"}, "Open, sesame!", "open sesame", true},
+		{[]string{"open sesame"}, "sesame open", "", false},
+		{[]string{""}, "anything", "", false},
+	}
+
+	for _, test := range tests {
+		keyword, matched := matchSpeechKeywords(test.keywords, test.text)
+		if keyword != test.keyword || matched != test.matched {
+			t.Fatalf("matchSpeechKeywords(%q, %q) = %q, %v, want %q, %v", test.keywords, test.text, keyword, matched, test.keyword, test.matched)
+		}
+	}
+}
+
+func TestRoomTriggersInterceptCommandsAndFireOnMovement(t *testing.T) {
+	game := &Game{vm: goja.New(), Characters: NewLinkedList[*Character]()}
+	game.resetScriptViolations()
+
+	script := &Script{Game: game, Id: 1, Name: "trap", Script: `
+		exports.onCommand = function(ch, command, args) {
+			if (command === 'north') return false;
+			if (command === 'xyzzy') return 'say ' + args;
+		};
+		exports.onRoomEnter = function(ch) { globalThis.entered = (globalThis.entered || 0) + 1; };
+		exports.onRoomLeave = function(ch, to) { globalThis.left = (globalThis.left || 0) + 1; };
+	`}
+
+	exports, err := script.GetExports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.Exports = exports
+
+	trap := game.NewRoom()
+	trap.script = script
+	corridor := game.NewRoom()
+
+	for _, room := range []*Room{trap, corridor} {
+		room.Characters = NewLinkedList[*Character]()
+		room.Objects = NewLinkedList[*ObjectInstance]()
+	}
+
+	tests := []struct {
+		command   string
+		arguments string
+		input     string
+		ok        bool
+	}{
+		{"north", "", "north", false},
+		{"xyzzy", "plugh", "say plugh", true},
+		{"look", "", "look", true},
+	}
+
+	for _, test := range tests {
+		input, ok := trap.interceptCommand(nil, test.command, test.arguments)
+		if input != test.input || ok != test.ok {
+			t.Fatalf("interceptCommand(%q, %q) = %q, %v, want %q, %v", test.command, test.arguments, input, ok, test.input, test.ok)
+		}
+	}
+
+	ch := NewCharacter()
+	corridor.AddCharacter(ch)
+	corridor.moveCharacter(ch, trap)
+	trap.characterEntered(ch, corridor)
+
+	spawned := NewCharacter()
+	trap.AddCharacter(spawned)
+	trap.characterEntered(spawned, nil)
+
+	if got := game.vm.Get("entered"); got == nil || got.ToInteger() != 1 {
+		t.Fatalf("entered = %v, want 1", got)
+	}
+
+	trap.moveCharacter(ch, corridor)
+	trap.removeCharacter(spawned)
+	if got := game.vm.Get("left"); got == nil || got.ToInteger() != 1 {
+		t.Fatalf("left = %v, want 1 for the move alone", got)
+	}
+}
+
+func TestRoomEnterTriggersFireAfterTheArrivalIsShown(t *testing.T) {
+	game, err := newScriptTestGame(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer game.db.Close()
+
+	script := &Script{Game: game, Id: 1, Name: "greeting", Script: `
+		exports.onRoomEnter = function(ch) { ch.send("The floor creaks underfoot.\r\n"); };
+	`}
+
+	exports, err := script.GetExports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.Exports = exports
+
+	suite := &scriptTestSuite{game: game}
+	hall := suite.createRoom("A Creaking Hall", "Old boards stretch away.")
+	porch := suite.createRoom("A Porch", "A door opens north.")
+	hall.script = script
+	suite.link(porch, "north", hall)
+
+	visitor := suite.player("Visitor", game.vm.ToValue(map[string]interface{}{"room": porch}))
+	suite.output(visitor)
+	visitor.Interpret("north")
+
+	output := suite.output(visitor)
+	look, creak := strings.Index(output, "Old boards stretch away."), strings.Index(output, "The floor creaks underfoot.")
+	if look < 0 || creak < look {
+		t.Fatalf("the enter trigger did not follow the room description:\n%s", output)
+	}
+}
+
+func TestScriptSchedulesRunWhenDueAndPersist(t *testing.T) {
+	db, err := sql.Open(databaseDriverSQLite, filepath.Join(t.TempDir(), "schedules.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migration, err := os.ReadFile(filepath.Join("..", "migrations", "22_create_script_schedules.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatal(err)
+	}
+
+	game := &Game{vm: goja.New(), db: db}
+	game.resetScriptViolations()
+
+	value, err := game.vm.RunString("(function() { globalThis.runs = (globalThis.runs || 0) + 1; })")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn, _ := goja.AssertFunction(value)
+
+	job, err := game.ScheduleScript("@hourly", "invasion", fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nextRunAt := job.NextRunAt
+	if nextRunAt.Minute() != 0 || !nextRunAt.After(time.Now()) {
+		t.Fatalf("next run at %v, want the top of a coming hour", nextRunAt)
+	}
+
+	game.scriptSchedulesUpdate()
+	if runs := game.vm.Get("runs"); runs != nil {
+		t.Fatalf("job ran %v times before it was due", runs)
+	}
+
+	/* Simulate a restart during which the job came due */
+	if _, err := db.Exec(`UPDATE script_schedules SET next_run_at = '2000-01-01 00:00:00'`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := game.LoadScriptSchedules(); err != nil {
+		t.Fatal(err)
+	}
+
+	game.scriptSchedulesUpdate()
+	if runs := game.vm.Get("runs"); runs != nil {
+		t.Fatal("an unbound job ran")
+	}
+
+	job, err = game.ScheduleScript("@hourly", "invasion", fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.NextRunAt.Year() != 2000 {
+		t.Fatalf("re-registering lost the persisted next run time: %v", job.NextRunAt)
+	}
+
+	game.scriptSchedulesUpdate()
+	game.scriptSchedulesUpdate()
+	if runs := game.vm.Get("runs"); runs == nil || runs.ToInteger() != 1 {
+		t.Fatalf("runs = %v, want exactly one catch-up run", runs)
+	}
+
+	if err := game.SetScriptSchedulePaused("invasion", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := game.LoadScriptSchedules(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !game.scriptSchedules["invasion"].Paused || game.scriptSchedules["invasion"].LastRunAt.IsZero() {
+		t.Fatalf("pause or last run not persisted: %+v", game.scriptSchedules["invasion"])
+	}
+}
+
+func TestRoomTriggersSeePlayersDieAndRespawn(t *testing.T) {
+	game, err := newScriptTestGame(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer game.db.Close()
+
+	script := &Script{Game: game, Id: 1, Name: "mourning", Script: `
+		exports.onRoomLeave = function(ch, to) { globalThis.left = to.id; };
+		exports.onRoomEnter = function(ch) { globalThis.entered = ch.room.id; };
+	`}
+
+	exports, err := script.GetExports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.Exports = exports
+
+	limbo, err := game.LoadRoomIndex(RoomLimbo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite := &scriptTestSuite{game: game}
+	battlefield := suite.createRoom("A Battlefield", "Broken spears litter the mud.")
+	battlefield.script = script
+	limbo.script = script
+
+	fallen := suite.player("Fallen", game.vm.ToValue(map[string]interface{}{"room": battlefield}))
+	game.Damage(nil, fallen, false, fallen.Health+10, DamageTypeSlash)
+
+	if fallen.Room != limbo {
+		t.Fatal("the player was not sent to limbo")
+	}
+
+	if got := game.vm.Get("left"); got == nil || got.ToInteger() != int64(RoomLimbo) {
+		t.Errorf("left for %v, want limbo", got)
+	}
+
+	if got := game.vm.Get("entered"); got == nil || got.ToInteger() != int64(RoomLimbo) {
+		t.Errorf("entered %v, want limbo", got)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/* Whether a path under the script root is a game script the loader would evaluate */
+func isWatchedScriptFile(filename string) bool {
+	name := filepath.Base(filename)
+
+	return !strings.HasPrefix(name, ".") && filepath.Ext(name) == ".js" && !strings.HasSuffix(name, ScriptTestSuffix)
+}
+
+/* Forget every event, combat, skill, spell and command handler registered by a file module */
+func (game *Game) detachScriptModuleHandlers(id string) {
+	for _, handlers := range game.eventHandlers {
+		for _, handler := range handlers.Values() {
+			if handler.module == id {
+				handlers.Remove(handler)
+			}
+		}
+	}
+
+	for step, hook := range game.combatHooks {
+		if hook.module == id {
+			delete(game.combatHooks, step)
+		}
+	}
+
+	for _, skill := range game.skills {
+		if skill.handlerModule == id {
+			skill.Handler = nil
+			skill.handlerModule = ""
+		}
+	}
+
+	for name, cmd := range CommandTable {
+		if cmd.Scripted && cmd.module == id {
+			delete(CommandTable, name)
+		}
+	}
+}
+
+/*
+ * Re-evaluate a single file under the script root in place of a full reload.
+ * Source that fails to compile leaves the running handlers untouched; otherwise
+ * the handlers the file registered before are dropped and it is evaluated anew.
+ * Modules that required the file keep the exports they were given.
+ */
+func (game *Game) reloadScriptFile(filename string) error {
+	id, err := scriptModuleIdFromPath(game.scriptRoot, filename)
+	if err != nil {
+		return err
+	}
+
+	if game.modules == nil {
+		game.clearScriptModules()
+	}
+
+	source, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		game.detachScriptModuleHandlers(id)
+		delete(game.modules, id)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = compileScriptModule(filename, string(source))
+	if err != nil {
+		return err
+	}
+
+	game.detachScriptModuleHandlers(id)
+	delete(game.modules, id)
+
+	_, err = game.evaluateScriptModule(id, filename, string(source))
+	return err
+}
+
+/* Called on the game loop for each script file the watcher saw change */
+func (game *Game) scriptFileChanged(filename string) {
+	err := game.reloadScriptFile(filename)
+	if err != nil {
+		out := fmt.Sprintf("{RScript reload of %s failed: %s{x\r\n", filename, scriptErrorDescription(err))
+		log.Print(stripColourCodes(out))
+		game.broadcast(out, WiznetBroadcastFilter)
+		return
+	}
+
+	out := fmt.Sprintf("{GReloaded script %s.{x\r\n", filename)
+	log.Print(stripColourCodes(out))
+	game.broadcast(out, WiznetBroadcastFilter)
+}
+
+/* In development mode, reload scripts as they are saved rather than waiting for "reload" */
+func (game *Game) watchScripts() error {
+	root := game.scriptRoot
+	if root == "" {
+		root = ScriptDirectory
+	}
+
+	watcher, err := newScriptWatcher(root)
+	if err != nil {
+		return err
+	}
+
+	game.scriptFileChanges = watcher.changes
+	go watcher.run()
+
+	log.Printf("Watching %s for script changes.\r\n", root)
+	return nil
+}
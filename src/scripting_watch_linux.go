@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+/* Saves by write-and-close or by renaming a temporary file over the original, and deletions */
+const scriptWatchFileEvents = unix.IN_CLOSE_WRITE | unix.IN_MOVED_TO | unix.IN_MOVED_FROM | unix.IN_DELETE
+
+/* New directories need watches of their own */
+const scriptWatchEvents = scriptWatchFileEvents | unix.IN_CREATE
+
+/* Watches every directory under the script root with inotify, which does not recurse on its own */
+type scriptWatcher struct {
+	fd          int
+	directories map[int]string
+	changes     chan string
+}
+
+func newScriptWatcher(root string) (*scriptWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &scriptWatcher{
+		fd:          fd,
+		directories: make(map[int]string),
+		changes:     make(chan string, 64),
+	}
+
+	err = watcher.addDirectory(root)
+	if err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	return watcher, nil
+}
+
+func (watcher *scriptWatcher) addDirectory(directory string) error {
+	return filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if path != directory && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		wd, err := unix.InotifyAddWatch(watcher.fd, path, scriptWatchEvents)
+		if err != nil {
+			return err
+		}
+
+		watcher.directories[wd] = path
+		return nil
+	})
+}
+
+/* Read events until the descriptor fails, handing changed script files to the game loop */
+func (watcher *scriptWatcher) run() {
+	buffer := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+
+	for {
+		n, err := unix.Read(watcher.fd, buffer)
+		if err == unix.EINTR {
+			continue
+		}
+
+		if err != nil || n <= 0 {
+			log.Printf("Stopped watching scripts: %v.\r\n", err)
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			name := string(bytes.TrimRight(buffer[offset+unix.SizeofInotifyEvent:offset+unix.SizeofInotifyEvent+int(event.Len)], "\x00"))
+			offset += unix.SizeofInotifyEvent + int(event.Len)
+
+			if event.Mask&unix.IN_IGNORED != 0 {
+				delete(watcher.directories, int(event.Wd))
+				continue
+			}
+
+			directory, ok := watcher.directories[int(event.Wd)]
+			if !ok || name == "" {
+				continue
+			}
+
+			path := filepath.Join(directory, name)
+
+			if event.Mask&unix.IN_ISDIR != 0 {
+				if event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 && !strings.HasPrefix(name, ".") {
+					err := watcher.addDirectory(path)
+					if err != nil {
+						log.Printf("Failed to watch new script directory %s: %v.\r\n", path, err)
+					}
+				}
+
+				continue
+			}
+
+			if event.Mask&scriptWatchFileEvents != 0 && isWatchedScriptFile(path) {
+				watcher.changes <- path
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScriptWatcherReportsSavedScripts(t *testing.T) {
+	root := t.TempDir()
+
+	watcher, err := newScriptWatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go watcher.run()
+
+	expect := func(want string) {
+		t.Helper()
+
+		select {
+		case got := <-watcher.changes:
+			if got != want {
+				t.Fatalf("changed script = %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no change reported for %s", want)
+		}
+	}
+
+	/* Notes and test suites are ignored, so only the script itself is reported */
+	for _, name := range []string{"notes.txt", "fireball.test.js", "fireball.js"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("module.exports = {};"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect(filepath.Join(root, "fireball.js"))
+
+	/* Directories created after startup are watched too */
+	magic := filepath.Join(root, "magic")
+	if err := os.Mkdir(magic, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err := os.WriteFile(filepath.Join(magic, "heal.js"), []byte("module.exports = {};"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case got := <-watcher.changes:
+			if got != filepath.Join(magic, "heal.js") {
+				t.Fatalf("changed script = %q", got)
+			}
+
+			return
+		case <-time.After(50 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("no change reported in a new directory")
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+
+package main
+
+import "errors"
+
+type scriptWatcher struct {
+	changes chan string
+}
+
+func newScriptWatcher(root string) (*scriptWatcher, error) {
+	return nil, errors.New("watching scripts for changes requires inotify, which is only available on Linux")
+}
+
+func (watcher *scriptWatcher) run() {}
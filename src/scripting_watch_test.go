@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja"
+)
+
+func TestReloadScriptFileReplacesOnlyThatFilesHandlers(t *testing.T) {
+	root := t.TempDir()
+	alpha := filepath.Join(root, "alpha.js")
+
+	files := map[string]string{
+		alpha:                          `Golem.registerEventHandler('ping', () => 'alpha1'); Golem.registerPlayerCommand('watchalpha', () => {}, 0);`,
+		filepath.Join(root, "beta.js"): `Golem.registerEventHandler('ping', () => 'beta');`,
+	}
+
+	for path, contents := range files {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Cleanup(func() {
+		delete(CommandTable, "watchalpha")
+		delete(CommandTable, "watchgamma")
+	})
+
+	game := &Game{vm: goja.New(), eventHandlers: make(map[string]*LinkedList[*EventHandler])}
+	game.installScriptGlobals()
+
+	if err := game.LoadScriptsFromDirectory(root); err != nil {
+		t.Fatal(err)
+	}
+
+	ping := func() string {
+		values, _ := game.InvokeNamedEventHandlersWithContextAndArguments("ping", goja.Undefined())
+
+		results := make([]string, 0, len(values))
+		for _, value := range values {
+			results = append(results, value.String())
+		}
+
+		sort.Strings(results)
+		return strings.Join(results, ",")
+	}
+
+	if got := ping(); got != "alpha1,beta" {
+		t.Fatalf("ping after load = %q", got)
+	}
+
+	steps := []struct {
+		source  string
+		wantErr bool
+		want    string
+	}{
+		{`Golem.registerEventHandler('ping', () => 'alpha2'); Golem.registerPlayerCommand('watchgamma', () => {}, 0);`, false, "alpha2,beta"},
+		{`Golem.registerEventHandler('ping', () => {`, true, "alpha2,beta"},
+		{"", false, "beta"},
+	}
+
+	for _, step := range steps {
+		if step.source == "" {
+			if err := os.Remove(alpha); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := os.WriteFile(alpha, []byte(step.source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := game.reloadScriptFile(alpha)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("reload of %q: err = %v, want error %v", step.source, err, step.wantErr)
+		}
+
+		if got := ping(); got != step.want {
+			t.Fatalf("ping after reloading %q = %q, want %q", step.source, got, step.want)
+		}
+	}
+
+	if _, ok := CommandTable["watchalpha"]; ok {
+		t.Fatal("a command the file no longer registers survived its reload")
+	}
+}
@@ -8,17 +8,27 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 )
 
+/* The most secondhand goods a keeper will hold at once */
+const ShopInventoryCapacity = 20
+
 type ShopListing struct {
 	Shop   *Shop   `json:"shop"`
 	Id     int     `json:"id"`
 	Object *Object `json:"object"`
 	Price  int     `json:"price"`
+
+	/* How many the keeper restocks to, or 0 for an endless supply, and how many are on hand */
+	Stock    int `json:"stock"`
+	Quantity int `json:"quantity"`
 }
 
 type Shop struct {
@@ -26,6 +36,31 @@ type Shop struct {
 	Id       int                       `json:"id"`
 	MobileId uint                      `json:"mobileId"`
 	Listings *LinkedList[*ShopListing] `json:"listings"`
+
+	/* Space-separated item types the keeper buys from players */
+	BuyTypes string `json:"buyTypes"`
+
+	/* Percentages of an item's value the keeper pays players, and charges to resell it */
+	BuyMargin  int `json:"buyMargin"`
+	SellMargin int `json:"sellMargin"`
+
+	/* Game hours the shop opens and closes; equal hours keep it open all day */
+	OpenHour  int `json:"openHour"`
+	CloseHour int `json:"closeHour"`
+
+	/* Goods players have sold to the keeper */
+	Inventory *LinkedList[*ObjectInstance] `json:"inventory"`
+}
+
+func (game *Game) NewShop(mobileId uint) *Shop {
+	return &Shop{
+		Game:       game,
+		MobileId:   mobileId,
+		Listings:   NewLinkedList[*ShopListing](),
+		BuyMargin:  50,
+		SellMargin: 100,
+		Inventory:  NewLinkedList[*ObjectInstance](),
+	}
 }
 
 func (game *Game) LoadShops() error {
@@ -37,7 +72,12 @@ func (game *Game) LoadShops() error {
 	rows, err := game.db.Query(`
 		SELECT
 			id,
-			mobile_id
+			mobile_id,
+			buy_types,
+			buy_margin,
+			sell_margin,
+			open_hour,
+			close_hour
 		FROM
 			shops
 	`)
@@ -49,8 +89,8 @@ func (game *Game) LoadShops() error {
 	defer rows.Close()
 
 	for rows.Next() {
-		shop := &Shop{Game: game, Listings: NewLinkedList[*ShopListing]()}
-		err := rows.Scan(&shop.Id, &shop.MobileId)
+		shop := game.NewShop(0)
+		err := rows.Scan(&shop.Id, &shop.MobileId, &shop.BuyTypes, &shop.BuyMargin, &shop.SellMargin, &shop.OpenHour, &shop.CloseHour)
 		if err != nil {
 			log.Printf("Unable to scan shop: %v.\r\n", err)
 			return err
@@ -69,6 +109,7 @@ func (game *Game) LoadShops() error {
 		SELECT
 			id,
 			price,
+			stock,
 			shop_id,
 			object_id
 		FROM
@@ -89,7 +130,7 @@ func (game *Game) LoadShops() error {
 		var objectId uint
 
 		shopListing := &ShopListing{}
-		err := rows.Scan(&shopListing.Id, &shopListing.Price, &shopId, &objectId)
+		err := rows.Scan(&shopListing.Id, &shopListing.Price, &shopListing.Stock, &shopId, &objectId)
 		if err != nil {
 			log.Printf("Unable to scan shop: %v.\r\n", err)
 			return err
@@ -100,6 +141,7 @@ func (game *Game) LoadShops() error {
 			continue
 		}
 
+		shopListing.Quantity = shopListing.Stock
 		shopListing.Shop = game.shops[shopId]
 		shopListing.Shop.Listings.Insert(shopListing)
 		objectIds[int(objectId)] = uint(shopListing.Id)
@@ -143,16 +185,430 @@ func (game *Game) LoadShops() error {
 		}
 	}
 
-	return nil
+	return game.loadShopInventories()
+}
+
+/* Put the goods players sold back on their shops' shelves */
+func (game *Game) loadShopInventories() error {
+	rows, err := game.db.Query(`
+		SELECT
+			shop_object_instance.shop_id,
+			object_instances.id,
+			object_instances.parent_id,
+			object_instances.serial,
+			object_instances.name,
+			object_instances.short_description,
+			object_instances.long_description,
+			object_instances.description,
+			object_instances.flags,
+			object_instances.item_type,
+			object_instances.value_1,
+			object_instances.value_2,
+			object_instances.value_3,
+			object_instances.value_4,
+			object_instances.weight,
+			object_instances.ttl,
+			CAST(strftime('%s', object_instances.created_at) AS INTEGER)
+		FROM
+			shop_object_instance
+		INNER JOIN
+			object_instances ON object_instances.id = shop_object_instance.object_instance_id
+		ORDER BY
+			shop_object_instance.id
+	`)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var shopId uint
+		var serial sql.NullString
+		var createdAt sql.NullInt64
+
+		obj := &ObjectInstance{Game: game, Contents: NewLinkedList[*ObjectInstance](), WearLocation: -1}
+		err = rows.Scan(&shopId, &obj.Id, &obj.ParentId, &serial, &obj.Name, &obj.ShortDescription, &obj.LongDescription, &obj.Description, &obj.Flags, &obj.ItemType, &obj.Value0, &obj.Value1, &obj.Value2, &obj.Value3, &obj.Weight, &obj.Ttl, &createdAt)
+		if err != nil {
+			return err
+		}
+
+		shop, ok := game.shops[shopId]
+		if !ok {
+			continue
+		}
+
+		obj.Serial = serial.String
+		obj.CreatedAt = objectCreatedAtFromUnix(createdAt)
+		obj.Ttl = normalizeObjectTtl(obj.Flags, obj.Ttl)
+		shop.Inventory.Insert(obj)
+	}
+
+	return rows.Err()
 }
 
+/* Write the shop and its listings, creating either if new and dropping listings since removed */
 func (shop *Shop) Save() error {
+	ctx := context.Background()
+	tx, err := shop.Game.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	created := make([]*ShopListing, 0)
+	rollback := func(err error) error {
+		tx.Rollback()
+
+		for _, listing := range created {
+			listing.Id = 0
+		}
+
+		return err
+	}
+
+	shopId := shop.Id
+	if shopId == 0 {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO
+				shops(mobile_id, buy_types, buy_margin, sell_margin, open_hour, close_hour)
+			VALUES
+				(?, ?, ?, ?, ?, ?)
+		`, shop.MobileId, shop.BuyTypes, shop.BuyMargin, shop.SellMargin, shop.OpenHour, shop.CloseHour)
+		if err != nil {
+			return rollback(err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return rollback(err)
+		}
+
+		shopId = int(id)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE
+				shops
+			SET
+				mobile_id = ?,
+				buy_types = ?,
+				buy_margin = ?,
+				sell_margin = ?,
+				open_hour = ?,
+				close_hour = ?,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE
+				id = ?
+		`, shop.MobileId, shop.BuyTypes, shop.BuyMargin, shop.SellMargin, shop.OpenHour, shop.CloseHour, shopId)
+		if err != nil {
+			return rollback(err)
+		}
+	}
+
+	kept := make([]uint, 0)
+	for listing := range shop.Listings.All() {
+		if listing.Id > 0 {
+			kept = append(kept, uint(listing.Id))
+		}
+	}
+
+	if len(kept) > 0 {
+		placeholders, args := sqlInClauseArgs(kept)
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+			DELETE FROM
+				shop_object
+			WHERE
+				shop_id = ?
+			AND
+				id NOT IN (%s)
+		`, placeholders), append([]interface{}{shopId}, args...)...)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM shop_object WHERE shop_id = ?`, shopId)
+	}
+
+	if err != nil {
+		return rollback(err)
+	}
+
+	for listing := range shop.Listings.All() {
+		if listing.Object == nil {
+			continue
+		}
+
+		if listing.Id > 0 {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE
+					shop_object
+				SET
+					object_id = ?,
+					price = ?,
+					stock = ?,
+					updated_at = CURRENT_TIMESTAMP
+				WHERE
+					id = ?
+			`, listing.Object.Id, listing.Price, listing.Stock, listing.Id)
+			if err != nil {
+				return rollback(err)
+			}
+
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO
+				shop_object(shop_id, object_id, price, stock)
+			VALUES
+				(?, ?, ?, ?)
+		`, shopId, listing.Object.Id, listing.Price, listing.Stock)
+		if err != nil {
+			return rollback(err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return rollback(err)
+		}
+
+		listing.Id = int(id)
+		created = append(created, listing)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return rollback(err)
+	}
+
+	shop.Id = shopId
+	shop.Game.shops[uint(shop.Id)] = shop
+	shop.Game.mobileShops[shop.MobileId] = shop
+	return nil
+}
+
+/* Offer a new listing, fully stocked; call Save to keep it */
+func (shop *Shop) AddListing(obj *Object, price int, stock int) *ShopListing {
+	listing := &ShopListing{Shop: shop, Object: obj, Price: price, Stock: stock, Quantity: stock}
+	shop.Listings.Insert(listing)
+
+	return listing
+}
+
+func (shop *Shop) RemoveListing(listing *ShopListing) {
+	shop.Listings.Remove(listing)
+}
+
+/* The listing shown at a number in the shop list, counting from 1 */
+func (shop *Shop) ListingAt(number int) *ShopListing {
+	listing, _ := shop.entryAt(number)
+	return listing
+}
+
+/* A listing or secondhand item at a number in the shop list; secondhand goods follow the listings */
+func (shop *Shop) entryAt(number int) (*ShopListing, *ObjectInstance) {
+	count := 1
+
+	for listing := range shop.Listings.All() {
+		if listing.Object == nil {
+			continue
+		}
+
+		if count == number {
+			return listing, nil
+		}
+
+		count++
+	}
+
+	for obj := range shop.Inventory.All() {
+		if count == number {
+			return nil, obj
+		}
+
+		count++
+	}
+
+	return nil, nil
+}
+
+func (shop *Shop) Buys(itemType string) bool {
+	return slices.Contains(strings.Fields(shop.BuyTypes), itemType)
+}
+
+/* Start or stop buying an item type, returning whether the keeper now buys it */
+func (shop *Shop) ToggleBuyType(itemType string) bool {
+	types := strings.Fields(shop.BuyTypes)
+
+	if index := slices.Index(types, itemType); index >= 0 {
+		shop.BuyTypes = strings.Join(slices.Delete(types, index, index+1), " ")
+		return false
+	}
+
+	shop.BuyTypes = strings.Join(append(types, itemType), " ")
+	return true
+}
+
+/* Whether the shop trades at an hour of the game day; hours past midnight wrap around */
+func (shop *Shop) IsOpen(hour int) bool {
+	if shop.OpenHour == shop.CloseHour {
+		return true
+	}
+
+	if shop.OpenHour < shop.CloseHour {
+		return hour >= shop.OpenHour && hour < shop.CloseHour
+	}
+
+	return hour >= shop.OpenHour || hour < shop.CloseHour
+}
+
+/* What a kind of object is worth: the lowest price any shop lists it for, or 0 if none sells it */
+func (game *Game) ObjectValue(objectId uint) int {
+	value := 0
+
+	for _, shop := range game.shops {
+		for listing := range shop.Listings.All() {
+			if listing.Object != nil && listing.Object.Id == objectId && (value == 0 || listing.Price < value) {
+				value = listing.Price
+			}
+		}
+	}
+
+	return value
+}
+
+/* What a kind of object is worth to this keeper: its own price if it lists the object, or else the lowest in the world */
+func (shop *Shop) ObjectValue(objectId uint) int {
+	for listing := range shop.Listings.All() {
+		if listing.Object != nil && listing.Object.Id == objectId {
+			return listing.Price
+		}
+	}
+
+	return shop.Game.ObjectValue(objectId)
+}
+
+/* What the keeper will pay for obj, or 0 if it won't buy it; never more than it would resell obj for */
+func (shop *Shop) Appraise(obj *ObjectInstance) int {
+	if obj == nil || !shop.Buys(obj.ItemType) {
+		return 0
+	}
+
+	return shop.ObjectValue(obj.ParentId) * max(0, min(shop.BuyMargin, shop.SellMargin, 100)) / 100
+}
+
+/* What the keeper asks for a secondhand item */
+func (shop *Shop) resalePrice(obj *ObjectInstance) int {
+	return max(1, shop.ObjectValue(obj.ParentId)*shop.SellMargin/100)
+}
+
+/* Refill every listing of the shops whose keepers stand in zone */
+func (game *Game) RestockShops(zone *Zone) {
+	for ch := range game.Characters.All() {
+		if ch.Flags&CHAR_IS_PLAYER != 0 || ch.Room == nil || ch.Room.Zone != zone {
+			continue
+		}
+
+		shop, ok := game.mobileShops[uint(ch.Id)]
+		if !ok {
+			continue
+		}
+
+		for listing := range shop.Listings.All() {
+			listing.Quantity = listing.Stock
+		}
+	}
+}
+
+/* Move a player's item onto the shop's shelves, in the database as well */
+func (shop *Shop) takeFrom(ch *Character, obj *ObjectInstance) error {
+	ctx := context.Background()
+	tx, err := shop.Game.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = ch.detachObjectTx(ctx, tx, obj)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	reified, err := obj.reifyTx(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			shop_object_instance(shop_id, object_instance_id)
+		VALUES
+			(?, ?)
+	`, shop.Id, obj.Id)
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	ch.RemoveObject(obj)
+	shop.Game.Objects.Remove(obj)
+	shop.Inventory.Insert(obj)
+	return nil
+}
+
+/* Hand a secondhand item from the shelves to a player */
+func (shop *Shop) giveTo(ch *Character, obj *ObjectInstance) error {
+	ctx := context.Background()
+	tx, err := shop.Game.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM
+			shop_object_instance
+		WHERE
+			object_instance_id = ?
+	`, obj.Id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	reified, err := ch.attachObjectTx(ctx, tx, obj)
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		resetReifiedObjectIDs(reified)
+		return err
+	}
+
+	shop.Inventory.Remove(obj)
+	ch.AddObject(obj)
+	shop.Game.Objects.Insert(obj)
 	return nil
 }
 
 func (ch *Character) FindShopInRoom() *Shop {
+	_, shop := ch.findShopkeeper()
+	return shop
+}
+
+func (ch *Character) findShopkeeper() (*Character, *Shop) {
 	if ch == nil || ch.Room == nil || ch.Room.Characters == nil {
-		return nil
+		return nil, nil
 	}
 
 	for rch := range ch.Room.Characters.All() {
@@ -163,17 +619,32 @@ func (ch *Character) FindShopInRoom() *Shop {
 				continue
 			}
 
-			return shop
+			return rch, shop
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
-func do_buy(ch *Character, arguments string) {
-	shop := ch.FindShopInRoom()
+/* The keeper of an open shop in ch's room, or nil after telling ch why they can't trade */
+func (ch *Character) findOpenShop() (*Character, *Shop) {
+	keeper, shop := ch.findShopkeeper()
 	if shop == nil {
 		ch.Send("You can't do that here.\r\n")
+		return nil, nil
+	}
+
+	if !shop.IsOpen(ch.Game.Hour()) {
+		ch.Send(fmt.Sprintf("{C%s says \"Sorry, we're closed.  Come back at %s.{C\"{x\r\n", keeper.GetShortDescriptionUpper(ch), formatGameHour(shop.OpenHour)))
+		return nil, nil
+	}
+
+	return keeper, shop
+}
+
+func do_buy(ch *Character, arguments string) {
+	keeper, shop := ch.findOpenShop()
+	if shop == nil {
 		return
 	}
 
@@ -204,86 +675,214 @@ func do_buy(ch *Character, arguments string) {
 		}
 	}
 
-	var count int = 1
+	listing, secondhand := shop.entryAt(id)
+	if secondhand != nil {
+		buySecondhand(ch, keeper, shop, secondhand, quantity)
+		return
+	}
 
-	for listing := range shop.Listings.All() {
-		if listing.Object == nil {
-			continue
+	if listing == nil {
+		ch.Send("That doesn't seem to be for sale.\r\n")
+		return
+	}
+
+	if listing.Stock > 0 && quantity > listing.Quantity {
+		if listing.Quantity == 0 {
+			ch.Send(fmt.Sprintf("{C%s says \"I'm sold out of those.{C\"{x\r\n", keeper.GetShortDescriptionUpper(ch)))
+		} else {
+			ch.Send(fmt.Sprintf("{C%s says \"I only have %d of those.{C\"{x\r\n", keeper.GetShortDescriptionUpper(ch), listing.Quantity))
 		}
 
-		if count == id {
-			if listing.Price > 0 && quantity > ch.Gold/listing.Price {
-				ch.Send("You can't afford that.\r\n")
-				return
-			}
+		return
+	}
 
-			if quantity > ch.getMaxItemsInventory()-ch.Inventory.Count {
-				ch.Send("You can't carry any more.\r\n")
-				return
-			}
+	if listing.Price > 0 && quantity > ch.Gold/listing.Price {
+		ch.Send("You can't afford that.\r\n")
+		return
+	}
 
-			totalPrice := listing.Price * quantity
+	if quantity > ch.getMaxItemsInventory()-ch.Inventory.Count {
+		ch.Send("You can't carry any more.\r\n")
+		return
+	}
 
-			objIndex := listing.Object
-			objects := make([]*ObjectInstance, 0, quantity)
+	totalPrice := listing.Price * quantity
 
-			for i := 0; i < quantity; i++ {
-				objects = append(objects, ch.Game.objectInstanceFromIndex(objIndex))
-			}
+	objIndex := listing.Object
+	objects := make([]*ObjectInstance, 0, quantity)
 
-			if !ch.canCarryObjects(objects) {
-				ch.Send("You can't carry that much weight.\r\n")
-				return
-			}
+	for i := 0; i < quantity; i++ {
+		objects = append(objects, ch.Game.objectInstanceFromIndex(objIndex))
+	}
 
-			err := ch.AttachObjects(objects)
-			if err != nil {
-				ch.Send("{RA mysterious force prevents you from buying that.{x\r\n")
-				return
-			}
+	if !ch.canCarryObjects(objects) {
+		ch.Send("You can't carry that much weight.\r\n")
+		return
+	}
 
-			for _, obj := range objects {
-				ch.AddObject(obj)
-				ch.Game.Objects.Insert(obj)
-			}
+	err = ch.AttachObjects(objects)
+	if err != nil {
+		ch.Send("{RA mysterious force prevents you from buying that.{x\r\n")
+		return
+	}
+
+	for _, obj := range objects {
+		ch.AddObject(obj)
+		ch.Game.Objects.Insert(obj)
+		ch.Game.AuditObject(obj, ObjectAuditSold, fmt.Sprintf("shop %d", shop.Id), auditCharacter(ch))
+	}
 
+	if quantity == 1 {
+		ch.Send(fmt.Sprintf("You buy %s for %d gold coins.\r\n", objects[0].GetShortDescription(ch), totalPrice))
+	} else {
+		ch.Send(fmt.Sprintf("You buy %d of %s for %d gold coins.\r\n", quantity, objects[0].GetShortDescription(ch), totalPrice))
+	}
+
+	for rch := range ch.Room.Characters.All() {
+		if !rch.IsEqual(ch) {
 			if quantity == 1 {
-				ch.Send(fmt.Sprintf("You buy %s for %d gold coins.\r\n", objects[0].GetShortDescription(ch), totalPrice))
+				rch.Send(fmt.Sprintf("%s buys %s.\r\n", ch.GetShortDescriptionUpper(rch), objects[0].GetShortDescription(rch)))
 			} else {
-				ch.Send(fmt.Sprintf("You buy %d of %s for %d gold coins.\r\n", quantity, objects[0].GetShortDescription(ch), totalPrice))
+				rch.Send(fmt.Sprintf("%s buys %d of %s.\r\n", ch.GetShortDescriptionUpper(rch), quantity, objects[0].GetShortDescription(rch)))
 			}
+		}
+	}
 
-			for rch := range ch.Room.Characters.All() {
-				if !rch.IsEqual(ch) {
-					if quantity == 1 {
-						rch.Send(fmt.Sprintf("%s buys %s.\r\n", ch.GetShortDescriptionUpper(rch), objects[0].GetShortDescription(rch)))
-					} else {
-						rch.Send(fmt.Sprintf("%s buys %d of %s.\r\n", ch.GetShortDescriptionUpper(rch), quantity, objects[0].GetShortDescription(rch)))
-					}
-				}
-			}
+	ch.Gold -= totalPrice
+	if listing.Stock > 0 {
+		listing.Quantity -= quantity
+	}
+}
 
-			ch.Gold -= totalPrice
-			return
+/* Secondhand goods are one of a kind, so each is bought alone */
+func buySecondhand(ch *Character, keeper *Character, shop *Shop, obj *ObjectInstance, quantity int) {
+	if quantity > 1 {
+		ch.Send(fmt.Sprintf("{C%s says \"I only have the one.{C\"{x\r\n", keeper.GetShortDescriptionUpper(ch)))
+		return
+	}
+
+	price := shop.resalePrice(obj)
+	if price > ch.Gold {
+		ch.Send("You can't afford that.\r\n")
+		return
+	}
+
+	if ch.Inventory.Count >= ch.getMaxItemsInventory() {
+		ch.Send("You can't carry any more.\r\n")
+		return
+	}
+
+	if !ch.canCarryObject(obj) {
+		ch.Send("You can't carry that much weight.\r\n")
+		return
+	}
+
+	err := shop.giveTo(ch, obj)
+	if err != nil {
+		ch.Send("{RA mysterious force prevents you from buying that.{x\r\n")
+		return
+	}
+
+	ch.Game.AuditObject(obj, ObjectAuditSold, fmt.Sprintf("shop %d", shop.Id), auditCharacter(ch))
+	ch.Gold -= price
+	ch.Send(fmt.Sprintf("You buy %s for %d gold coins.\r\n", obj.GetShortDescription(ch), price))
+
+	for rch := range ch.Room.Characters.All() {
+		if !rch.IsEqual(ch) {
+			rch.Send(fmt.Sprintf("%s buys %s.\r\n", ch.GetShortDescriptionUpper(rch), obj.GetShortDescription(rch)))
 		}
+	}
+}
 
-		count++
+/* Find an item ch may sell to keeper and what it fetches, or tell ch why not */
+func (ch *Character) findSaleable(keeper *Character, shop *Shop, argument string) (*ObjectInstance, int) {
+	obj := ch.FindObjectOnSelf(argument)
+	if obj == nil {
+		ch.Send("You don't have that.\r\n")
+		return nil, 0
+	}
+
+	price := shop.Appraise(obj)
+	if price < 1 {
+		ch.Send(fmt.Sprintf("{C%s says \"I'm not interested in %s{C.\"{x\r\n", keeper.GetShortDescriptionUpper(ch), obj.GetShortDescription(ch)))
+		return nil, 0
+	}
+
+	if obj.Contents != nil && obj.Contents.Count > 0 {
+		ch.Send(fmt.Sprintf("{C%s says \"Empty %s{C first.\"{x\r\n", keeper.GetShortDescriptionUpper(ch), obj.GetShortDescription(ch)))
+		return nil, 0
+	}
+
+	return obj, price
+}
+
+func do_value(ch *Character, arguments string) {
+	keeper, shop := ch.findOpenShop()
+	if shop == nil {
+		return
+	}
+
+	if len(arguments) < 1 {
+		ch.Send("Value what?\r\n")
+		return
+	}
+
+	obj, price := ch.findSaleable(keeper, shop, arguments)
+	if obj == nil {
+		return
+	}
+
+	ch.Send(fmt.Sprintf("{C%s says \"I'll give you %d gold coins for %s{C.\"{x\r\n", keeper.GetShortDescriptionUpper(ch), price, obj.GetShortDescription(ch)))
+}
+
+func do_sell(ch *Character, arguments string) {
+	keeper, shop := ch.findOpenShop()
+	if shop == nil {
+		return
+	}
+
+	if len(arguments) < 1 {
+		ch.Send("Sell what?\r\n")
+		return
+	}
+
+	obj, price := ch.findSaleable(keeper, shop, arguments)
+	if obj == nil {
+		return
+	}
+
+	if shop.Inventory.Count >= ShopInventoryCapacity {
+		ch.Send(fmt.Sprintf("{C%s says \"I've no room for anything more.{C\"{x\r\n", keeper.GetShortDescriptionUpper(ch)))
+		return
 	}
 
-	ch.Send("That doesn't seem to be for sale.\r\n")
+	err := shop.takeFrom(ch, obj)
+	if err != nil {
+		ch.Send("{RA mysterious force prevents you from selling that.{x\r\n")
+		return
+	}
+
+	ch.Game.AuditObject(obj, ObjectAuditSold, auditCharacter(ch), fmt.Sprintf("shop %d", shop.Id))
+	ch.Gold += price
+	ch.Send(fmt.Sprintf("You sell %s for %d gold coins.\r\n", obj.GetShortDescription(ch), price))
+
+	for rch := range ch.Room.Characters.All() {
+		if !rch.IsEqual(ch) {
+			rch.Send(fmt.Sprintf("%s sells %s.\r\n", ch.GetShortDescriptionUpper(rch), obj.GetShortDescription(rch)))
+		}
+	}
 }
 
 func do_shop(ch *Character, arguments string) {
 	var output strings.Builder
 	var count int = 1
 
-	shop := ch.FindShopInRoom()
+	_, shop := ch.findOpenShop()
 	if shop == nil {
-		ch.Send("You can't do that here.\r\n")
 		return
 	}
 
-	if shop.Listings == nil || shop.Listings.Count == 0 {
+	if shop.Listings.Count == 0 && shop.Inventory.Count == 0 {
 		ch.Send("There isn't anything for sale here.\r\n")
 		return
 	}
@@ -293,9 +892,25 @@ func do_shop(ch *Character, arguments string) {
 			continue
 		}
 
-		output.WriteString(fmt.Sprintf("{x%2d) %-32s {Y%5d gold coins\r\n", count, listing.Object.ShortDescription, listing.Price))
+		stock := ""
+		if listing.Stock > 0 && listing.Quantity < 1 {
+			stock = " {D(sold out)"
+		} else if listing.Stock > 0 {
+			stock = fmt.Sprintf(" {D(%d left)", listing.Quantity)
+		}
+
+		output.WriteString(fmt.Sprintf("{x%2d) %-32s {Y%5d gold coins%s{x\r\n", count, listing.Object.ShortDescription, listing.Price, stock))
 		count++
 	}
 
+	if shop.Inventory.Count > 0 {
+		output.WriteString("{WSecondhand goods:{x\r\n")
+
+		for obj := range shop.Inventory.All() {
+			output.WriteString(fmt.Sprintf("{x%2d) %-32s {Y%5d gold coins{x\r\n", count, obj.ShortDescription, shop.resalePrice(obj)))
+			count++
+		}
+	}
+
 	ch.Send(output.String())
 }
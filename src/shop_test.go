@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestShopHoursWrapPastMidnight(t *testing.T) {
+	shop := &Shop{OpenHour: 20, CloseHour: 4}
+
+	for hour, want := range map[int]bool{19: false, 20: true, 23: true, 0: true, 3: true, 4: false, 12: false} {
+		if got := shop.IsOpen(hour); got != want {
+			t.Errorf("open at %d is %v, want %v", hour, got, want)
+		}
+	}
+
+	shop.OpenHour, shop.CloseHour = 9, 17
+	if shop.IsOpen(8) || !shop.IsOpen(9) || shop.IsOpen(17) {
+		t.Error("a daytime shop kept the wrong hours")
+	}
+
+	shop.CloseHour = 9
+	if !shop.IsOpen(3) {
+		t.Error("a shop opening and closing at the same hour should never close")
+	}
+}
+
+func TestShopsValueGoodsByTheirOwnOrTheLowestListing(t *testing.T) {
+	game := &Game{shops: make(map[uint]*Shop)}
+
+	cutlass := &Object{Id: 6}
+	listing := func(price int) *LinkedList[*ShopListing] {
+		listings := NewLinkedList[*ShopListing]()
+		listings.Insert(&ShopListing{Object: cutlass, Price: price})
+
+		return listings
+	}
+
+	armoury := game.NewShop(1)
+	armoury.Listings = listing(200)
+	armoury.BuyTypes = ItemTypeWeapon
+
+	boutique := game.NewShop(2)
+	boutique.Listings = listing(5000)
+
+	pawnbroker := game.NewShop(3)
+	pawnbroker.BuyTypes = ItemTypeWeapon
+
+	for id, shop := range []*Shop{armoury, boutique, pawnbroker} {
+		game.shops[uint(id+1)] = shop
+	}
+
+	sword := &ObjectInstance{ParentId: cutlass.Id, ItemType: ItemTypeWeapon}
+
+	if got := armoury.Appraise(sword); got != 100 {
+		t.Errorf("the armoury pays %d for its own cutlass, want 100 from its 200 gold listing", got)
+	}
+
+	if got := pawnbroker.Appraise(sword); got != 100 {
+		t.Errorf("the pawnbroker pays %d for a cutlass, want 100 from the cheapest listing", got)
+	}
+
+	pawnbroker.BuyMargin = 150
+	if got := pawnbroker.Appraise(sword); got > pawnbroker.resalePrice(sword) {
+		t.Errorf("the pawnbroker pays %d for a cutlass it resells for %d", got, pawnbroker.resalePrice(sword))
+	}
+}
+
+func TestShopsKeepTheirListingsAndSecondhandGoodsAcrossAReboot(t *testing.T) {
+	game, err := newScriptTestGame(ScriptTestOptions{
+		ScriptRoot:    filepath.Join("..", "scripts"),
+		MigrationRoot: filepath.Join("..", "migrations"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer game.db.Close()
+
+	suite := &scriptTestSuite{game: game}
+	seller := suite.player("Pedlar", nil)
+	sword := suite.object(6, game.vm.ToValue(seller))
+
+	key, err := game.LoadObjectIndex(13)
+	if err != nil || key == nil {
+		t.Fatalf("could not load the brass key: %v", err)
+	}
+
+	shop := game.shops[1]
+	removed := shop.ListingAt(1)
+	shop.RemoveListing(removed)
+	shop.AddListing(key, 5, 2)
+	shop.ToggleBuyType(ItemTypeKey)
+	shop.OpenHour, shop.CloseHour = 8, 20
+
+	if err := shop.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shop.takeFrom(seller, sword); err != nil {
+		t.Fatal(err)
+	}
+
+	if seller.Inventory.Count != 0 || shop.Inventory.Count != 1 {
+		t.Fatalf("the sword did not change hands: %d carried, %d on the shelves", seller.Inventory.Count, shop.Inventory.Count)
+	}
+
+	reboot := newGame()
+	reboot.db = game.db
+
+	if err := reboot.loadWorld(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reboot.LoadShops(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := reboot.shops[1]
+	if reloaded == nil {
+		t.Fatal("the shop was not reloaded")
+	}
+
+	if reloaded.Listings.Count != shop.Listings.Count {
+		t.Errorf("reloaded %d listings, want %d", reloaded.Listings.Count, shop.Listings.Count)
+	}
+
+	var listed bool
+	for listing := range reloaded.Listings.All() {
+		if listing.Object != nil && listing.Object.Id == removed.Object.Id {
+			t.Error("a removed listing came back")
+		}
+
+		if listing.Object != nil && listing.Object.Id == 13 {
+			listed = listing.Price == 5 && listing.Stock == 2 && listing.Quantity == 2
+		}
+	}
+
+	if !listed {
+		t.Error("the new key listing was not saved with its price and stock")
+	}
+
+	if !reloaded.Buys(ItemTypeKey) || !reloaded.Buys(ItemTypeWeapon) || reloaded.OpenHour != 8 || reloaded.CloseHour != 20 {
+		t.Errorf("the shop forgot its terms: buys %q from %d to %d", reloaded.BuyTypes, reloaded.OpenHour, reloaded.CloseHour)
+	}
+
+	if reloaded.Inventory.Count != 1 || reloaded.Inventory.Head.Value.Serial != sword.Serial {
+		t.Error("the secondhand sword was not kept on the shelves")
+	}
+}
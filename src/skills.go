@@ -23,6 +23,9 @@ type Skill struct {
 	SkillType int
 	Intent    string
 	Handler   *goja.Callable
+
+	/* Script module that registered the handler */
+	handlerModule string
 }
 
 const (
@@ -75,6 +78,7 @@ func (game *Game) RegisterSkillHandler(name string, fn goja.Callable) goja.Value
 	}
 
 	skill.Handler = &fn
+	skill.handlerModule = game.currentScriptModule()
 	return game.vm.ToValue(skill)
 }
 
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+const (
+	/* Stamina spent entering a room without terrain, and the most a flying character ever pays */
+	DefaultMovementCost = 2
+
+	/* Milliseconds of wait-state per point of movement cost after climbing into rugged terrain */
+	RuggedTerrainDelay = 50
+)
+
+func FindTerrain(id int) *Terrain {
+	return TerrainTable[id]
+}
+
+/* The terrain underfoot in a dungeon cell or planar room; ordinary rooms have none */
+func (room *Room) Terrain() *Terrain {
+	if room == nil {
+		return nil
+	}
+
+	if room.Cell != nil {
+		return TerrainTable[room.Cell.Terrain]
+	}
+
+	layer, ok := room.planarLayer()
+	if !ok || room.Y < 0 || room.Y >= len(layer.Terrain) || room.X < 0 || room.X >= len(layer.Terrain[room.Y]) {
+		return nil
+	}
+
+	return TerrainTable[layer.Terrain[room.Y][room.X]]
+}
+
+/* Whether anyone at all may walk here; walls and city blocks cost -1 or are flagged impassable */
+func (terrain *Terrain) IsPassable() bool {
+	return terrain == nil || (terrain.Flags&TERRAIN_IMPASSABLE == 0 && terrain.MovementCost >= 0)
+}
+
+/* Flying, swimming, or carrying a boat */
+func (ch *Character) CanCrossDeepWater() bool {
+	if ch.Affected&AFFECT_FLYING != 0 {
+		return true
+	}
+
+	if swim := ch.FindProficiencyByName("swim"); swim != nil && swim.Proficiency > 0 {
+		return true
+	}
+
+	if ch.Inventory != nil {
+		for obj := range ch.Inventory.All() {
+			if obj.ItemType == ItemTypeBoat {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+/* Why ch may not enter terrain, or the empty string if it may; immortals go anywhere */
+func (ch *Character) terrainRefusal(terrain *Terrain) string {
+	if terrain == nil || ch.Level > LevelHero {
+		return ""
+	}
+
+	if !terrain.IsPassable() {
+		return "Alas, you cannot go that way."
+	}
+
+	if terrain.Flags&TERRAIN_DEEP_WATER != 0 && !ch.CanCrossDeepWater() {
+		return "You need a boat to go there."
+	}
+
+	return ""
+}
+
+func (ch *Character) CanTraverse(terrain *Terrain) bool {
+	return ch.terrainRefusal(terrain) == ""
+}
+
+/* Stamina ch spends entering terrain; flying characters glide over the rough ground */
+func (ch *Character) MovementCost(terrain *Terrain) int {
+	cost := DefaultMovementCost
+	if terrain != nil && terrain.MovementCost >= 0 {
+		cost = terrain.MovementCost
+	}
+
+	if ch.Affected&AFFECT_FLYING != 0 {
+		cost = min(cost, DefaultMovementCost)
+	}
+
+	return cost
+}
+
+/* Milliseconds ch must wait after entering terrain before acting again */
+func (ch *Character) terrainDelay(terrain *Terrain) int {
+	if terrain == nil || terrain.Flags&TERRAIN_RUGGED == 0 || ch.Affected&AFFECT_FLYING != 0 || ch.Level > LevelHero {
+		return 0
+	}
+
+	return ch.MovementCost(terrain) * RuggedTerrainDelay
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import "testing"
+
+func withTestTerrain(t *testing.T) {
+	terrain := TerrainTable
+	TerrainTable = map[int]*Terrain{
+		TerrainTypeCaveWall:              {Id: TerrainTypeCaveWall, MovementCost: -1},
+		TerrainTypeCaveTunnel:            {Id: TerrainTypeCaveTunnel, MovementCost: 2},
+		TerrainTypeOcean:                 {Id: TerrainTypeOcean, MovementCost: 8, Flags: TERRAIN_DEEP_WATER},
+		TerrainTypeShallowWater:          {Id: TerrainTypeShallowWater, MovementCost: 6, Flags: TERRAIN_SHALLOW_WATER},
+		TerrainTypeMountains:             {Id: TerrainTypeMountains, MovementCost: 20, Flags: TERRAIN_RUGGED},
+		TerrainTypeOverworldCityInterior: {Id: TerrainTypeOverworldCityInterior, MovementCost: -1, Flags: TERRAIN_IMPASSABLE},
+	}
+
+	t.Cleanup(func() { TerrainTable = terrain })
+}
+
+func TestTerrainDecidesWhoMayTravelAndAtWhatCost(t *testing.T) {
+	withTestTerrain(t)
+
+	game := &Game{skills: map[uint]*Skill{24: {Id: 24, Name: "swim"}}}
+	ch := newCombatTestCharacter(game, 10)
+
+	ocean, mountains := FindTerrain(TerrainTypeOcean), FindTerrain(TerrainTypeMountains)
+
+	if ch.CanTraverse(FindTerrain(TerrainTypeOverworldCityInterior)) || ch.CanTraverse(FindTerrain(TerrainTypeCaveWall)) {
+		t.Error("walked into impassable terrain")
+	}
+
+	if ch.CanTraverse(ocean) || !ch.CanTraverse(FindTerrain(TerrainTypeShallowWater)) {
+		t.Error("deep water should need a boat, shallow water should not")
+	}
+
+	if got := ch.MovementCost(mountains); got != 20 || ch.terrainDelay(mountains) != 20*RuggedTerrainDelay {
+		t.Errorf("mountains cost %d stamina and %dms, want 20 and %dms", got, ch.terrainDelay(mountains), 20*RuggedTerrainDelay)
+	}
+
+	if got := ch.MovementCost(nil); got != DefaultMovementCost {
+		t.Errorf("a room without terrain costs %d, want %d", got, DefaultMovementCost)
+	}
+
+	ch.Skills[24] = &Proficiency{SkillId: 24, Proficiency: 1}
+	if !ch.CanTraverse(ocean) {
+		t.Error("a swimmer could not cross deep water")
+	}
+
+	delete(ch.Skills, 24)
+	ch.AddObject(&ObjectInstance{ItemType: ItemTypeBoat})
+	if !ch.CanTraverse(ocean) {
+		t.Error("a sailor could not cross deep water")
+	}
+
+	flier := newCombatTestCharacter(game, 10)
+	flier.Affected |= AFFECT_FLYING
+	if !flier.CanTraverse(ocean) || flier.MovementCost(mountains) != DefaultMovementCost || flier.terrainDelay(mountains) != 0 {
+		t.Error("flying did not carry the character over water and mountains")
+	}
+}
+
+func TestPathfindingAvoidsTerrainTheTravellerCannotCross(t *testing.T) {
+	withTestTerrain(t)
+
+	game := &Game{skills: map[uint]*Skill{}}
+	maze := game.NewMaze(5, 5)
+
+	/* An open three by three floor with deep water between the two ends of its top row */
+	for y := 1; y <= 3; y++ {
+		for x := 1; x <= 3; x++ {
+			maze.Grid[x][y].Wall = false
+			maze.Grid[x][y].Terrain = TerrainTypeCaveTunnel
+		}
+	}
+
+	maze.Grid[2][1].Terrain = TerrainTypeOcean
+	start, end := maze.cellAt(1, 1), maze.cellAt(3, 1)
+
+	walker := newCombatTestCharacter(game, 10)
+	if path := maze.findPathAStar(start, end, walker); len(path) != 5 || path[0].gScore != 4*DefaultMovementCost {
+		t.Errorf("walked a path of %d cells, want around the water in 5", len(path))
+	}
+
+	maze.Grid[2][2].Terrain = TerrainTypeCaveWall
+	if path := maze.findPathAStar(start, end, walker); len(path) != 7 || path[0].gScore != 6*DefaultMovementCost {
+		t.Errorf("walked a path of %d cells, want the long way round in 7", len(path))
+	}
+
+	walker.AddObject(&ObjectInstance{ItemType: ItemTypeBoat})
+	if path := maze.findPathAStar(start, end, walker); len(path) != 3 || path[0].gScore != 8+DefaultMovementCost {
+		t.Errorf("sailed a path of %d cells, want straight across in 3", len(path))
+	}
+
+	maze.Grid[2][3].Terrain = TerrainTypeCaveWall
+	walker.RemoveObject(walker.Inventory.Head.Value)
+	if path := maze.findPathAStar(start, end, walker); len(path) != 0 {
+		t.Errorf("found a path of %d cells through impassable terrain", len(path))
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import "fmt"
+
+/* How much a mobile wants to fight one character, kept in the order they first drew its attention */
+type threatEntry struct {
+	source *Character
+	amount int
+}
+
+/* Add to the threat source holds with a mobile; players keep no threat table */
+func (ch *Character) AddThreat(source *Character, amount int) {
+	if ch == nil || source == nil || ch == source || ch.Flags&CHAR_IS_PLAYER != 0 {
+		return
+	}
+
+	for i := range ch.threat {
+		if ch.threat[i].source == source {
+			ch.threat[i].amount = max(0, ch.threat[i].amount+amount)
+			return
+		}
+	}
+
+	ch.threat = append(ch.threat, threatEntry{source: source, amount: max(0, amount)})
+}
+
+func (ch *Character) Threat(source *Character) int {
+	for _, entry := range ch.threat {
+		if entry.source == source {
+			return entry.amount
+		}
+	}
+
+	return 0
+}
+
+func (ch *Character) ClearThreat() {
+	ch.threat = nil
+}
+
+/*
+ * The character in the room holding the most threat.  The current target
+ * keeps the mobile's attention on a tie, then whoever drew it first.
+ */
+func (ch *Character) TopThreat() *Character {
+	var top *Character
+	best := -1
+
+	if ch.Fighting != nil && ch.Fighting.Room != nil && ch.Fighting.Room == ch.Room {
+		top = ch.Fighting
+		best = ch.Threat(top)
+	}
+
+	for _, entry := range ch.threat {
+		if entry.source.Room == nil || entry.source.Room != ch.Room {
+			continue
+		}
+
+		if entry.amount > best {
+			top = entry.source
+			best = entry.amount
+		}
+	}
+
+	return top
+}
+
+/* Mobiles fighting target, or holding threat from it, take a dislike to whoever heals it */
+func (game *Game) addHealingThreat(healer *Character, target *Character, healed int) {
+	if healer == nil || target == nil || target.Room == nil || healed <= 0 {
+		return
+	}
+
+	for rch := range target.Room.Characters.All() {
+		if rch == healer || rch.Flags&CHAR_IS_PLAYER != 0 || rch.Fighting == nil {
+			continue
+		}
+
+		if rch.Fighting == target || rch.Threat(target) > 0 {
+			rch.AddThreat(healer, max(1, healed/2))
+		}
+	}
+}
+
+/* Mobiles turn on whoever holds the most threat with them, unless a target hook picks someone else */
+func (game *Game) combatSelectTarget(ch *Character) {
+	target := ch.Fighting
+	if ch.Flags&CHAR_IS_PLAYER == 0 && len(ch.threat) > 0 {
+		target = ch.TopThreat()
+	}
+
+	var current interface{}
+	if target != nil {
+		current = target
+	}
+
+	result := game.callCombatHook(CombatStepTarget, ch, current)
+	if result != nil {
+		if chosen, ok := result.Export().(*Character); ok {
+			target = chosen
+		}
+	}
+
+	if target == nil || target == ch || target == ch.Fighting || ch.Room == nil || target.Room != ch.Room {
+		return
+	}
+
+	target.Send(fmt.Sprintf("{R%s{R turns to attack you!{x\r\n", ch.GetShortDescriptionUpper(target)))
+	for rch := range ch.Room.Characters.All() {
+		if rch != ch && rch != target {
+			rch.Send(fmt.Sprintf("{R%s{R turns to attack %s{R!{x\r\n", ch.GetShortDescriptionUpper(rch), target.GetShortDescription(rch)))
+		}
+	}
+
+	ch.Fighting = target
+	if ch.Combat != nil {
+		game.AddCombatParticipant(ch.Combat, target)
+	}
+}
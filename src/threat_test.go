@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import "testing"
+
+func TestThreatAccumulatesAndNeverGoesNegative(t *testing.T) {
+	game := &Game{}
+	mob := newCombatTestCharacter(game, 10)
+	player := newCombatTestCharacter(game, 10)
+	player.Flags |= CHAR_IS_PLAYER
+
+	mob.AddThreat(player, 10)
+	mob.AddThreat(player, 5)
+	if got := mob.Threat(player); got != 15 {
+		t.Fatalf("threat is %d, want 15", got)
+	}
+
+	mob.AddThreat(player, -40)
+	if got := mob.Threat(player); got != 0 {
+		t.Errorf("threat is %d after dropping below zero, want 0", got)
+	}
+
+	player.AddThreat(mob, 10)
+	if got := player.Threat(mob); got != 0 {
+		t.Errorf("a player holds %d threat, want players to keep no table", got)
+	}
+}
+
+func TestTopThreatPrefersTheCurrentTargetOnTies(t *testing.T) {
+	game := &Game{}
+	tank, mob := newCombatTestFight(game)
+	healer := newCombatTestCharacter(game, 10)
+	away := newCombatTestCharacter(game, 10)
+	tank.Room.AddCharacter(healer)
+
+	mob.Fighting = healer
+	mob.AddThreat(tank, 20)
+	mob.AddThreat(healer, 20)
+	mob.AddThreat(away, 100)
+
+	if top := mob.TopThreat(); top != healer {
+		t.Errorf("the mobile's top threat on a tie is %v, want its current target", top)
+	}
+
+	mob.AddThreat(tank, 1)
+	if top := mob.TopThreat(); top != tank {
+		t.Errorf("the mobile's top threat is %v, want the tank who outpaced the healer", top)
+	}
+}
+
+func TestHealingDrawsThreatFromTheHealedsAttackers(t *testing.T) {
+	game := &Game{}
+	tank, mob := newCombatTestFight(game)
+	healer := newCombatTestCharacter(game, 10)
+	tank.Room.AddCharacter(healer)
+	tank.Health = 900
+
+	game.Damage(mob, tank, false, 30, DamageTypeTrue)
+	game.Damage(healer, tank, false, -100, DamageTypeTrue)
+
+	if got := mob.Threat(healer); got != 50 {
+		t.Errorf("healing 100 drew %d threat, want 50", got)
+	}
+
+	tank.Health = tank.MaxHealth
+	game.Damage(healer, tank, false, -100, DamageTypeTrue)
+	if got := mob.Threat(healer); got != 50 {
+		t.Errorf("healing a character at full health drew threat to %d, want it to stay at 50", got)
+	}
+}
+
+func TestCombatSelectTargetTurnsToTopThreat(t *testing.T) {
+	game := &Game{}
+	tank, mob := newCombatTestFight(game)
+	healer := newCombatTestCharacter(game, 10)
+	tank.Room.AddCharacter(healer)
+
+	mob.AddThreat(tank, 10)
+	mob.AddThreat(healer, 30)
+	game.combatSelectTarget(mob)
+
+	if mob.Fighting != healer {
+		t.Fatalf("the mobile is fighting %v, want the healer", mob.Fighting)
+	}
+
+	if healer.Combat != mob.Combat {
+		t.Error("the healer was not brought into the mobile's fight")
+	}
+
+	mob.ClearThreat()
+	mob.Fighting = tank
+	game.combatSelectTarget(mob)
+
+	if mob.Fighting != tank {
+		t.Error("a mobile with no threat table changed its target")
+	}
+}
@@ -26,7 +26,7 @@ func (game *Game) characterUpdate() {
 			if fx.Duration != EffectDurationPermanent && int(time.Since(fx.CreatedAt).Seconds()) >= fx.Duration {
 				if fx.OnComplete != nil {
 					affected := game.vm.ToValue(ch)
-					_, err := (*fx.OnComplete)(affected, affected)
+					_, err := game.callScriptFunction(ScriptHookEffect, "", *fx.OnComplete, affected, affected)
 					if err != nil {
 						log.Println(err)
 					}
@@ -100,6 +100,7 @@ func (game *Game) decayObject(obj *ObjectInstance) {
 	game.Objects.Remove(obj)
 
 	game.syncDecayedContainerContents(movedContents, location)
+	game.AuditObject(obj, ObjectAuditDecayed, location.auditOwner(), "")
 
 	if err := game.deletePersistedObjectInstance(obj); err != nil {
 		log.Printf("Warning: failed to delete decayed object instance %d: %v\r\n", obj.Id, err)
@@ -292,12 +293,16 @@ func (game *Game) Update() {
 	for ch := range game.Characters.All() {
 		ch.onUpdate()
 	}
+
+	game.pvpUpdate(int(updatePulse / time.Second))
+	game.mobileTickTriggers()
 }
 
 func (game *Game) ZoneUpdate() {
 	for zone := range game.Zones.All() {
 		if time.Since(zone.LastReset).Minutes() > float64(zone.ResetFrequency) {
 			game.ResetZone(zone)
+			game.RestockShops(zone)
 		}
 	}
 
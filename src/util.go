@@ -188,10 +188,22 @@ func EffectDescription(fx *Effect) string {
 			AffectedFlagNames(fx.Bits),
 			EffectDurationDescription(fx))
 	case EffectTypeImmunity:
-		return fmt.Sprintf("%s level %d grants immunity bits %d for %s",
+		return fmt.Sprintf("%s level %d grants immunity to %s for %s",
 			fx.Name,
 			fx.Level,
-			fx.Bits,
+			DamageFlagNames(fx.Bits),
+			EffectDurationDescription(fx))
+	case EffectTypeResist:
+		return fmt.Sprintf("%s level %d grants resistance to %s for %s",
+			fx.Name,
+			fx.Level,
+			DamageFlagNames(fx.Bits),
+			EffectDurationDescription(fx))
+	case EffectTypeSuscept:
+		return fmt.Sprintf("%s level %d causes susceptibility to %s for %s",
+			fx.Name,
+			fx.Level,
+			DamageFlagNames(fx.Bits),
 			EffectDurationDescription(fx))
 	default:
 		return fmt.Sprintf("%s level %d type %d for %s",
@@ -0,0 +1,547 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const (
+	WorldIssueExit   = "exit"
+	WorldIssueObject = "object"
+	WorldIssueReset  = "reset"
+	WorldIssueRoom   = "room"
+	WorldIssueScript = "script"
+	WorldIssueShop   = "shop"
+)
+
+type WorldIssue struct {
+	Kind    string
+	Message string
+}
+
+/* Each check selects the integer columns consumed by its format string, one row per problem */
+type worldCheck struct {
+	kind   string
+	format string
+	query  string
+}
+
+var worldChecks = []worldCheck{
+	{
+		kind:   WorldIssueExit,
+		format: "exit %d in room %d leads to missing or deleted room %d",
+		query: `
+		SELECT
+			exits.id,
+			exits.room_id,
+			exits.to_room_id
+		FROM
+			exits
+		LEFT JOIN
+			rooms
+		ON
+			rooms.id = exits.to_room_id
+		AND
+			rooms.deleted_at IS NULL
+		WHERE
+			exits.deleted_at IS NULL
+		AND
+			rooms.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueExit,
+		format: "exit %d belongs to missing or deleted room %d",
+		query: `
+		SELECT
+			exits.id,
+			exits.room_id
+		FROM
+			exits
+		LEFT JOIN
+			rooms
+		ON
+			rooms.id = exits.room_id
+		AND
+			rooms.deleted_at IS NULL
+		WHERE
+			exits.deleted_at IS NULL
+		AND
+			rooms.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueReset,
+		format: "mobile reset %d in room %d references missing or deleted mobile %d",
+		query: `
+		SELECT
+			resets.id,
+			resets.room_id,
+			resets.value_1
+		FROM
+			resets
+		LEFT JOIN
+			mobiles
+		ON
+			mobiles.id = resets.value_1
+		AND
+			mobiles.deleted_at IS NULL
+		WHERE
+			resets.deleted_at IS NULL
+		AND
+			resets.type = 'mobile'
+		AND
+			mobiles.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueReset,
+		format: "object reset %d in room %d references missing or deleted object %d",
+		query: `
+		SELECT
+			resets.id,
+			resets.room_id,
+			resets.value_1
+		FROM
+			resets
+		LEFT JOIN
+			objects
+		ON
+			objects.id = resets.value_1
+		AND
+			objects.deleted_at IS NULL
+		WHERE
+			resets.deleted_at IS NULL
+		AND
+			resets.type = 'object'
+		AND
+			objects.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueReset,
+		format: "reset %d references missing or deleted room %d",
+		query: `
+		SELECT
+			resets.id,
+			resets.room_id
+		FROM
+			resets
+		LEFT JOIN
+			rooms
+		ON
+			rooms.id = resets.room_id
+		AND
+			rooms.deleted_at IS NULL
+		WHERE
+			resets.deleted_at IS NULL
+		AND
+			rooms.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueRoom,
+		format: "room %d lies outside the range %d-%d of its zone %d",
+		query: `
+		SELECT
+			rooms.id,
+			zones.low,
+			zones.high,
+			zones.id
+		FROM
+			rooms
+		INNER JOIN
+			zones
+		ON
+			zones.id = rooms.zone_id
+		WHERE
+			rooms.deleted_at IS NULL
+		AND
+			(rooms.id < zones.low OR rooms.id > zones.high)
+		`,
+	},
+	{
+		kind:   WorldIssueRoom,
+		format: "room %d belongs to missing or deleted zone %d",
+		query: `
+		SELECT
+			rooms.id,
+			rooms.zone_id
+		FROM
+			rooms
+		LEFT JOIN
+			zones
+		ON
+			zones.id = rooms.zone_id
+		AND
+			zones.deleted_at IS NULL
+		WHERE
+			rooms.deleted_at IS NULL
+		AND
+			zones.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueScript,
+		format: "script %d is bound to missing or deleted mobile %d",
+		query: `
+		SELECT
+			mobile_script.script_id,
+			mobile_script.mobile_id
+		FROM
+			mobile_script
+		LEFT JOIN
+			mobiles
+		ON
+			mobiles.id = mobile_script.mobile_id
+		AND
+			mobiles.deleted_at IS NULL
+		WHERE
+			mobiles.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueScript,
+		format: "script %d is bound to missing or deleted object %d",
+		query: `
+		SELECT
+			object_script.script_id,
+			object_script.object_id
+		FROM
+			object_script
+		LEFT JOIN
+			objects
+		ON
+			objects.id = object_script.object_id
+		AND
+			objects.deleted_at IS NULL
+		WHERE
+			objects.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueScript,
+		format: "script %d is bound to missing or deleted room %d",
+		query: `
+		SELECT
+			room_script.script_id,
+			room_script.room_id
+		FROM
+			room_script
+		LEFT JOIN
+			rooms
+		ON
+			rooms.id = room_script.room_id
+		AND
+			rooms.deleted_at IS NULL
+		WHERE
+			rooms.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueScript,
+		format: "script %d is bound to missing plane %d",
+		query: `
+		SELECT
+			plane_script.script_id,
+			plane_script.plane_id
+		FROM
+			plane_script
+		LEFT JOIN
+			planes
+		ON
+			planes.id = plane_script.plane_id
+		WHERE
+			planes.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueScript,
+		format: "script %d is bound to missing webhook %d",
+		query: `
+		SELECT
+			webhook_script.script_id,
+			webhook_script.webhook_id
+		FROM
+			webhook_script
+		LEFT JOIN
+			webhooks
+		ON
+			webhooks.id = webhook_script.webhook_id
+		WHERE
+			webhooks.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueScript,
+		format: "script %d is bound to missing district %d",
+		query: `
+		SELECT
+			district_script.script_id,
+			district_script.district_id
+		FROM
+			district_script
+		LEFT JOIN
+			districts
+		ON
+			districts.id = district_script.district_id
+		WHERE
+			districts.id IS NULL
+		`,
+	},
+	{
+		kind:   WorldIssueShop,
+		format: "shop %d is kept by mobile %d which is never reset into the world",
+		query: `
+		SELECT
+			shops.id,
+			shops.mobile_id
+		FROM
+			shops
+		LEFT JOIN
+			resets
+		ON
+			resets.type = 'mobile'
+		AND
+			resets.value_1 = shops.mobile_id
+		AND
+			resets.deleted_at IS NULL
+		WHERE
+			resets.id IS NULL
+		`,
+	},
+}
+
+/* Validate world references directly against the database so the check also works offline */
+func CheckWorld(db *sql.DB) ([]WorldIssue, error) {
+	issues := make([]WorldIssue, 0)
+
+	for _, check := range worldChecks {
+		found, err := runWorldCheck(db, check)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, found...)
+	}
+
+	found, err := checkOneWayExits(db)
+	if err != nil {
+		return nil, err
+	}
+
+	issues = append(issues, found...)
+
+	found, err = checkDuplicateObjectSerials(db)
+	if err != nil {
+		return nil, err
+	}
+
+	issues = append(issues, found...)
+	return issues, nil
+}
+
+func runWorldCheck(db *sql.DB, check worldCheck) ([]WorldIssue, error) {
+	rows, err := db.Query(check.query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]WorldIssue, 0)
+
+	for rows.Next() {
+		values := make([]sql.NullInt64, len(columns))
+		destinations := make([]interface{}, len(columns))
+		for i := range values {
+			destinations[i] = &values[i]
+		}
+
+		err := rows.Scan(destinations...)
+		if err != nil {
+			return nil, err
+		}
+
+		args := make([]interface{}, len(values))
+		for i, value := range values {
+			args[i] = value.Int64
+		}
+
+		issues = append(issues, WorldIssue{Kind: check.kind, Message: fmt.Sprintf(check.format, args...)})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+/* An exit with no counterpart leading back the opposite way is reported as one-way */
+func checkOneWayExits(db *sql.DB) ([]WorldIssue, error) {
+	type exitKey struct {
+		roomId    uint
+		toRoomId  uint
+		direction uint
+	}
+
+	rows, err := db.Query(`
+		SELECT
+			exits.id,
+			exits.room_id,
+			exits.to_room_id,
+			exits.direction
+		FROM
+			exits
+		INNER JOIN
+			rooms
+		ON
+			rooms.id = exits.to_room_id
+		AND
+			rooms.deleted_at IS NULL
+		WHERE
+			exits.deleted_at IS NULL
+		ORDER BY
+			exits.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	exits := make(map[exitKey]uint)
+	ordered := make([]exitKey, 0)
+
+	for rows.Next() {
+		var id uint
+		var key exitKey
+
+		err := rows.Scan(&id, &key.roomId, &key.toRoomId, &key.direction)
+		if err != nil {
+			return nil, err
+		}
+
+		exits[key] = id
+		ordered = append(ordered, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	issues := make([]WorldIssue, 0)
+
+	for _, key := range ordered {
+		reverse, ok := ReverseDirection[key.direction]
+		if !ok {
+			issues = append(issues, WorldIssue{
+				Kind:    WorldIssueExit,
+				Message: fmt.Sprintf("exit %d in room %d has invalid direction %d", exits[key], key.roomId, key.direction),
+			})
+			continue
+		}
+
+		_, ok = exits[exitKey{roomId: key.toRoomId, toRoomId: key.roomId, direction: reverse}]
+		if ok {
+			continue
+		}
+
+		issues = append(issues, WorldIssue{
+			Kind:    WorldIssueExit,
+			Message: fmt.Sprintf("exit %d %s from room %d to room %d has no %s exit back", exits[key], ExitName[key.direction], key.roomId, key.toRoomId, ExitName[reverse]),
+		})
+	}
+
+	return issues, nil
+}
+
+func summarizeWorldIssues(issues []WorldIssue) string {
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		counts[issue.Kind]++
+	}
+
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+/* Startup only reports problems; FixExits and LoadResets decide what is fatal */
+func (game *Game) checkWorldAtStartup() {
+	log.Printf("Checking world integrity.\r\n")
+
+	issues, err := CheckWorld(game.db)
+	if err != nil {
+		log.Printf("Unable to check world integrity: %v.\r\n", err)
+		return
+	}
+
+	for _, issue := range issues {
+		log.Printf("Warning: world check: %s.\r\n", issue.Message)
+	}
+
+	if len(issues) > 0 {
+		log.Printf("World check found %d issues (%s).\r\n", len(issues), summarizeWorldIssues(issues))
+		return
+	}
+
+	log.Printf("World check found no issues.\r\n")
+}
+
+func do_checkworld(ch *Character, arguments string) {
+	filter, _ := OneArgument(arguments)
+	filter = strings.ToLower(filter)
+
+	issues, err := CheckWorld(ch.Game.db)
+	if err != nil {
+		ch.Send(fmt.Sprintf("{RUnable to check world integrity: %v{x\r\n", err))
+		return
+	}
+
+	var output strings.Builder
+	shown := 0
+
+	for _, issue := range issues {
+		if filter != "" && issue.Kind != filter {
+			continue
+		}
+
+		output.WriteString(fmt.Sprintf("{Y[%-6s] {w%s{x\r\n", issue.Kind, issue.Message))
+		shown++
+	}
+
+	if len(issues) == 0 {
+		output.WriteString("{GNo world integrity issues found.{x\r\n")
+	} else {
+		output.WriteString(fmt.Sprintf("\r\n{W%d of %d issues shown (%s).{x\r\n", shown, len(issues), summarizeWorldIssues(issues)))
+	}
+
+	ch.Send(output.String())
+}
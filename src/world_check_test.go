@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2021 James Skarzinskas.
+ * All rights reserved.
+ * See LICENSE.txt in project root for license information.
+ * Authors:
+ *     James Skarzinskas <james@jskarzin.org>
+ */
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheckWorldReportsSeededProblems(t *testing.T) {
+	db := newCommandTestDatabase(t)
+
+	issues, err := CheckWorld(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 0 {
+		t.Fatalf("the seed world has %d issues: %v", len(issues), issues)
+	}
+
+	statements := []string{
+		`INSERT INTO exits (id, room_id, to_room_id, direction, flags) VALUES (9001, 1, 999999, 0, 0)`,
+		`INSERT INTO resets (id, zone_id, room_id, type, value_1) VALUES (9002, (SELECT MIN(id) FROM zones), 1, 'object', 999998)`,
+		`INSERT INTO object_instances (id, parent_id, name, short_description, long_description, serial) VALUES (9003, 1, 'dupe', 'a dupe', 'A dupe lies here.', 'cafebabe'), (9004, 1, 'dupe', 'a dupe', 'A dupe lies here.', 'cafebabe')`,
+		`INSERT INTO player_character_object (player_character_id, object_instance_id) VALUES (1, 9003), (1, 9004)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err = CheckWorld(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reported := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		reported = append(reported, fmt.Sprintf("[%s] %s", issue.Kind, issue.Message))
+	}
+
+	all := strings.Join(reported, "\n")
+
+	for _, want := range []string{
+		"[exit] exit 9001 in room 1 leads to missing or deleted room 999999",
+		"[reset] object reset 9002 in room 1 references missing or deleted object 999998",
+		"[object] object serial cafebabe is held 2 times by Admin",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing %q among the issues:\n%s", want, all)
+		}
+	}
+}
@@ -151,6 +151,7 @@ func (game *Game) ResetRoom(room *Room) {
 
 				room.AddObject(obj)
 				game.Objects.Insert(obj)
+				game.AuditObject(obj, ObjectAuditCreated, "", auditRoom(room))
 			}
 
 		case ResetTypeMobile: